@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -14,10 +16,12 @@ import (
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/keystore"
 	"github.com/MixinNetwork/mixin/rpc"
 	"github.com/MixinNetwork/mixin/storage"
 	"github.com/MixinNetwork/mixin/util"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 )
 
 func createAddressCmd(c *cli.Context) error {
@@ -152,6 +156,36 @@ func removeGraphEntries(c *cli.Context) error {
 	return err
 }
 
+func rebuildAssetSupply(c *cli.Context) error {
+	custom, err := config.Initialize(c.String("dir") + "/config.toml")
+	if err != nil {
+		return err
+	}
+	store, err := storage.NewBadgerStore(custom, c.String("dir"))
+	if err != nil {
+		return err
+	}
+	defer util.CloseOrPanic(store)
+	count, err := store.RebuildAssetSupply()
+	fmt.Printf("rebuilt %d supply changes with %v\n", count, err)
+	return err
+}
+
+func rebuildTransactionIndex(c *cli.Context) error {
+	custom, err := config.Initialize(c.String("dir") + "/config.toml")
+	if err != nil {
+		return err
+	}
+	store, err := storage.NewBadgerStore(custom, c.String("dir"))
+	if err != nil {
+		return err
+	}
+	defer util.CloseOrPanic(store)
+	count, err := store.RebuildTransactionIndex()
+	fmt.Printf("indexed %d snapshots with %v\n", count, err)
+	return err
+}
+
 func validateGraphEntries(c *cli.Context) error {
 	custom, err := config.Initialize(c.String("dir") + "/config.toml")
 	if err != nil {
@@ -215,20 +249,10 @@ func buildRawTransactionCmd(c *cli.Context) error {
 		crypto.ReadRand(seed)
 	}
 
-	viewKey, err := crypto.KeyFromString(c.String("view"))
+	account, err := signingAccount(c)
 	if err != nil {
 		return err
 	}
-	spendKey, err := crypto.KeyFromString(c.String("spend"))
-	if err != nil {
-		return err
-	}
-	account := common.Address{
-		PrivateViewKey:  viewKey,
-		PrivateSpendKey: spendKey,
-		PublicViewKey:   viewKey.Public(),
-		PublicSpendKey:  spendKey.Public(),
-	}
 
 	asset, err := crypto.HashFromString(c.String("asset"))
 	if err != nil {
@@ -295,8 +319,16 @@ func buildRawTransactionCmd(c *cli.Context) error {
 	tx.Extra = extra
 
 	signed := tx.AsVersioned()
+	if c.Bool("aggregate") {
+		err = aggregateSign(signed, raw, []*common.Address{account})
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(signed.Marshal()))
+		return nil
+	}
 	for i := range tx.Inputs {
-		err = signed.SignInput(raw, i, []*common.Address{&account})
+		err = signed.SignInput(raw, i, []*common.Address{account})
 		if err != nil {
 			return err
 		}
@@ -362,23 +394,20 @@ func signTransactionCmd(c *cli.Context) error {
 	}
 	tx.Extra = extra
 
-	keys := c.StringSlice("key")
-	var accounts []*common.Address
-	for _, s := range keys {
-		key, err := hex.DecodeString(s)
+	accounts, err := signingAccounts(c)
+	if err != nil {
+		return err
+	}
+
+	signed := tx.AsVersioned()
+	if c.Bool("aggregate") {
+		err = aggregateSign(signed, raw, accounts)
 		if err != nil {
 			return err
 		}
-		if len(key) != 64 {
-			return fmt.Errorf("invalid key length %d", len(key))
-		}
-		var account common.Address
-		copy(account.PrivateViewKey[:], key[:32])
-		copy(account.PrivateSpendKey[:], key[32:])
-		accounts = append(accounts, &account)
+		fmt.Println(hex.EncodeToString(signed.Marshal()))
+		return nil
 	}
-
-	signed := tx.AsVersioned()
 	for i := range signed.Inputs {
 		err := signed.SignInput(raw, i, accounts)
 		if err != nil {
@@ -389,6 +418,249 @@ func signTransactionCmd(c *cli.Context) error {
 	return nil
 }
 
+func aggregateSign(signed *common.VersionedTransaction, raw signerInput, accounts []*common.Address) error {
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	return signed.AggregateSignAccounts(raw, accounts, seed)
+}
+
+func createPartialTransactionCmd(c *cli.Context) error {
+	raw, err := hex.DecodeString(c.String("raw"))
+	if err != nil {
+		return err
+	}
+	ver, err := common.UnmarshalVersionedTransaction(raw)
+	if err != nil {
+		return err
+	}
+
+	utxos, err := rpc.NewClient(c.String("node")).GetUTXOs(c.Context, ver.Inputs)
+	if err != nil {
+		return err
+	}
+	spent := make([]*common.UTXO, len(utxos))
+	for i, utxo := range utxos {
+		if utxo == nil {
+			in := ver.Inputs[i]
+			return fmt.Errorf("input not found %s:%d", in.Hash, in.Index)
+		}
+		spent[i] = &utxo.UTXO
+	}
+	pt, err := common.NewPartialTransaction(ver, spent)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(pt.Marshal()))
+	return nil
+}
+
+func commitPartialTransactionCmd(c *cli.Context) error {
+	pt, err := readPartialTransaction(c.String("partial"))
+	if err != nil {
+		return err
+	}
+	accounts, err := signingAccounts(c)
+	if err != nil {
+		return err
+	}
+	path := c.String("nonces")
+	if path == "" {
+		return fmt.Errorf("empty nonces file")
+	}
+	nonces, err := pt.Commit(accounts)
+	if err != nil {
+		return err
+	}
+	if len(nonces) == 0 {
+		return fmt.Errorf("no input committed by the accounts")
+	}
+
+	data, err := json.Marshal(nonces)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(pt.Marshal()))
+	return nil
+}
+
+// signPartialTransactionCmd makes the aggregation partial signatures with the
+// nonces file, which is removed after, so the secret nonces are never used
+// twice. Without the nonces file it signs the inputs directly.
+func signPartialTransactionCmd(c *cli.Context) error {
+	pt, err := readPartialTransaction(c.String("partial"))
+	if err != nil {
+		return err
+	}
+	accounts, err := signingAccounts(c)
+	if err != nil {
+		return err
+	}
+
+	path := c.String("nonces")
+	if path == "" {
+		count, err := pt.Sign(accounts)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("no input signed by the accounts")
+		}
+		fmt.Println(string(pt.Marshal()))
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var nonces []*common.PartialNonce
+	err = json.Unmarshal(data, &nonces)
+	if err != nil {
+		return fmt.Errorf("%s %v", path, err)
+	}
+	count, err := pt.AggregateSign(accounts, nonces)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no partial signature made by the nonces")
+	}
+	err = os.Remove(path)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(pt.Marshal()))
+	return nil
+}
+
+func combinePartialTransactionsCmd(c *cli.Context) error {
+	paths := c.StringSlice("partial")
+	if len(paths) < 2 {
+		return fmt.Errorf("at least two partial transactions to combine")
+	}
+	pt, err := readPartialTransaction(paths[0])
+	if err != nil {
+		return err
+	}
+	for _, path := range paths[1:] {
+		other, err := readPartialTransaction(path)
+		if err != nil {
+			return err
+		}
+		_, err = pt.Combine(other)
+		if err != nil {
+			return fmt.Errorf("%s %v", path, err)
+		}
+	}
+	fmt.Println(string(pt.Marshal()))
+	return nil
+}
+
+func inspectPartialTransactionCmd(c *cli.Context) error {
+	pt, err := readPartialTransaction(c.String("partial"))
+	if err != nil {
+		return err
+	}
+	aggregated := len(pt.Signers) > 0
+	inputs := make([]map[string]any, len(pt.Inputs))
+	for i, in := range pt.Inputs {
+		signers := pt.SignedKeys(i)
+		inputs[i] = map[string]any{
+			"hash":      in.Hash,
+			"index":     in.Index,
+			"keys":      len(in.Keys),
+			"threshold": in.Threshold(),
+			"signers":   append([]int{}, signers...),
+			"complete":  len(signers) >= in.Threshold(),
+		}
+		if aggregated {
+			inputs[i]["committed"] = append([]int{}, pt.CommittedKeys(i)...)
+		}
+	}
+	data, err := json.MarshalIndent(map[string]any{
+		"hash":       pt.Transaction.PayloadHash(),
+		"inputs":     inputs,
+		"aggregated": aggregated,
+		"complete":   pt.Complete(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func finalizePartialTransactionCmd(c *cli.Context) error {
+	pt, err := readPartialTransaction(c.String("partial"))
+	if err != nil {
+		return err
+	}
+	signed, err := pt.Finalize()
+	if err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(signed.Marshal()))
+	return nil
+}
+
+func exportPartialTransactionCmd(c *cli.Context) error {
+	pt, err := readPartialTransaction(c.String("partial"))
+	if err != nil {
+		return err
+	}
+	frames, err := common.EncodeFrames(pt.Marshal(), c.Int("size"))
+	if err != nil {
+		return err
+	}
+	for _, f := range frames {
+		fmt.Println(f)
+	}
+	return nil
+}
+
+func importPartialTransactionCmd(c *cli.Context) error {
+	path := c.String("frames")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err = common.DecodeFrames(strings.Split(string(data), "\n"))
+	if err != nil {
+		return fmt.Errorf("%s %v", path, err)
+	}
+	pt, err := common.UnmarshalPartialTransaction(data)
+	if err != nil {
+		return fmt.Errorf("%s %v", path, err)
+	}
+	fmt.Println(string(pt.Marshal()))
+	return nil
+}
+
+func readPartialTransaction(path string) (*common.PartialTransaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := common.UnmarshalPartialTransaction(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s %v", path, err)
+	}
+	return pt, nil
+}
+
 func sendTransactionCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "sendrawtransaction", []any{
 		c.String("raw"),
@@ -399,28 +671,56 @@ func sendTransactionCmd(c *cli.Context) error {
 	return err
 }
 
+func sendTransactionsCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "sendrawtransactions", []any{
+		strings.Split(c.String("raws"), ","),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func validateTransactionCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "validaterawtransaction", []any{
+		c.String("raw"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func custodianDepositCmd(c *cli.Context) error {
 	receiver, err := common.NewAddressFromString(c.String("receiver"))
 	if err != nil {
 		return fmt.Errorf("invalid receiver %s", c.String("receiver"))
 	}
-	kph := c.String("custodian")
-	if len(kph) != 128 {
-		return fmt.Errorf("invalid custodian %s", kph)
-	}
-	view, err := crypto.KeyFromString(kph[:64])
-	if err != nil {
-		return fmt.Errorf("invalid custodian %s", kph)
-	}
-	spend, err := crypto.KeyFromString(kph[64:])
-	if err != nil {
-		return fmt.Errorf("invalid custodian %s", kph)
-	}
-	custodian := &common.Address{
-		PrivateViewKey:  view,
-		PrivateSpendKey: spend,
-		PublicViewKey:   view.Public(),
-		PublicSpendKey:  spend.Public(),
+	var custodian *common.Address
+	if name := c.String("account"); name != "" {
+		custodian, err = unlockAccount(c, name)
+		if err != nil {
+			return err
+		}
+	} else {
+		kph := c.String("custodian")
+		if len(kph) != 128 {
+			return fmt.Errorf("invalid custodian %s", kph)
+		}
+		view, err := crypto.KeyFromString(kph[:64])
+		if err != nil {
+			return fmt.Errorf("invalid custodian %s", kph)
+		}
+		spend, err := crypto.KeyFromString(kph[64:])
+		if err != nil {
+			return fmt.Errorf("invalid custodian %s", kph)
+		}
+		custodian = &common.Address{
+			PrivateViewKey:  view,
+			PrivateSpendKey: spend,
+			PublicViewKey:   view.Public(),
+			PublicSpendKey:  spend.Public(),
+		}
 	}
 
 	asset, err := crypto.HashFromString(c.String("asset"))
@@ -456,20 +756,10 @@ func custodianDepositCmd(c *cli.Context) error {
 func pledgeNodeCmd(c *cli.Context) error {
 	seed := make([]byte, 64)
 	crypto.ReadRand(seed)
-	viewKey, err := crypto.KeyFromString(c.String("view"))
+	account, err := signingAccount(c)
 	if err != nil {
 		return err
 	}
-	spendKey, err := crypto.KeyFromString(c.String("spend"))
-	if err != nil {
-		return err
-	}
-	account := common.Address{
-		PrivateViewKey:  viewKey,
-		PrivateSpendKey: spendKey,
-		PublicViewKey:   viewKey.Public(),
-		PublicSpendKey:  spendKey.Public(),
-	}
 
 	signer, err := common.NewAddressFromString(c.String("signer"))
 	if err != nil {
@@ -511,7 +801,7 @@ func pledgeNodeCmd(c *cli.Context) error {
 	tx.References = []crypto.Hash{snap.Transactions[0]}
 
 	signed := tx.AsVersioned()
-	err = signed.SignInput(raw, 0, []*common.Address{&account})
+	err = signed.SignInput(raw, 0, []*common.Address{account})
 	if err != nil {
 		return err
 	}
@@ -522,11 +812,7 @@ func pledgeNodeCmd(c *cli.Context) error {
 func cancelNodeCmd(c *cli.Context) error {
 	seed := make([]byte, 64)
 	crypto.ReadRand(seed)
-	viewKey, err := crypto.KeyFromString(c.String("view"))
-	if err != nil {
-		return err
-	}
-	spendKey, err := crypto.KeyFromString(c.String("spend"))
+	account, err := signingAccount(c)
 	if err != nil {
 		return err
 	}
@@ -534,12 +820,6 @@ func cancelNodeCmd(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	account := common.Address{
-		PrivateViewKey:  viewKey,
-		PrivateSpendKey: spendKey,
-		PublicViewKey:   viewKey.Public(),
-		PublicSpendKey:  spendKey.Public(),
-	}
 	if account.String() != receiver.String() {
 		return fmt.Errorf("invalid key and receiver %s %s", account, receiver)
 	}
@@ -574,7 +854,7 @@ func cancelNodeCmd(c *cli.Context) error {
 	if len(source.Outputs) != 1 || len(source.Outputs[0].Keys) != 1 {
 		return fmt.Errorf("invalid source transaction outputs %d %d", len(source.Outputs), len(source.Outputs[0].Keys))
 	}
-	pig := crypto.ViewGhostOutputKey(source.Outputs[0].Keys[0], &viewKey, &source.Outputs[0].Mask, 0)
+	pig := crypto.ViewGhostOutputKey(source.Outputs[0].Keys[0], &account.PrivateViewKey, &source.Outputs[0].Mask, 0)
 	if pig.String() != receiver.PublicSpendKey.String() {
 		return fmt.Errorf("invalid source and receiver %s %s", pig.String(), receiver.PublicSpendKey)
 	}
@@ -583,7 +863,7 @@ func cancelNodeCmd(c *cli.Context) error {
 	tx.AddInput(pledge.PayloadHash(), 0)
 	tx.AddOutputWithType(common.OutputTypeNodeCancel, nil, common.Script{}, pledge.Outputs[0].Amount.Div(100), seed)
 	tx.AddScriptOutput([]*common.Address{&receiver}, common.NewThresholdScript(1), pledge.Outputs[0].Amount.Sub(tx.Outputs[0].Amount), seed)
-	tx.Extra = append(pledge.Extra, viewKey[:]...)
+	tx.Extra = append(pledge.Extra, account.PrivateViewKey[:]...)
 	utxo := &common.UTXO{
 		Input: common.Input{
 			Hash:  pledge.PayloadHash(),
@@ -596,7 +876,7 @@ func cancelNodeCmd(c *cli.Context) error {
 		},
 	}
 	signed := tx.AsVersioned()
-	err = signed.SignUTXO(utxo, []*common.Address{&account})
+	err = signed.SignUTXO(utxo, []*common.Address{account})
 	if err != nil {
 		return err
 	}
@@ -714,6 +994,20 @@ func listSnapshotsCmd(c *cli.Context) error {
 	return err
 }
 
+func listSnapshotsByTimeCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listsnapshotsbytime", []any{
+		c.Uint64("start"),
+		c.Uint64("end"),
+		c.Uint64("count"),
+		c.Bool("sig"),
+		c.Bool("tx"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func getSnapshotCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "getsnapshot", []any{
 		c.String("hash"),
@@ -724,6 +1018,16 @@ func getSnapshotCmd(c *cli.Context) error {
 	return err
 }
 
+func getSnapshotTraceCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "getsnapshottrace", []any{
+		c.String("hash"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func getTransactionCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "gettransaction", []any{
 		c.String("hash"),
@@ -734,6 +1038,77 @@ func getTransactionCmd(c *cli.Context) error {
 	return err
 }
 
+func getTransactionProofCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "gettransactionproof", []any{
+		c.String("hash"),
+		c.Uint64("since"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func getTransactionStatusCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "gettransactionstatus", []any{
+		c.String("hash"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func waitTransactionCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "waittransaction", []any{
+		c.String("hash"),
+		c.Uint64("timeout"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func listReferencingTransactionsCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listreferencingtransactions", []any{
+		c.String("hash"),
+		c.Uint64("since"),
+		c.Uint64("count"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func listCacheTransactionsCmd(c *cli.Context) error {
+	params := []any{
+		c.Uint64("since"),
+		c.Uint64("count"),
+		c.Bool("conflicts"),
+	}
+	if after := c.String("after"); after != "" {
+		params = append(params, after)
+	}
+	data, err := callRPC(c.String("node"), "listcachetransactions", params, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func listDroppedTransactionsCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listdroppedtransactions", []any{
+		c.Uint64("since"),
+		c.Uint64("count"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func getCacheTransactionCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "getcachetransaction", []any{
 		c.String("hash"),
@@ -777,6 +1152,24 @@ func getUTXOCmd(c *cli.Context) error {
 	return err
 }
 
+func getUTXOsCmd(c *cli.Context) error {
+	var outputs []map[string]any
+	for _, out := range strings.Split(c.String("outputs"), ",") {
+		hash, index, found := strings.Cut(out, ":")
+		if !found {
+			return fmt.Errorf("invalid output %s", out)
+		}
+		outputs = append(outputs, map[string]any{"hash": hash, "index": index})
+	}
+	data, err := callRPC(c.String("node"), "getutxos", []any{
+		outputs,
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func getKeyCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "getkey", []any{
 		c.String("key"),
@@ -787,6 +1180,16 @@ func getKeyCmd(c *cli.Context) error {
 	return err
 }
 
+func getKeysCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "getkeys", []any{
+		strings.Split(c.String("keys"), ","),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func getAssetCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "getasset", []any{
 		c.String("id"),
@@ -797,6 +1200,41 @@ func getAssetCmd(c *cli.Context) error {
 	return err
 }
 
+func listAssetsCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listassets", []any{
+		c.String("offset"),
+		c.Uint64("count"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func listAssetSupplyCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listassetsupply", []any{
+		c.String("id"),
+		c.Uint64("since"),
+		c.Uint64("count"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+func listTransactionsByAssetCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "listtransactionsbyasset", []any{
+		c.String("id"),
+		c.Uint64("since"),
+		c.Uint64("count"),
+	}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func listCustodianUpdatesCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "listcustodianupdates", []any{}, c.Bool("time"))
 	if err == nil {
@@ -846,6 +1284,14 @@ func getInfoCmd(c *cli.Context) error {
 	return err
 }
 
+func getSupplyCmd(c *cli.Context) error {
+	data, err := callRPC(c.String("node"), "getsupply", []any{}, c.Bool("time"))
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 func listPeersCmd(c *cli.Context) error {
 	data, err := callRPC(c.String("node"), "listpeers", []any{}, c.Bool("time"))
 	if err == nil {
@@ -870,6 +1316,33 @@ func dumpGraphHeadCmd(c *cli.Context) error {
 	return err
 }
 
+func addScanAccountCmd(c *cli.Context) error {
+	return callAdminRPC(c, "addscanaccount", []any{
+		c.String("view"),
+		c.String("spend"),
+		c.Uint64("since"),
+	})
+}
+
+func removeScanAccountCmd(c *cli.Context) error {
+	return callAdminRPC(c, "removescanaccount", []any{
+		c.String("address"),
+	})
+}
+
+func listScanAccountsCmd(c *cli.Context) error {
+	return callAdminRPC(c, "listscanaccounts", []any{})
+}
+
+func listScanOutputsCmd(c *cli.Context) error {
+	return callAdminRPC(c, "listscanoutputs", []any{
+		c.String("address"),
+		c.Uint64("since"),
+		c.Uint64("count"),
+		c.Bool("unspent"),
+	})
+}
+
 func setupTestNetCmd(c *cli.Context) error {
 	var signers, payees, custodians []common.Address
 
@@ -966,6 +1439,187 @@ func callRPC(node, method string, params []any, _ bool) ([]byte, error) {
 	return rpc.CallMixinRPC(node, method, params)
 }
 
+func keystoreCreateCmd(c *cli.Context) error {
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	addr := common.NewAddressFromSeed(seed)
+	return addKeystoreAccount(c, &addr)
+}
+
+func keystoreImportCmd(c *cli.Context) error {
+	fmt.Fprint(os.Stderr, "private view key followed by private spend key, encoded as hex: ")
+	line, err := readLine()
+	if err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(line)
+	if err != nil {
+		return err
+	}
+	if len(key) != 64 {
+		return fmt.Errorf("invalid key length %d", len(key))
+	}
+	var addr common.Address
+	copy(addr.PrivateViewKey[:], key[:32])
+	copy(addr.PrivateSpendKey[:], key[32:])
+	addr.PublicViewKey = addr.PrivateViewKey.Public()
+	addr.PublicSpendKey = addr.PrivateSpendKey.Public()
+	return addKeystoreAccount(c, &addr)
+}
+
+func keystoreListCmd(c *cli.Context) error {
+	ks, err := keystore.Open(c.String("keystore"))
+	if err != nil {
+		return err
+	}
+	for _, a := range ks.Accounts {
+		fmt.Printf("%s\t%s\n", a.Name, a.Address)
+	}
+	return nil
+}
+
+func keystoreExportCmd(c *cli.Context) error {
+	addr, err := unlockAccount(c, c.String("name"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("address:\t%s\n", addr.String())
+	fmt.Printf("view key:\t%s\n", addr.PrivateViewKey.String())
+	fmt.Printf("spend key:\t%s\n", addr.PrivateSpendKey.String())
+	return nil
+}
+
+func addKeystoreAccount(c *cli.Context, addr *common.Address) error {
+	ks, err := keystore.Open(c.String("keystore"))
+	if err != nil {
+		return err
+	}
+	name := c.String("name")
+	pass, err := readPassphrase(fmt.Sprintf("new passphrase of %s: ", name))
+	if err != nil {
+		return err
+	}
+	if os.Getenv("MIXIN_KEYSTORE_PASSPHRASE") == "" {
+		confirm, err := readPassphrase(fmt.Sprintf("repeat passphrase of %s: ", name))
+		if err != nil {
+			return err
+		}
+		if string(confirm) != string(pass) {
+			return fmt.Errorf("passphrases not match")
+		}
+	}
+	a, err := ks.Add(name, addr, pass)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\t%s\n", a.Name, a.Address)
+	return nil
+}
+
+// signingAccount unlocks the keystore account of --account, or reads the
+// private keys of --view and --spend.
+func signingAccount(c *cli.Context) (*common.Address, error) {
+	if name := c.String("account"); name != "" {
+		return unlockAccount(c, name)
+	}
+	viewKey, err := crypto.KeyFromString(c.String("view"))
+	if err != nil {
+		return nil, err
+	}
+	spendKey, err := crypto.KeyFromString(c.String("spend"))
+	if err != nil {
+		return nil, err
+	}
+	return &common.Address{
+		PrivateViewKey:  viewKey,
+		PrivateSpendKey: spendKey,
+		PublicViewKey:   viewKey.Public(),
+		PublicSpendKey:  spendKey.Public(),
+	}, nil
+}
+
+// signingAccounts unlocks the --account keystore accounts, and decodes the
+// --key private keys of the other accounts.
+func signingAccounts(c *cli.Context) ([]*common.Address, error) {
+	var accounts []*common.Address
+	for _, s := range c.StringSlice("key") {
+		key, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 64 {
+			return nil, fmt.Errorf("invalid key length %d", len(key))
+		}
+		var account common.Address
+		copy(account.PrivateViewKey[:], key[:32])
+		copy(account.PrivateSpendKey[:], key[32:])
+		accounts = append(accounts, &account)
+	}
+	for _, name := range c.StringSlice("account") {
+		account, err := unlockAccount(c, name)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func unlockAccount(c *cli.Context, name string) (*common.Address, error) {
+	ks, err := keystore.Open(c.String("keystore"))
+	if err != nil {
+		return nil, err
+	}
+	a := ks.Find(name)
+	if a == nil {
+		return nil, fmt.Errorf("account %s not found in %s", name, c.String("keystore"))
+	}
+	pass, err := readPassphrase(fmt.Sprintf("passphrase of %s: ", name))
+	if err != nil {
+		return nil, err
+	}
+	return a.Unlock(pass)
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readPassphrase uses MIXIN_KEYSTORE_PASSPHRASE if set, otherwise it prompts
+// on the standard error and reads a line from the standard input, without
+// echo if the standard input is a terminal.
+func readPassphrase(prompt string) ([]byte, error) {
+	if pass := os.Getenv("MIXIN_KEYSTORE_PASSPHRASE"); pass != "" {
+		return []byte(pass), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		pass, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return pass, err
+	}
+	line, err := readLine()
+	return []byte(line), err
+}
+
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// callAdminRPC calls an admin method with the token and prints the data.
+func callAdminRPC(c *cli.Context, method string, params []any) error {
+	client := rpc.NewClient(c.String("node"))
+	client.Token = c.String("token")
+	var data json.RawMessage
+	err := client.Call(c.Context, method, params, &data)
+	if err == nil {
+		fmt.Println(string(data))
+	}
+	return err
+}
+
 type signerInput struct {
 	Version uint8 `json:"version"`
 	Inputs  []struct {
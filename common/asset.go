@@ -25,11 +25,30 @@ var (
 	XINAsset = &Asset{Chain: EthereumAssetId, AssetKey: "0xa974c709cfb4566686553a20790685a47aceaa33"}
 )
 
+const (
+	AssetSupplyGenesis    = "genesis"
+	AssetSupplyDeposit    = "deposit"
+	AssetSupplyMint       = "mint"
+	AssetSupplyWithdrawal = "withdrawal"
+)
+
 type Asset struct {
 	Chain    crypto.Hash
 	AssetKey string
 }
 
+// AssetSupplyChange is a finalized transaction changing the total balance of
+// an asset in the kernel. Amount is always positive and subtracted from the
+// balance for withdrawals, Total is the balance after the transaction.
+type AssetSupplyChange struct {
+	Asset       crypto.Hash
+	Transaction crypto.Hash
+	Timestamp   uint64
+	Type        string
+	Amount      Integer
+	Total       Integer
+}
+
 func (a *Asset) Verify() error {
 	if !a.Chain.HasValue() {
 		return fmt.Errorf("invalid asset chain %v", *a)
@@ -0,0 +1,194 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// Recipient receives an output of Amount, spendable by Threshold of the
+// Accounts. A single address is a recipient of one account and threshold 1.
+type Recipient struct {
+	Accounts  []*Address
+	Threshold uint8
+	Amount    Integer
+}
+
+// TransactionBuilder builds an unsigned transaction paying the Recipients
+// from the UTXOs, with the change back to the Change accounts. The Amount
+// of Change is ignored.
+type TransactionBuilder struct {
+	Asset      crypto.Hash
+	UTXOs      []*UTXO
+	Recipients []*Recipient
+	Change     *Recipient
+	Extra      []byte
+	References []crypto.Hash
+}
+
+func NewTransactionBuilder(asset crypto.Hash) *TransactionBuilder {
+	return &TransactionBuilder{Asset: asset}
+}
+
+func (b *TransactionBuilder) AddUTXOs(utxos ...*UTXO) {
+	b.UTXOs = append(b.UTXOs, utxos...)
+}
+
+func (b *TransactionBuilder) AddRecipient(accounts []*Address, threshold uint8, amount Integer) {
+	b.Recipients = append(b.Recipients, &Recipient{
+		Accounts:  accounts,
+		Threshold: threshold,
+		Amount:    amount,
+	})
+}
+
+func (b *TransactionBuilder) SetChange(accounts []*Address, threshold uint8) {
+	b.Change = &Recipient{Accounts: accounts, Threshold: threshold}
+}
+
+// Build selects the UTXOs to pay the recipients and the storage price of a
+// large extra, then returns the unsigned transaction with the selected UTXOs
+// in the order of its inputs. The storage output is the first output, and
+// the change output is the last one if any.
+func (b *TransactionBuilder) Build() (*VersionedTransaction, []*UTXO, error) {
+	if len(b.Recipients) == 0 {
+		return nil, nil, fmt.Errorf("empty recipients")
+	}
+	if b.Change == nil {
+		return nil, nil, fmt.Errorf("empty change accounts")
+	}
+	err := b.Change.verify()
+	if err != nil {
+		return nil, nil, fmt.Errorf("change %v", err)
+	}
+	if len(b.Extra) > ExtraSizeStorageCapacity {
+		return nil, nil, fmt.Errorf("invalid extra size %d", len(b.Extra))
+	}
+	if len(b.References) > ReferencesCountLimit {
+		return nil, nil, fmt.Errorf("invalid references count %d", len(b.References))
+	}
+
+	total := NewInteger(0)
+	storage := ExtraStoragePrice(len(b.Extra))
+	if storage.Sign() > 0 {
+		if b.Asset != XINAssetId {
+			return nil, nil, fmt.Errorf("invalid storage asset %s", b.Asset)
+		}
+		total = total.Add(storage)
+	}
+	for i, r := range b.Recipients {
+		err := r.verify()
+		if err != nil {
+			return nil, nil, fmt.Errorf("recipient %d %v", i, err)
+		}
+		if r.Amount.Sign() <= 0 {
+			return nil, nil, fmt.Errorf("recipient %d invalid amount %s", i, r.Amount)
+		}
+		total = total.Add(r.Amount)
+	}
+	outputs := len(b.Recipients) + 1
+	if storage.Sign() > 0 {
+		outputs += 1
+	}
+	if outputs > SliceCountLimit {
+		return nil, nil, fmt.Errorf("invalid outputs count %d", outputs)
+	}
+
+	utxos, err := b.selectUTXOs(total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := NewTransactionV5(b.Asset)
+	for _, u := range utxos {
+		tx.AddInput(u.Hash, u.Index)
+	}
+	if storage.Sign() > 0 {
+		vanish := NewAddressFromSeedInternalVanish(make([]byte, 64))
+		tx.AddRandomScriptOutput([]*Address{&vanish}, NewThresholdScript(Operator64), storage)
+	}
+	for _, r := range b.Recipients {
+		tx.AddRandomScriptOutput(r.Accounts, NewThresholdScript(r.Threshold), r.Amount)
+	}
+	var sum Integer
+	for _, u := range utxos {
+		sum = sum.Add(u.Amount)
+	}
+	if change := sum.Sub(total); change.Sign() > 0 {
+		tx.AddRandomScriptOutput(b.Change.Accounts, NewThresholdScript(b.Change.Threshold), change)
+	}
+	tx.Extra = b.Extra
+	tx.References = b.References
+
+	ver := tx.AsVersioned()
+	size := len(ver.payloadMarshal()) + signaturesMapSize(utxos)
+	if size > config.TransactionMaximumSize {
+		return nil, nil, fmt.Errorf("transaction too large %d", size)
+	}
+	return ver, utxos, nil
+}
+
+// signaturesMapSize is the encoded size of the threshold signatures of all
+// the UTXOs, which the signed transaction will grow by.
+func signaturesMapSize(utxos []*UTXO) int {
+	size := 2
+	for _, u := range utxos {
+		size += 2 + int(u.Script[2])*(2+len(crypto.Signature{}))
+	}
+	return size
+}
+
+// selectUTXOs uses the smallest UTXO covering the total if any, otherwise
+// the largest ones until the total is covered, so the inputs count is low.
+func (b *TransactionBuilder) selectUTXOs(total Integer) ([]*UTXO, error) {
+	var candidates []*UTXO
+	filter := make(map[string]bool)
+	for _, u := range b.UTXOs {
+		if u.Asset != b.Asset || u.Type != OutputTypeScript || u.Amount.Sign() <= 0 {
+			continue
+		}
+		if u.Script.VerifyFormat() != nil || len(u.Keys) == 0 || int(u.Script[2]) > len(u.Keys) {
+			continue
+		}
+		k := fmt.Sprintf("%s:%d", u.Hash, u.Index)
+		if filter[k] {
+			continue
+		}
+		filter[k] = true
+		candidates = append(candidates, u)
+	}
+	slices.SortStableFunc(candidates, func(a, b *UTXO) int { return b.Amount.Cmp(a.Amount) })
+
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].Amount.Cmp(total) >= 0 {
+			return []*UTXO{candidates[i]}, nil
+		}
+	}
+
+	var sum Integer
+	for i, u := range candidates {
+		if i == SliceCountLimit {
+			return nil, fmt.Errorf("too many inputs to pay %s", total)
+		}
+		sum = sum.Add(u.Amount)
+		if sum.Cmp(total) >= 0 {
+			return candidates[:i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("insufficient balance %s %s", sum, total)
+}
+
+func (r *Recipient) verify() error {
+	if len(r.Accounts) == 0 || len(r.Accounts) > SliceCountLimit {
+		return fmt.Errorf("invalid accounts count %d", len(r.Accounts))
+	}
+	if slices.Contains(r.Accounts, nil) {
+		return fmt.Errorf("invalid accounts")
+	}
+	if r.Threshold == 0 || r.Threshold > Operator64 || int(r.Threshold) > len(r.Accounts) {
+		return fmt.Errorf("invalid threshold %d/%d", r.Threshold, len(r.Accounts))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionBuilder(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 3)
+	for i := range accounts {
+		a := randomAccount()
+		accounts[i] = &a
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	// the store outputs at index 0 are owned by accounts[0] and accounts[1]
+	// with threshold 1, and all of 10000 XIN
+	var utxos []*UTXO
+	for i := range 3 {
+		hash := crypto.Blake3Hash([]byte{byte(i)})
+		utxo, err := store.ReadUTXOLock(hash, 0)
+		require.Nil(err)
+		utxos = append(utxos, &utxo.UTXO)
+	}
+	utxos[1].Amount = NewInteger(3000)
+	utxos[2].Amount = NewInteger(5000)
+	other := *utxos[0]
+	other.Asset = crypto.Blake3Hash([]byte("other"))
+	receiver, multisig := randomAccount(), randomAccount()
+
+	b := NewTransactionBuilder(XINAssetId)
+	b.AddUTXOs(append(utxos, &other, utxos[2])...)
+	b.AddRecipient([]*Address{&receiver}, 1, NewInteger(4000))
+	_, _, err := b.Build()
+	require.ErrorContains(err, "empty change accounts")
+	b.SetChange(accounts[:1], 1)
+
+	ver, selected, err := b.Build()
+	require.Nil(err)
+	require.Equal([]*UTXO{utxos[2]}, selected)
+	require.Len(ver.Inputs, 1)
+	require.Len(ver.Outputs, 2)
+	require.Equal("4000.00000000", ver.Outputs[0].Amount.String())
+	require.Equal("1000.00000000", ver.Outputs[1].Amount.String())
+	out := ver.Outputs[0]
+	require.Equal(receiver.PublicSpendKey, *crypto.ViewGhostOutputKey(out.Keys[0], &receiver.PrivateViewKey, &out.Mask, 0))
+
+	b.AddRecipient([]*Address{&receiver, &multisig}, 2, NewInteger(6000))
+	b.Extra = bytes.Repeat([]byte{1}, ExtraSizeStorageStep*2+1)
+	ver, selected, err = b.Build()
+	require.Nil(err)
+	require.Equal([]*UTXO{utxos[0], utxos[2]}, selected)
+	require.Len(ver.Outputs, 4)
+	require.Equal(ExtraStoragePrice(len(b.Extra)), ver.Outputs[0].Amount)
+	require.Equal("fffe40", ver.Outputs[0].Script.String())
+	require.Equal("fffe02", ver.Outputs[2].Script.String())
+	require.Len(ver.Outputs[2].Keys, 2)
+	require.Equal("4999.99970000", ver.Outputs[3].Amount.String())
+	require.GreaterOrEqual(ver.GetExtraLimit(), len(b.Extra))
+	for _, out := range ver.Outputs[1:] {
+		require.True(out.Mask.CheckKey())
+	}
+
+	b.AddRecipient([]*Address{&receiver}, 1, NewInteger(10000))
+	_, _, err = b.Build()
+	require.ErrorContains(err, "insufficient balance 18000.00000000 20000.00030000")
+	b.Recipients[2].Threshold = 2
+	_, _, err = b.Build()
+	require.ErrorContains(err, "recipient 2 invalid threshold 2/1")
+
+	b = NewTransactionBuilder(other.Asset)
+	b.AddUTXOs(&other)
+	b.AddRecipient([]*Address{&receiver}, 1, NewInteger(1))
+	b.SetChange(accounts[:1], 1)
+	b.Extra = bytes.Repeat([]byte{1}, ExtraSizeGeneralLimit+1)
+	_, _, err = b.Build()
+	require.ErrorContains(err, "invalid storage asset")
+
+	b = NewTransactionBuilder(XINAssetId)
+	for i := range 3 {
+		utxo, err := store.ReadUTXOLock(crypto.Blake3Hash([]byte{byte(i)}), 0)
+		require.Nil(err)
+		b.AddUTXOs(&utxo.UTXO)
+	}
+	b.AddRecipient([]*Address{&receiver, &multisig}, 2, NewInteger(15000))
+	b.SetChange(accounts[1:2], 1)
+	b.Extra = bytes.Repeat([]byte{1}, ExtraSizeStorageCapacity)
+	_, _, err = b.Build()
+	require.ErrorContains(err, "transaction too large")
+	b.Extra = b.Extra[:ExtraSizeStorageCapacity-ExtraSizeStorageStep]
+	ver, selected, err = b.Build()
+	require.Nil(err)
+	require.Len(selected, 2)
+	require.Equal("4999.59050000", ver.Outputs[2].Amount.String())
+	for i := range ver.Inputs {
+		err = ver.SignInput(store, i, accounts[1:2])
+		require.Nil(err)
+	}
+	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.Nil(err)
+}
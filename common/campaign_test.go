@@ -822,6 +822,8 @@ func TestValidationHelpersCampaign(t *testing.T) {
 	dup.SignaturesMap = []map[uint16]*crypto.Signature{{0: nil}}
 	_, _, err = dup.validateInputs(store, crypto.Hash{}, TransactionTypeScript, false)
 	require.ErrorContains(err, "invalid input")
+	require.Equal(ErrorCodeInvalidInput, AsError(err).Code)
+	require.Equal(1, AsError(err).Input)
 
 	store.readUTXOErr = errors.New("utxo read failure")
 	_, _, err = (&SignedTransaction{Transaction: Transaction{
@@ -829,6 +831,7 @@ func TestValidationHelpersCampaign(t *testing.T) {
 		Inputs: []*Input{{Hash: utxoHash, Index: 0}},
 	}}).validateInputs(store, crypto.Hash{}, TransactionTypeScript, false)
 	require.ErrorIs(err, store.readUTXOErr)
+	require.Equal(ErrorCodeUnknown, AsError(err).Code)
 	store.readUTXOErr = nil
 
 	_, _, err = (&SignedTransaction{Transaction: Transaction{
@@ -836,6 +839,8 @@ func TestValidationHelpersCampaign(t *testing.T) {
 		Inputs: []*Input{{Hash: crypto.Blake3Hash([]byte("missing")), Index: 0}},
 	}}).validateInputs(store, crypto.Hash{}, TransactionTypeScript, false)
 	require.ErrorContains(err, "input not found")
+	require.Equal(ErrorCodeInputNotFound, AsError(err).Code)
+	require.True(AsError(err).Retryable)
 
 	store.utxos[utxoRef(utxoHash, 0)].Asset = BitcoinAssetId
 	_, _, err = (&SignedTransaction{Transaction: Transaction{
@@ -851,6 +856,9 @@ func TestValidationHelpersCampaign(t *testing.T) {
 		Inputs: []*Input{{Hash: utxoHash, Index: 0}},
 	}}).validateInputs(store, crypto.Blake3Hash([]byte("other")), TransactionTypeScript, false)
 	require.ErrorContains(err, "input locked for transaction")
+	require.Equal(ErrorCodeInputLocked, AsError(err).Code)
+	require.Equal(ErrorCategoryConflict, AsError(err).Category)
+	require.Equal(0, AsError(err).Input)
 
 	lockedFork := &SignedTransaction{Transaction: Transaction{
 		Asset:  XINAssetId,
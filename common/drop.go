@@ -0,0 +1,99 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const TransactionDropReasonLimit = 1024
+
+// TransactionDrop records a transaction removed from the cache queue because
+// it failed validation when the queue was processed. Origin tells who queued
+// it, e.g. rpc, self or peer:<node id>, and is empty if unknown.
+type TransactionDrop struct {
+	Hash      crypto.Hash
+	Timestamp uint64
+	Origin    string
+	Code      ErrorCode
+	Input     int
+	Reason    string
+}
+
+func NewTransactionDrop(hash crypto.Hash, timestamp uint64, err error) *TransactionDrop {
+	e := AsError(err)
+	reason := err.Error()
+	if len(reason) > TransactionDropReasonLimit {
+		reason = reason[:TransactionDropReasonLimit]
+	}
+	return &TransactionDrop{
+		Hash:      hash,
+		Timestamp: timestamp,
+		Code:      e.Code,
+		Input:     e.Input,
+		Reason:    reason,
+	}
+}
+
+// Error rebuilds the classified validation error of the drop.
+func (d *TransactionDrop) Error() *Error {
+	e := NewError(d.Code, errors.New(d.Reason))
+	e.Input = d.Input
+	return e
+}
+
+func (d *TransactionDrop) Marshal() []byte {
+	enc := NewMinimumEncoder()
+	enc.Write(d.Hash[:])
+	enc.WriteUint64(d.Timestamp)
+	enc.WriteInt(len(d.Origin))
+	enc.Write([]byte(d.Origin))
+	enc.WriteInt(len(d.Code))
+	enc.Write([]byte(d.Code))
+	enc.WriteInt(d.Input + 1)
+	enc.WriteInt(len(d.Reason))
+	enc.Write([]byte(d.Reason))
+	return enc.Bytes()
+}
+
+func UnmarshalTransactionDrop(b []byte) (*TransactionDrop, error) {
+	if len(b) < 52 {
+		return nil, fmt.Errorf("invalid transaction drop size %d", len(b))
+	}
+
+	var d TransactionDrop
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	err = dec.Read(d.Hash[:])
+	if err != nil {
+		return nil, err
+	}
+	d.Timestamp, err = dec.ReadUint64()
+	if err != nil {
+		return nil, err
+	}
+	origin, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.Origin = string(origin)
+	code, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.Code = ErrorCode(code)
+	input, err := dec.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+	d.Input = input - 1
+	reason, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.Reason = string(reason)
+	return &d, nil
+}
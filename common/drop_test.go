@@ -0,0 +1,43 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionDrop(t *testing.T) {
+	require := require.New(t)
+
+	hash := crypto.Blake3Hash([]byte("dropped"))
+	err := NewInputErrorf(ErrorCodeInputLocked, 3, "input locked for transaction %s", hash)
+	drop := NewTransactionDrop(hash, 1700000000000000000, err)
+	res, err := UnmarshalTransactionDrop(drop.Marshal())
+	require.Nil(err)
+	require.Equal(drop, res)
+	require.Equal(ErrorCodeInputLocked, res.Error().Code)
+	require.Equal(ErrorCategoryConflict, res.Error().Category)
+	require.Equal(3, res.Error().Input)
+	require.Equal("input locked for transaction "+hash.String(), res.Error().Error())
+
+	drop = NewTransactionDrop(hash, 1, errors.New(strings.Repeat("x", TransactionDropReasonLimit+10)))
+	require.Len(drop.Reason, TransactionDropReasonLimit)
+	require.Equal(ErrorCodeUnknown, drop.Code)
+	require.Equal(-1, drop.Input)
+	res, err = UnmarshalTransactionDrop(drop.Marshal())
+	require.Nil(err)
+	require.Equal(drop, res)
+
+	drop.Origin = "peer:" + hash.String()
+	res, err = UnmarshalTransactionDrop(drop.Marshal())
+	require.Nil(err)
+	require.Equal(drop, res)
+
+	_, err = UnmarshalTransactionDrop(drop.Marshal()[:51])
+	require.NotNil(err)
+	_, err = UnmarshalTransactionDrop(drop.Marshal()[:60])
+	require.NotNil(err)
+}
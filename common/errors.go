@@ -0,0 +1,132 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrorCode string
+
+type ErrorCategory string
+
+const (
+	ErrorCategoryRequest     ErrorCategory = "request"
+	ErrorCategoryTransaction ErrorCategory = "transaction"
+	ErrorCategoryInput       ErrorCategory = "input"
+	ErrorCategorySignature   ErrorCategory = "signature"
+	ErrorCategoryOutput      ErrorCategory = "output"
+	ErrorCategoryConflict    ErrorCategory = "conflict"
+	ErrorCategoryUnavailable ErrorCategory = "unavailable"
+	ErrorCategoryUnknown     ErrorCategory = "unknown"
+)
+
+const (
+	ErrorCodeInvalidParams      ErrorCode = "invalid_params"
+	ErrorCodeInvalidEncoding    ErrorCode = "invalid_encoding"
+	ErrorCodeInvalidTransaction ErrorCode = "invalid_transaction"
+	ErrorCodeReferenceNotFound  ErrorCode = "reference_not_found"
+	ErrorCodeInvalidInput       ErrorCode = "invalid_input"
+	ErrorCodeInputNotFound      ErrorCode = "input_not_found"
+	ErrorCodeInvalidSignature   ErrorCode = "invalid_signature"
+	ErrorCodeInvalidOutput      ErrorCode = "invalid_output"
+	ErrorCodeInputLocked        ErrorCode = "input_locked"
+	ErrorCodeGhostKeyLocked     ErrorCode = "ghost_key_locked"
+	ErrorCodeNodeBusy           ErrorCode = "node_busy"
+	ErrorCodeUnknown            ErrorCode = "unknown"
+)
+
+var errorCodes = map[ErrorCode]struct {
+	category  ErrorCategory
+	retryable bool
+}{
+	ErrorCodeInvalidParams:      {ErrorCategoryRequest, false},
+	ErrorCodeInvalidEncoding:    {ErrorCategoryRequest, false},
+	ErrorCodeInvalidTransaction: {ErrorCategoryTransaction, false},
+	ErrorCodeReferenceNotFound:  {ErrorCategoryTransaction, true},
+	ErrorCodeInvalidInput:       {ErrorCategoryInput, false},
+	ErrorCodeInputNotFound:      {ErrorCategoryInput, true},
+	ErrorCodeInvalidSignature:   {ErrorCategorySignature, false},
+	ErrorCodeInvalidOutput:      {ErrorCategoryOutput, false},
+	ErrorCodeInputLocked:        {ErrorCategoryConflict, false},
+	ErrorCodeGhostKeyLocked:     {ErrorCategoryConflict, false},
+	ErrorCodeNodeBusy:           {ErrorCategoryUnavailable, true},
+	ErrorCodeUnknown:            {ErrorCategoryUnknown, false},
+}
+
+// Error classifies a validation or RPC failure without changing its message.
+// Retryable means the same request may succeed later, e.g. after the inputs or
+// references are finalized, or the node is less busy. Input is the index of
+// the offending transaction input, or -1 if the error is not about one input.
+type Error struct {
+	Code      ErrorCode
+	Category  ErrorCategory
+	Retryable bool
+	Input     int
+
+	err error
+}
+
+func NewError(code ErrorCode, err error) *Error {
+	e := &Error{Code: code, Category: ErrorCategoryUnknown, Input: -1, err: err}
+	if c, found := errorCodes[code]; found {
+		e.Category = c.category
+		e.Retryable = c.retryable
+	}
+	return e
+}
+
+func NewErrorf(code ErrorCode, format string, a ...any) error {
+	return NewError(code, fmt.Errorf(format, a...))
+}
+
+func NewInputErrorf(code ErrorCode, input int, format string, a ...any) error {
+	e := NewError(code, fmt.Errorf(format, a...))
+	e.Input = input
+	return e
+}
+
+// AsError returns the classified error in the chain of err. An error without
+// classification is returned as ErrorCodeUnknown, and nil stays nil.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return NewError(ErrorCodeUnknown, err)
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// classifyError tags an unclassified err with code and keeps a classified one.
+func classifyError(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return NewError(code, err)
+}
+
+func classifyInputError(code ErrorCode, input int, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := AsError(err)
+	if e.Code == ErrorCodeUnknown {
+		e = NewError(code, err)
+	}
+	c := *e
+	c.Input = input
+	return &c
+}
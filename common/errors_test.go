@@ -0,0 +1,53 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorClassification(t *testing.T) {
+	require := require.New(t)
+
+	require.Nil(AsError(nil))
+	require.Nil(classifyError(ErrorCodeInvalidTransaction, nil))
+	require.Nil(classifyInputError(ErrorCodeInvalidSignature, 1, nil))
+
+	cause := errors.New("plain failure")
+	e := AsError(cause)
+	require.Equal(ErrorCodeUnknown, e.Code)
+	require.Equal(ErrorCategoryUnknown, e.Category)
+	require.False(e.Retryable)
+	require.Equal(-1, e.Input)
+	require.ErrorIs(e, cause)
+
+	err := NewInputErrorf(ErrorCodeInputNotFound, 2, "input not found %d", 7)
+	require.Equal("input not found 7", err.Error())
+	wrapped := fmt.Errorf("queue %w", err)
+	e = AsError(wrapped)
+	require.Equal(ErrorCodeInputNotFound, e.Code)
+	require.Equal(ErrorCategoryInput, e.Category)
+	require.True(e.Retryable)
+	require.Equal(2, e.Input)
+
+	require.Same(err, classifyError(ErrorCodeInvalidTransaction, err))
+	err = classifyError(ErrorCodeInvalidTransaction, cause)
+	require.Equal(ErrorCodeInvalidTransaction, AsError(err).Code)
+	require.Equal("plain failure", err.Error())
+
+	err = classifyInputError(ErrorCodeInvalidSignature, 3, cause)
+	require.Equal(ErrorCodeInvalidSignature, AsError(err).Code)
+	require.Equal(3, AsError(err).Input)
+	shared := NewError(ErrorCodeInvalidParams, errors.New("shared failure"))
+	err = classifyInputError(ErrorCodeInvalidSignature, 4, fmt.Errorf("wrap %w", shared))
+	require.Equal(ErrorCodeInvalidParams, AsError(err).Code)
+	require.Equal(4, AsError(err).Input)
+	require.Equal("shared failure", err.Error())
+	require.Equal(-1, shared.Input)
+
+	e = NewError(ErrorCode("future_code"), cause)
+	require.Equal(ErrorCategoryUnknown, e.Category)
+	require.False(e.Retryable)
+}
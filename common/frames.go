@@ -0,0 +1,131 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const (
+	FramePrefix          = "mixin"
+	FrameDataSizeDefault = 400
+	FrameDataSizeMinimum = 64
+	FrameCountLimit      = 1024
+	frameChecksumSize    = 4
+)
+
+// EncodeFrames splits the data into text frames small enough for a QR code,
+// so it can be carried to and from an offline machine by scanning them in an
+// animation. Each frame is
+//
+//	mixin:<sequence>/<total>:<checksum>:<chunk>
+//
+// where the sequence starts from 1, the checksum is the first 4 bytes of the
+// Blake3 hash of the whole data in hex, and the chunk is at most size bytes
+// of the data in unpadded URL base64.
+func EncodeFrames(data []byte, size int) ([]string, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty frames data")
+	}
+	if size < FrameDataSizeMinimum {
+		return nil, fmt.Errorf("invalid frame size %d", size)
+	}
+	total := (len(data) + size - 1) / size
+	if total > FrameCountLimit {
+		return nil, fmt.Errorf("too many frames %d", total)
+	}
+
+	checksum := frameChecksum(data)
+	frames := make([]string, total)
+	for i := range frames {
+		chunk := data[i*size : min((i+1)*size, len(data))]
+		frames[i] = fmt.Sprintf("%s:%d/%d:%s:%s", FramePrefix, i+1, total,
+			checksum, base64.RawURLEncoding.EncodeToString(chunk))
+	}
+	return frames, nil
+}
+
+// DecodeFrames joins the frames of EncodeFrames back to the data. The frames
+// may come in any order with duplicates, as read from an animation, but all
+// of them must be present and belong to the same data.
+func DecodeFrames(frames []string) ([]byte, error) {
+	var checksum string
+	var chunks [][]byte
+	for _, f := range frames {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		seq, total, sum, chunk, err := parseFrame(f)
+		if err != nil {
+			return nil, err
+		}
+		if chunks == nil {
+			checksum, chunks = sum, make([][]byte, total)
+		}
+		if sum != checksum || total != len(chunks) {
+			return nil, fmt.Errorf("frame %d/%d of another data %s %s", seq, total, sum, checksum)
+		}
+		old := chunks[seq-1]
+		if old != nil && string(old) != string(chunk) {
+			return nil, fmt.Errorf("frame %d/%d duplicated with another chunk", seq, total)
+		}
+		chunks[seq-1] = chunk
+	}
+	if chunks == nil {
+		return nil, fmt.Errorf("empty frames")
+	}
+
+	var data []byte
+	var missing []int
+	for i, chunk := range chunks {
+		if chunk == nil {
+			missing = append(missing, i+1)
+		}
+		data = append(data, chunk...)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("frames missing %v of %d", missing, len(chunks))
+	}
+	if frameChecksum(data) != checksum {
+		return nil, fmt.Errorf("invalid frames checksum %s", checksum)
+	}
+	return data, nil
+}
+
+func parseFrame(f string) (int, int, string, []byte, error) {
+	parts := strings.Split(f, ":")
+	if len(parts) != 4 || parts[0] != FramePrefix {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame %s", f)
+	}
+	seq, total, found := strings.Cut(parts[1], "/")
+	if !found {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame sequence %s", parts[1])
+	}
+	s, err := strconv.Atoi(seq)
+	if err != nil {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame sequence %s", parts[1])
+	}
+	t, err := strconv.Atoi(total)
+	if err != nil || t < 1 || t > FrameCountLimit || s < 1 || s > t {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame sequence %s", parts[1])
+	}
+	sum, err := hex.DecodeString(parts[2])
+	if err != nil || len(sum) != frameChecksumSize {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame checksum %s", parts[2])
+	}
+	chunk, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil || len(chunk) == 0 {
+		return 0, 0, "", nil, fmt.Errorf("invalid frame %d/%d chunk", s, t)
+	}
+	return s, t, parts[2], chunk, nil
+}
+
+func frameChecksum(data []byte) string {
+	h := crypto.Blake3Hash(data)
+	return hex.EncodeToString(h[:frameChecksumSize])
+}
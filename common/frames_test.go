@@ -0,0 +1,67 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrames(t *testing.T) {
+	require := require.New(t)
+
+	data := bytes.Repeat([]byte("frames"), 100)
+	_, err := EncodeFrames(data, FrameDataSizeMinimum-1)
+	require.ErrorContains(err, "invalid frame size 63")
+	_, err = EncodeFrames(nil, FrameDataSizeDefault)
+	require.ErrorContains(err, "empty frames data")
+	_, err = EncodeFrames(data, len(data)/FrameCountLimit+FrameDataSizeMinimum)
+	require.Nil(err)
+
+	frames, err := EncodeFrames(data, 256)
+	require.Nil(err)
+	require.Len(frames, 3)
+	require.True(strings.HasPrefix(frames[0], "mixin:1/3:"))
+	require.True(strings.HasPrefix(frames[2], "mixin:3/3:"))
+	res, err := DecodeFrames(frames)
+	require.Nil(err)
+	require.Equal(data, res)
+
+	shuffled := []string{frames[2], "", frames[0], frames[2], " " + frames[1] + "\r"}
+	res, err = DecodeFrames(shuffled)
+	require.Nil(err)
+	require.Equal(data, res)
+
+	_, err = DecodeFrames(nil)
+	require.ErrorContains(err, "empty frames")
+	_, err = DecodeFrames(frames[:1])
+	require.ErrorContains(err, "frames missing [2 3] of 3")
+	_, err = DecodeFrames([]string{"mixin:1/0:00000000:AA"})
+	require.ErrorContains(err, "invalid frame sequence 1/0")
+	_, err = DecodeFrames([]string{"mixin:1/1:0000:AA"})
+	require.ErrorContains(err, "invalid frame checksum 0000")
+	_, err = DecodeFrames([]string{"bitcoin:1/1:00000000:AA"})
+	require.ErrorContains(err, "invalid frame bitcoin")
+
+	other, err := EncodeFrames(append(data, 1), 256)
+	require.Nil(err)
+	_, err = DecodeFrames([]string{frames[0], other[1], frames[2]})
+	require.ErrorContains(err, "frame 2/3 of another data")
+
+	parts := strings.Split(frames[1], ":")
+	parts[3] = base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte("x"), 256))
+	forged := strings.Join(parts, ":")
+	_, err = DecodeFrames([]string{frames[0], forged, frames[2]})
+	require.ErrorContains(err, "invalid frames checksum")
+	_, err = DecodeFrames([]string{frames[0], forged, frames[1], frames[2]})
+	require.ErrorContains(err, "frame 2/3 duplicated with another chunk")
+
+	frames, err = EncodeFrames(data[:FrameDataSizeMinimum], FrameDataSizeMinimum)
+	require.Nil(err)
+	require.Len(frames, 1)
+	res, err = DecodeFrames(frames)
+	require.Nil(err)
+	require.Equal(data[:FrameDataSizeMinimum], res)
+}
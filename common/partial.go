@@ -0,0 +1,555 @@
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const PartialTransactionVersion = 1
+
+// PartialInput is the script UTXO spent by an input of a partially signed
+// transaction, with the signatures collected for it so far, indexed by the
+// position of the signing key in Keys as in the SignaturesMap.
+type PartialInput struct {
+	Hash       crypto.Hash                  `json:"hash"`
+	Index      uint                         `json:"index"`
+	Keys       []*crypto.Key                `json:"keys"`
+	Mask       crypto.Key                   `json:"mask"`
+	Script     Script                       `json:"script"`
+	Signatures map[uint16]*crypto.Signature `json:"signatures"`
+}
+
+// PartialSigner is a key of the aggregated signature of all the inputs, by
+// its position in the keys of all the inputs in order, as the signers of the
+// AggregatedSignature. Partial is nil until the signer makes it with the
+// secret nonces of the Commitment.
+type PartialSigner struct {
+	Index      int           `json:"index"`
+	Commitment [2]crypto.Key `json:"commitment"`
+	Partial    *crypto.Key   `json:"partial,omitempty"`
+}
+
+// PartialNonce is the secret nonces of a PartialSigner commitment, which the
+// signer keeps to itself until its partial signature is made.
+type PartialNonce struct {
+	Transaction crypto.Hash   `json:"transaction"`
+	Index       int           `json:"index"`
+	Secret      [2]crypto.Key `json:"secret"`
+}
+
+// PartialTransaction is a portable container to collect the signatures of a
+// transaction spending threshold script outputs. It carries everything the
+// signers need, so they can sign offline without a node, then the copies
+// signed by different signers are combined and finalized.
+//
+// The signers either sign the inputs with the SignaturesMap, or commit their
+// nonces to the Signers first, then make the partial signatures combined into
+// a single AggregatedSignature, without sharing their private keys.
+type PartialTransaction struct {
+	Transaction *VersionedTransaction
+	Inputs      []*PartialInput
+	Signers     []*PartialSigner
+}
+
+type partialTransactionJSON struct {
+	Version int              `json:"version"`
+	Raw     string           `json:"raw"`
+	Inputs  []*PartialInput  `json:"inputs"`
+	Signers []*PartialSigner `json:"signers,omitempty"`
+}
+
+// NewPartialTransaction makes the container from the transaction and the
+// UTXOs spent by its inputs in order. The signatures already in the
+// SignaturesMap of the transaction are verified and kept.
+func NewPartialTransaction(ver *VersionedTransaction, utxos []*UTXO) (*PartialTransaction, error) {
+	if ver.AggregatedSignature != nil {
+		return nil, fmt.Errorf("aggregated signature not supported")
+	}
+	if len(utxos) != len(ver.Inputs) {
+		return nil, fmt.Errorf("invalid utxos count %d %d", len(utxos), len(ver.Inputs))
+	}
+	if len(ver.SignaturesMap) > len(ver.Inputs) {
+		return nil, fmt.Errorf("invalid signatures count %d %d", len(ver.SignaturesMap), len(ver.Inputs))
+	}
+
+	pt := &PartialTransaction{Transaction: unsignedTransaction(ver)}
+	for i, in := range ver.Inputs {
+		utxo := utxos[i]
+		if utxo.Hash != in.Hash || utxo.Index != in.Index {
+			return nil, fmt.Errorf("invalid utxo %s:%d for input %d", utxo.Hash, utxo.Index, i)
+		}
+		pi := &PartialInput{
+			Hash:       in.Hash,
+			Index:      in.Index,
+			Keys:       utxo.Keys,
+			Mask:       utxo.Mask,
+			Script:     utxo.Script,
+			Signatures: make(map[uint16]*crypto.Signature),
+		}
+		if i < len(ver.SignaturesMap) {
+			for k, sig := range ver.SignaturesMap[i] {
+				pi.Signatures[k] = sig
+			}
+		}
+		pt.Inputs = append(pt.Inputs, pi)
+	}
+	return pt, pt.verify()
+}
+
+// UnmarshalPartialTransaction decodes the JSON container and verifies all
+// the signatures in it.
+func UnmarshalPartialTransaction(b []byte) (*PartialTransaction, error) {
+	var pj partialTransactionJSON
+	err := json.Unmarshal(b, &pj)
+	if err != nil {
+		return nil, err
+	}
+	if pj.Version != PartialTransactionVersion {
+		return nil, fmt.Errorf("invalid partial transaction version %d", pj.Version)
+	}
+	raw, err := hex.DecodeString(pj.Raw)
+	if err != nil {
+		return nil, err
+	}
+	ver, err := UnmarshalVersionedTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	if ver.AggregatedSignature != nil || len(ver.SignaturesMap) > 0 {
+		return nil, fmt.Errorf("signed partial transaction raw")
+	}
+	pt := &PartialTransaction{Transaction: ver, Inputs: pj.Inputs, Signers: pj.Signers}
+	for _, in := range pt.Inputs {
+		if in != nil && in.Signatures == nil {
+			in.Signatures = make(map[uint16]*crypto.Signature)
+		}
+	}
+	return pt, pt.verify()
+}
+
+func (pt *PartialTransaction) Marshal() []byte {
+	data, err := json.Marshal(partialTransactionJSON{
+		Version: PartialTransactionVersion,
+		Raw:     hex.EncodeToString(pt.Transaction.Marshal()),
+		Inputs:  pt.Inputs,
+		Signers: pt.Signers,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// Sign adds the signatures of the accounts to all the inputs they own keys
+// of, and returns the count of new signatures.
+func (pt *PartialTransaction) Sign(accounts []*Address) (int, error) {
+	if len(pt.Signers) > 0 {
+		return 0, fmt.Errorf("partial transaction committed to aggregation")
+	}
+	msg := pt.Transaction.PayloadHash()
+	var count int
+	for m, priv := range pt.privateKeys(accounts) {
+		in, k := pt.locate(m)
+		if in.Signatures[k] != nil {
+			continue
+		}
+		sig := priv.Sign(msg)
+		in.Signatures[k] = &sig
+		count += 1
+	}
+	return count, nil
+}
+
+// Commit adds the nonce commitments of the accounts for all the keys they
+// own to the Signers, and returns the secret nonces to make the partial
+// signatures with AggregateSign later.
+func (pt *PartialTransaction) Commit(accounts []*Address) ([]*PartialNonce, error) {
+	if pt.signed() {
+		return nil, fmt.Errorf("partial transaction signed without aggregation")
+	}
+	if pt.aggregationFixed() {
+		return nil, fmt.Errorf("aggregation signers fixed")
+	}
+	msg := pt.Transaction.PayloadHash()
+	var nonces []*PartialNonce
+	for m, priv := range pt.privateKeys(accounts) {
+		if pt.signer(m) != nil {
+			continue
+		}
+		secret, public := crypto.AggregateNonce(priv, msg)
+		pt.Signers = append(pt.Signers, &PartialSigner{Index: m, Commitment: public})
+		nonces = append(nonces, &PartialNonce{Transaction: msg, Index: m, Secret: secret})
+	}
+	slices.SortFunc(pt.Signers, func(a, b *PartialSigner) int { return a.Index - b.Index })
+	slices.SortFunc(nonces, func(a, b *PartialNonce) int { return a.Index - b.Index })
+	return nonces, nil
+}
+
+// AggregateSign makes the partial signatures of the accounts with the secret
+// nonces of their commitments, and returns the count of new signatures. All
+// the input thresholds must be met by the committed signers, and no more
+// signers can commit after this.
+func (pt *PartialTransaction) AggregateSign(accounts []*Address, nonces []*PartialNonce) (int, error) {
+	for i, in := range pt.Inputs {
+		if c := len(pt.CommittedKeys(i)); c < in.Threshold() {
+			return 0, fmt.Errorf("input %d threshold not committed %d %d", i, c, in.Threshold())
+		}
+	}
+	msg := pt.Transaction.PayloadHash()
+	keys := pt.keys()
+	signers, commitments := pt.aggregation()
+	privs := pt.privateKeys(accounts)
+
+	var count int
+	for _, n := range nonces {
+		if n.Transaction != msg {
+			return count, fmt.Errorf("nonce of transaction %s", n.Transaction)
+		}
+		s := pt.signer(n.Index)
+		if s == nil {
+			return count, fmt.Errorf("signer %d not committed", n.Index)
+		}
+		if s.Partial != nil {
+			continue
+		}
+		priv := privs[n.Index]
+		if priv == nil {
+			return count, fmt.Errorf("signer %d account not found", n.Index)
+		}
+		partial, err := crypto.AggregatePartialSign(priv, n.Secret, keys, signers, n.Index, commitments, msg)
+		if err != nil {
+			return count, err
+		}
+		s.Partial = partial
+		count += 1
+	}
+	return count, nil
+}
+
+// Combine merges the signatures of another copy of the same transaction,
+// and returns the count of new signatures.
+func (pt *PartialTransaction) Combine(other *PartialTransaction) (int, error) {
+	hash := pt.Transaction.PayloadHash()
+	if oh := other.Transaction.PayloadHash(); oh != hash {
+		return 0, fmt.Errorf("partial transaction not match %s %s", oh, hash)
+	}
+	if len(other.Inputs) != len(pt.Inputs) {
+		return 0, fmt.Errorf("invalid inputs count %d %d", len(other.Inputs), len(pt.Inputs))
+	}
+	for i, in := range pt.Inputs {
+		oi := other.Inputs[i]
+		if oi.Mask != in.Mask || len(oi.Keys) != len(in.Keys) {
+			return 0, fmt.Errorf("input %d utxo not match", i)
+		}
+		for j, k := range in.Keys {
+			if *oi.Keys[j] != *k {
+				return 0, fmt.Errorf("input %d utxo not match", i)
+			}
+		}
+	}
+
+	if (pt.signed() && len(other.Signers) > 0) || (len(pt.Signers) > 0 && other.signed()) {
+		return 0, fmt.Errorf("partial transaction signed with and without aggregation")
+	}
+	if len(pt.Signers) > 0 || len(other.Signers) > 0 {
+		return pt.combineSigners(other)
+	}
+
+	var count int
+	for i, in := range pt.Inputs {
+		for k, sig := range other.Inputs[i].Signatures {
+			if in.Signatures[k] == nil {
+				in.Signatures[k] = sig
+				count += 1
+			}
+		}
+	}
+	return count, nil
+}
+
+// Threshold is the count of signatures required by the input script.
+func (in *PartialInput) Threshold() int {
+	return int(in.Script[2])
+}
+
+// SignedKeys returns the key indexes of the input signed, either with the
+// SignaturesMap or with the partial signatures of the aggregation.
+func (pt *PartialTransaction) SignedKeys(i int) []int {
+	var signers []int
+	for k := range pt.Inputs[i].Signatures {
+		signers = append(signers, int(k))
+	}
+	offset := pt.offset(i)
+	for _, s := range pt.Signers {
+		if s.Partial != nil && s.Index >= offset && s.Index < offset+len(pt.Inputs[i].Keys) {
+			signers = append(signers, s.Index-offset)
+		}
+	}
+	slices.Sort(signers)
+	return signers
+}
+
+// CommittedKeys returns the key indexes of the input committed to the
+// aggregation, with or without the partial signatures.
+func (pt *PartialTransaction) CommittedKeys(i int) []int {
+	var committed []int
+	offset := pt.offset(i)
+	for _, s := range pt.Signers {
+		if s.Index >= offset && s.Index < offset+len(pt.Inputs[i].Keys) {
+			committed = append(committed, s.Index-offset)
+		}
+	}
+	return committed
+}
+
+// Complete tells whether all the input thresholds are met, and all the
+// committed signers of the aggregation have made the partial signatures.
+func (pt *PartialTransaction) Complete() bool {
+	for _, s := range pt.Signers {
+		if s.Partial == nil {
+			return false
+		}
+	}
+	for i, in := range pt.Inputs {
+		if len(pt.SignedKeys(i)) < in.Threshold() {
+			return false
+		}
+	}
+	return true
+}
+
+// Finalize returns the signed transaction when all the input thresholds are
+// met. Without aggregation only a threshold count of signatures is kept for
+// each input, otherwise all the partial signatures are combined.
+func (pt *PartialTransaction) Finalize() (*VersionedTransaction, error) {
+	signed := pt.Transaction.SignedTransaction
+	signed.SignaturesMap = nil
+	signed.AggregatedSignature = nil
+	for i, in := range pt.Inputs {
+		if c := len(pt.SignedKeys(i)); c < in.Threshold() {
+			return nil, fmt.Errorf("input %d threshold not met %d %d", i, c, in.Threshold())
+		}
+	}
+	if len(pt.Signers) > 0 {
+		as, err := pt.aggregate()
+		if err != nil {
+			return nil, err
+		}
+		signed.AggregatedSignature = as
+		return signed.AsVersioned(), nil
+	}
+
+	for _, in := range pt.Inputs {
+		sigs := make(map[uint16]*crypto.Signature)
+		for k := uint16(0); len(sigs) < in.Threshold(); k++ {
+			if sig := in.Signatures[k]; sig != nil {
+				sigs[k] = sig
+			}
+		}
+		signed.SignaturesMap = append(signed.SignaturesMap, sigs)
+	}
+	return signed.AsVersioned(), nil
+}
+
+func (pt *PartialTransaction) aggregate() (*AggregatedSignature, error) {
+	var partials []*crypto.Key
+	for _, s := range pt.Signers {
+		if s.Partial == nil {
+			return nil, fmt.Errorf("signer %d partial signature not found", s.Index)
+		}
+		partials = append(partials, s.Partial)
+	}
+	signers, commitments := pt.aggregation()
+	msg := pt.Transaction.PayloadHash()
+	sig, err := crypto.AggregateCombine(partials, pt.keys(), signers, commitments, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &AggregatedSignature{Signers: signers, Signature: *sig}, nil
+}
+
+func (pt *PartialTransaction) combineSigners(other *PartialTransaction) (int, error) {
+	union := len(pt.Signers)
+	for _, os := range other.Signers {
+		s := pt.signer(os.Index)
+		if s == nil {
+			union += 1
+		} else if s.Commitment != os.Commitment {
+			return 0, fmt.Errorf("signer %d commitment not match", os.Index)
+		}
+	}
+	if pt.aggregationFixed() && union != len(pt.Signers) {
+		return 0, fmt.Errorf("aggregation signers fixed")
+	}
+	if other.aggregationFixed() && union != len(other.Signers) {
+		return 0, fmt.Errorf("aggregation signers fixed")
+	}
+
+	var count int
+	for _, os := range other.Signers {
+		s := pt.signer(os.Index)
+		if s == nil {
+			s = &PartialSigner{Index: os.Index, Commitment: os.Commitment}
+			pt.Signers = append(pt.Signers, s)
+		}
+		if s.Partial == nil && os.Partial != nil {
+			s.Partial = os.Partial
+			count += 1
+		}
+	}
+	slices.SortFunc(pt.Signers, func(a, b *PartialSigner) int { return a.Index - b.Index })
+	return count, nil
+}
+
+func (pt *PartialTransaction) verify() error {
+	ver := pt.Transaction
+	if len(pt.Inputs) != len(ver.Inputs) {
+		return fmt.Errorf("invalid inputs count %d %d", len(pt.Inputs), len(ver.Inputs))
+	}
+	msg := ver.PayloadHash()
+	for i, in := range pt.Inputs {
+		if in == nil {
+			return fmt.Errorf("invalid input %d", i)
+		}
+		if in.Hash != ver.Inputs[i].Hash || in.Index != ver.Inputs[i].Index {
+			return fmt.Errorf("invalid input %d %s:%d", i, in.Hash, in.Index)
+		}
+		if !in.Hash.HasValue() {
+			return fmt.Errorf("invalid input %d type", i)
+		}
+		err := in.Script.VerifyFormat()
+		if err != nil {
+			return fmt.Errorf("input %d %v", i, err)
+		}
+		if len(in.Keys) == 0 || len(in.Keys) > SliceCountLimit {
+			return fmt.Errorf("invalid input %d keys count %d", i, len(in.Keys))
+		}
+		if slices.Contains(in.Keys, nil) {
+			return fmt.Errorf("invalid input %d keys", i)
+		}
+		if in.Threshold() > len(in.Keys) {
+			return fmt.Errorf("invalid input %d threshold %d %d", i, in.Threshold(), len(in.Keys))
+		}
+		for k, sig := range in.Signatures {
+			if int(k) >= len(in.Keys) || sig == nil {
+				return fmt.Errorf("invalid input %d signature index %d", i, k)
+			}
+			if !in.Keys[k].Verify(msg, *sig) {
+				return fmt.Errorf("invalid input %d signature %d", i, k)
+			}
+		}
+	}
+	if len(pt.Signers) == 0 {
+		return nil
+	}
+
+	if pt.signed() {
+		return fmt.Errorf("partial transaction signed with and without aggregation")
+	}
+	keys := pt.keys()
+	for i, s := range pt.Signers {
+		if s == nil || s.Index >= len(keys) || (i > 0 && s.Index <= pt.Signers[i-1].Index) {
+			return fmt.Errorf("invalid signer %d", i)
+		}
+		if !s.Commitment[0].CheckKey() || !s.Commitment[1].CheckKey() {
+			return fmt.Errorf("invalid signer %d commitment", s.Index)
+		}
+	}
+	signers, commitments := pt.aggregation()
+	for _, s := range pt.Signers {
+		if s.Partial == nil {
+			continue
+		}
+		err := crypto.AggregatePartialVerify(s.Partial, keys, signers, s.Index, commitments, msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// privateKeys derives the private ghost keys of the accounts, by their
+// positions in the keys of all the inputs.
+func (pt *PartialTransaction) privateKeys(accounts []*Address) map[int]*crypto.Key {
+	privs := make(map[int]*crypto.Key)
+	var offset int
+	for _, in := range pt.Inputs {
+		keysFilter := make(map[crypto.Key]int)
+		for i, k := range in.Keys {
+			keysFilter[*k] = i
+		}
+		for _, acc := range accounts {
+			priv := crypto.DeriveGhostPrivateKey(&in.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(in.Index))
+			if i, found := keysFilter[priv.Public()]; found {
+				privs[offset+i] = priv
+			}
+		}
+		offset += len(in.Keys)
+	}
+	return privs
+}
+
+func (pt *PartialTransaction) locate(m int) (*PartialInput, uint16) {
+	for _, in := range pt.Inputs {
+		if m < len(in.Keys) {
+			return in, uint16(m)
+		}
+		m -= len(in.Keys)
+	}
+	panic(m)
+}
+
+func (pt *PartialTransaction) offset(i int) int {
+	var offset int
+	for _, in := range pt.Inputs[:i] {
+		offset += len(in.Keys)
+	}
+	return offset
+}
+
+func (pt *PartialTransaction) keys() []*crypto.Key {
+	var keys []*crypto.Key
+	for _, in := range pt.Inputs {
+		keys = append(keys, in.Keys...)
+	}
+	return keys
+}
+
+func (pt *PartialTransaction) signer(m int) *PartialSigner {
+	i := slices.IndexFunc(pt.Signers, func(s *PartialSigner) bool { return s.Index == m })
+	if i < 0 {
+		return nil
+	}
+	return pt.Signers[i]
+}
+
+func (pt *PartialTransaction) aggregation() ([]int, [][2]crypto.Key) {
+	signers := make([]int, len(pt.Signers))
+	commitments := make([][2]crypto.Key, len(pt.Signers))
+	for i, s := range pt.Signers {
+		signers[i] = s.Index
+		commitments[i] = s.Commitment
+	}
+	return signers, commitments
+}
+
+// aggregationFixed tells whether any partial signature is made, which binds
+// all the committed signers.
+func (pt *PartialTransaction) aggregationFixed() bool {
+	return slices.ContainsFunc(pt.Signers, func(s *PartialSigner) bool { return s.Partial != nil })
+}
+
+func (pt *PartialTransaction) signed() bool {
+	return slices.ContainsFunc(pt.Inputs, func(in *PartialInput) bool { return len(in.Signatures) > 0 })
+}
+
+func unsignedTransaction(ver *VersionedTransaction) *VersionedTransaction {
+	signed := ver.SignedTransaction
+	signed.AggregatedSignature = nil
+	signed.SignaturesMap = nil
+	return signed.AsVersioned()
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialTransaction(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 3)
+	for i := range accounts {
+		a := randomAccount()
+		accounts[i] = &a
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("partial")), 1)
+	receiver := randomAccount()
+	tx.AddScriptOutput([]*Address{&receiver}, NewThresholdScript(1), NewInteger(10000), seed)
+	ver := tx.AsVersioned()
+	utxo, err := store.ReadUTXOLock(ver.Inputs[0].Hash, ver.Inputs[0].Index)
+	require.Nil(err)
+	require.Len(utxo.Keys, 3)
+
+	pt, err := NewPartialTransaction(ver, []*UTXO{&utxo.UTXO})
+	require.Nil(err)
+	require.Equal(2, pt.Inputs[0].Threshold())
+	require.False(pt.Complete())
+	_, err = pt.Finalize()
+	require.ErrorContains(err, "input 0 threshold not met 0 2")
+
+	other, err := UnmarshalPartialTransaction(pt.Marshal())
+	require.Nil(err)
+	count, err := pt.Sign(accounts[:1])
+	require.Nil(err)
+	require.Equal(1, count)
+	count, _ = pt.Sign(accounts[:1])
+	require.Equal(0, count)
+	count, _ = pt.Sign([]*Address{&receiver})
+	require.Equal(0, count)
+	count, _ = other.Sign(accounts[2:])
+	require.Equal(1, count)
+	require.False(pt.Complete())
+
+	other, err = UnmarshalPartialTransaction(other.Marshal())
+	require.Nil(err)
+	require.Len(other.Inputs[0].Signatures, 1)
+	require.NotNil(other.Inputs[0].Signatures[2])
+	count, err = pt.Combine(other)
+	require.Nil(err)
+	require.Equal(1, count)
+	require.True(pt.Complete())
+
+	signed, err := pt.Finalize()
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), signed.PayloadHash())
+	require.Len(signed.SignaturesMap, 1)
+	require.Len(signed.SignaturesMap[0], 2)
+	err = signed.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.Nil(err)
+
+	lifted, err := NewPartialTransaction(signed, []*UTXO{&utxo.UTXO})
+	require.Nil(err)
+	require.True(lifted.Complete())
+
+	other.Inputs[0].Signatures[0] = other.Inputs[0].Signatures[2]
+	_, err = UnmarshalPartialTransaction(other.Marshal())
+	require.ErrorContains(err, "invalid input 0 signature 0")
+
+	tx.Extra = []byte("partial")
+	changed, err := NewPartialTransaction(tx.AsVersioned(), []*UTXO{&utxo.UTXO})
+	require.Nil(err)
+	_, err = pt.Combine(changed)
+	require.ErrorContains(err, "partial transaction not match")
+	_, err = changed.Commit(accounts)
+	require.Nil(err)
+	_, err = changed.Sign(accounts)
+	require.ErrorContains(err, "partial transaction committed to aggregation")
+	_, err = pt.Commit(accounts)
+	require.ErrorContains(err, "partial transaction signed without aggregation")
+}
+
+func TestPartialTransactionAggregation(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 4)
+	for i := range accounts {
+		a := randomAccount()
+		accounts[i] = &a
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("aggregation")), 1)
+	tx.AddInput(crypto.Blake3Hash([]byte("aggregation")), 2)
+	receiver := randomAccount()
+	tx.AddScriptOutput([]*Address{&receiver}, NewThresholdScript(1), NewInteger(20000), seed)
+	var utxos []*UTXO
+	for _, in := range tx.Inputs {
+		utxo, err := store.ReadUTXOLock(in.Hash, in.Index)
+		require.Nil(err)
+		utxos = append(utxos, &utxo.UTXO)
+	}
+	pt, err := NewPartialTransaction(tx.AsVersioned(), utxos)
+	require.Nil(err)
+	require.Equal(2, pt.Inputs[0].Threshold())
+	require.Equal(3, pt.Inputs[1].Threshold())
+
+	copies := make([]*PartialTransaction, 3)
+	nonces := make([][]*PartialNonce, 3)
+	for i := range copies {
+		copies[i], err = UnmarshalPartialTransaction(pt.Marshal())
+		require.Nil(err)
+		nonces[i], err = copies[i].Commit(accounts[i : i+1])
+		require.Nil(err)
+		require.Len(nonces[i], 2)
+		require.Equal(i, nonces[i][0].Index)
+		require.Equal(i+3, nonces[i][1].Index)
+	}
+	_, err = copies[0].AggregateSign(accounts[:1], nonces[0])
+	require.ErrorContains(err, "input 0 threshold not committed 1 2")
+
+	for _, c := range copies {
+		_, err = pt.Combine(c)
+		require.Nil(err)
+	}
+	require.Equal([]int{0, 1, 2}, pt.CommittedKeys(0))
+	require.Equal([]int{0, 1, 2}, pt.CommittedKeys(1))
+	require.False(pt.Complete())
+
+	for i := range copies {
+		copies[i], err = UnmarshalPartialTransaction(pt.Marshal())
+		require.Nil(err)
+	}
+	count, err := copies[0].AggregateSign(accounts[:1], nonces[0])
+	require.Nil(err)
+	require.Equal(2, count)
+	_, err = copies[0].AggregateSign(accounts[1:2], nonces[1][:1])
+	require.Nil(err)
+	_, err = copies[0].Commit(accounts[3:])
+	require.ErrorContains(err, "aggregation signers fixed")
+	_, err = copies[2].AggregateSign(accounts[:1], nonces[2])
+	require.ErrorContains(err, "signer 2 account not found")
+	_, err = copies[2].AggregateSign(accounts[2:3], nonces[2])
+	require.Nil(err)
+
+	late, err := UnmarshalPartialTransaction(copies[1].Marshal())
+	require.Nil(err)
+	_, err = late.Commit(accounts[3:])
+	require.Nil(err)
+	_, err = copies[0].Combine(late)
+	require.ErrorContains(err, "aggregation signers fixed")
+
+	for _, c := range copies {
+		_, err = pt.Combine(c)
+		require.Nil(err)
+	}
+	_, err = pt.Finalize()
+	require.ErrorContains(err, "input 1 threshold not met 2 3")
+	require.False(pt.Complete())
+	pt, err = UnmarshalPartialTransaction(pt.Marshal())
+	require.Nil(err)
+	_, err = pt.AggregateSign(accounts[1:2], nonces[1])
+	require.Nil(err)
+	require.True(pt.Complete())
+
+	signed, err := pt.Finalize()
+	require.Nil(err)
+	require.Nil(signed.SignaturesMap)
+	require.Equal([]int{0, 1, 2, 3, 4, 5}, signed.AggregatedSignature.Signers)
+	signed, err = UnmarshalVersionedTransaction(signed.Marshal())
+	require.Nil(err)
+	err = signed.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.Nil(err)
+
+	pt.Signers[1].Partial = pt.Signers[0].Partial
+	_, err = UnmarshalPartialTransaction(pt.Marshal())
+	require.ErrorContains(err, "invalid aggregation partial signature 1")
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// ScanAccount is an account registered to the node output scanner by its
+// private view key and public spend key. Topology is the next snapshot to
+// scan for the account.
+type ScanAccount struct {
+	ViewKey  crypto.Key
+	SpendKey crypto.Key
+	Topology uint64
+}
+
+// ScanOutput is a finalized script output with at least one key owned by
+// Account. Spent is the finalized transaction spending it, or zero.
+type ScanOutput struct {
+	Account     Address
+	Transaction crypto.Hash
+	Index       uint
+	Asset       crypto.Hash
+	Amount      Integer
+	Keys        []*crypto.Key
+	Mask        crypto.Key
+	Script      Script
+	Snapshot    crypto.Hash
+	Topology    uint64
+	Spent       crypto.Hash
+}
+
+// ScanSpend is a finalized transaction spending the output at Hash:Index.
+type ScanSpend struct {
+	Hash        crypto.Hash
+	Index       uint
+	Transaction crypto.Hash
+}
+
+func (a *ScanAccount) Address() Address {
+	return Address{
+		PrivateViewKey: a.ViewKey,
+		PublicViewKey:  a.ViewKey.Public(),
+		PublicSpendKey: a.SpendKey,
+	}
+}
+
+// Owns tells whether the ghost key of the output at index belongs to the
+// account, both the key and the mask should be valid points.
+func (a *ScanAccount) Owns(key, mask *crypto.Key, index uint64) bool {
+	return *crypto.ViewGhostOutputKey(key, &a.ViewKey, mask, index) == a.SpendKey
+}
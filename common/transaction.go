@@ -2,6 +2,8 @@ package common
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/MixinNetwork/mixin/crypto"
 )
@@ -247,17 +249,65 @@ func (signed *SignedTransaction) SignRaw(key crypto.Key) error {
 }
 
 func (signed *SignedTransaction) AggregateSign(reader UTXOKeysReader, accounts [][]*Address, seed []byte) error {
-	var signers []int
-	var pubKeys, privKeys []*crypto.Key
-	for index, in := range signed.Inputs {
+	utxos, err := signed.readUTXOKeys(reader)
+	if err != nil {
+		return err
+	}
+	return signed.aggregateSign(utxos, accounts, seed)
+}
+
+// AggregateSignAccounts signs all the inputs with the same accounts into an
+// AggregatedSignature, each input is signed by the accounts owning its keys,
+// in the order of the keys.
+func (signed *SignedTransaction) AggregateSignAccounts(reader UTXOKeysReader, accounts []*Address, seed []byte) error {
+	utxos, err := signed.readUTXOKeys(reader)
+	if err != nil {
+		return err
+	}
+	signers := make([][]*Address, len(utxos))
+	for index, utxo := range utxos {
+		in := signed.Inputs[index]
+		owners := make(map[int]*Address)
+		for _, acc := range accounts {
+			priv := crypto.DeriveGhostPrivateKey(&utxo.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(in.Index))
+			i := slices.IndexFunc(utxo.Keys, func(k *crypto.Key) bool { return *k == priv.Public() })
+			if i >= 0 {
+				owners[i] = acc
+			}
+		}
+		if len(owners) == 0 {
+			return fmt.Errorf("no key for the input %s:%d", in.Hash.String(), in.Index)
+		}
+		for _, i := range slices.Sorted(maps.Keys(owners)) {
+			signers[index] = append(signers[index], owners[i])
+		}
+	}
+	return signed.aggregateSign(utxos, signers, seed)
+}
+
+func (signed *SignedTransaction) readUTXOKeys(reader UTXOKeysReader) ([]*UTXOKeys, error) {
+	utxos := make([]*UTXOKeys, len(signed.Inputs))
+	for i, in := range signed.Inputs {
+		if in.Deposit != nil || in.Mint != nil {
+			return nil, fmt.Errorf("invalid input %d for aggregation", i)
+		}
 		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if utxo == nil {
-			return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+			return nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
 		}
+		utxos[i] = utxo
+	}
+	return utxos, nil
+}
 
+func (signed *SignedTransaction) aggregateSign(utxos []*UTXOKeys, accounts [][]*Address, seed []byte) error {
+	var signers []int
+	var pubKeys, privKeys []*crypto.Key
+	for index, in := range signed.Inputs {
+		utxo := utxos[index]
 		keysFilter := make(map[string]int)
 		for i, k := range utxo.Keys {
 			keysFilter[k.String()] = i
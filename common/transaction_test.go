@@ -108,6 +108,8 @@ func TestTransactionReferences(t *testing.T) {
 	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
 	require.NotNil(err)
 	require.Equal("batch verification failure 3 3", err.Error())
+	require.Equal(ErrorCodeInvalidSignature, AsError(err).Code)
+	require.Equal(-1, AsError(err).Input)
 	sm = make([]map[uint16]*crypto.Signature, 2)
 	for i, m := range om {
 		if sm[i] == nil {
@@ -387,3 +389,52 @@ func (ver *VersionedTransaction) resetCache() {
 	ver.hash = crypto.Hash{}
 	ver.pmbytes = nil
 }
+
+func TestExtraStoragePrice(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("0.00000000", ExtraStoragePrice(0).String())
+	require.Equal("0.00000000", ExtraStoragePrice(ExtraSizeGeneralLimit).String())
+	require.Equal("0.00010000", ExtraStoragePrice(ExtraSizeGeneralLimit+1).String())
+	require.Equal("0.00010000", ExtraStoragePrice(ExtraSizeStorageStep).String())
+	require.Equal("0.00020000", ExtraStoragePrice(ExtraSizeStorageStep+1).String())
+
+	account := deterministicAddress(170)
+	for _, size := range []int{ExtraSizeGeneralLimit + 1, ExtraSizeStorageStep * 3, ExtraSizeStorageCapacity} {
+		tx := NewTransactionV5(XINAssetId)
+		tx.AddOutputWithType(OutputTypeScript, []*Address{&account}, NewThresholdScript(64), ExtraStoragePrice(size), bytes.Repeat([]byte{13}, 64))
+		require.GreaterOrEqual(tx.AsVersioned().GetExtraLimit(), size)
+	}
+}
+
+func TestAggregateSignAccounts(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 4)
+	for i := range accounts {
+		a := randomAccount()
+		accounts[i] = &a
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("aggregate")), 1)
+	tx.AddInput(crypto.Blake3Hash([]byte("aggregate")), 2)
+	tx.AddScriptOutput(accounts[:1], NewThresholdScript(1), NewInteger(20000), seed)
+	ver := tx.AsVersioned()
+
+	signers := []*Address{accounts[2], accounts[0], accounts[1]}
+	err := ver.AggregateSign(store, [][]*Address{signers, signers}, seed)
+	require.ErrorContains(err, "invalid signers order")
+
+	err = ver.AggregateSignAccounts(store, signers, seed)
+	require.Nil(err)
+	require.Equal([]int{0, 1, 2, 3, 4, 5}, ver.AggregatedSignature.Signers)
+	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.Nil(err)
+
+	err = ver.AggregateSignAccounts(store, accounts[3:], seed)
+	require.ErrorContains(err, "no key for the input")
+}
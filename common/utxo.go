@@ -60,6 +60,21 @@ func (tx *VersionedTransaction) UnspentOutputs() []*UTXOWithLock {
 	return utxos
 }
 
+// Burned tells whether the output can never be spent, i.e. a script or
+// custodian update output whose threshold is larger than its keys count,
+// e.g. the 64/1 outputs to the internal vanish address.
+func (out *Output) Burned() bool {
+	switch out.Type {
+	case OutputTypeScript, OutputTypeCustodianUpdateNodes:
+	default:
+		return false
+	}
+	if out.Script.VerifyFormat() != nil {
+		return false
+	}
+	return int(out.Script[2]) > len(out.Keys)
+}
+
 func (out *UTXOWithLock) Marshal() []byte {
 	enc := NewMinimumEncoder()
 	enc.Write(out.Asset[:])
@@ -34,3 +34,22 @@ func TestUTXO(t *testing.T) {
 	require.Len(utxo.Output.Keys, 3)
 	require.Equal(XINAssetId, utxo.Asset)
 }
+
+func TestOutputBurned(t *testing.T) {
+	require := require.New(t)
+
+	out := &Output{Type: OutputTypeScript, Script: NewThresholdScript(2), Keys: make([]*crypto.Key, 3)}
+	require.False(out.Burned())
+	out.Script = NewThresholdScript(Operator64)
+	require.True(out.Burned())
+	out.Keys = make([]*crypto.Key, 64)
+	require.False(out.Burned())
+	out.Keys = make([]*crypto.Key, 1)
+	out.Type = OutputTypeCustodianUpdateNodes
+	require.True(out.Burned())
+	out.Type = OutputTypeNodePledge
+	require.False(out.Burned())
+	out.Type = OutputTypeScript
+	out.Script = Script{OperatorCmp, OperatorSum}
+	require.False(out.Burned())
+}
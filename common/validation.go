@@ -14,41 +14,41 @@ func (ver *VersionedTransaction) Validate(store DataStore, snapTime uint64, fork
 	switch ver.Version {
 	case TxVersionHashSignature:
 	default:
-		return fmt.Errorf("invalid tx version %d", ver.Version)
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid tx version %d", ver.Version)
 	}
 
 	if txType == TransactionTypeUnknown {
-		return fmt.Errorf("invalid tx type %d", txType)
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid tx type %d", txType)
 	}
 	if len(tx.Inputs) < 1 || len(tx.Outputs) < 1 {
-		return fmt.Errorf("invalid tx inputs or outputs %d %d",
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid tx inputs or outputs %d %d",
 			len(tx.Inputs), len(tx.Outputs))
 	}
 	if len(tx.Inputs) > SliceCountLimit || len(tx.Outputs) > SliceCountLimit ||
 		len(tx.References) > SliceCountLimit {
-		return fmt.Errorf("invalid tx inputs or outputs %d %d %d",
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid tx inputs or outputs %d %d %d",
 			len(tx.Inputs), len(tx.Outputs), len(tx.References))
 	}
-	for _, in := range tx.Inputs {
+	for i, in := range tx.Inputs {
 		if in.Index > InputIndexLimit {
-			return fmt.Errorf("invalid input index %d", in.Index)
+			return NewInputErrorf(ErrorCodeInvalidInput, i, "invalid input index %d", in.Index)
 		}
 	}
 	if len(tx.Extra) > tx.GetExtraLimit() {
-		return fmt.Errorf("invalid extra size %d", len(tx.Extra))
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid extra size %d", len(tx.Extra))
 	}
 	ver.validatedSize = len(ver.PayloadMarshal())
 	if ver.validatedSize > config.TransactionMaximumSize {
-		return fmt.Errorf("invalid transaction size %d", len(ver.PayloadMarshal()))
+		return NewErrorf(ErrorCodeInvalidTransaction, "invalid transaction size %d", len(ver.PayloadMarshal()))
 	}
 
 	if tx.AggregatedSignature != nil {
 		if tx.SignaturesMap != nil {
-			return fmt.Errorf("invalid signatures map %d", len(tx.SignaturesMap))
+			return NewErrorf(ErrorCodeInvalidSignature, "invalid signatures map %d", len(tx.SignaturesMap))
 		}
 	} else {
 		if len(tx.Inputs) != len(tx.SignaturesMap) && txType != TransactionTypeNodeRemove {
-			return fmt.Errorf("invalid tx signature number %d %d %d",
+			return NewErrorf(ErrorCodeInvalidSignature, "invalid tx signature number %d %d %d",
 				len(tx.Inputs), len(tx.SignaturesMap), txType)
 		}
 	}
@@ -62,13 +62,20 @@ func (ver *VersionedTransaction) Validate(store DataStore, snapTime uint64, fork
 		return err
 	}
 	if inputAmount.Sign() <= 0 {
-		return fmt.Errorf("invalid input amount %s", inputAmount)
+		return NewErrorf(ErrorCodeInvalidInput, "invalid input amount %s", inputAmount)
 	}
 	err = tx.validateOutputs(store, ver.PayloadHash(), inputAmount, fork)
 	if err != nil {
 		return err
 	}
 
+	err = ver.validateType(store, inputsFilter, snapTime)
+	return classifyError(ErrorCodeInvalidTransaction, err)
+}
+
+func (ver *VersionedTransaction) validateType(store DataStore, inputsFilter map[string]*UTXO, snapTime uint64) error {
+	tx := &ver.SignedTransaction
+	txType := tx.TransactionType()
 	switch txType {
 	case TransactionTypeScript:
 		return validateScriptTransaction(inputsFilter)
@@ -126,6 +133,16 @@ func (tx *SignedTransaction) GetExtraLimit() int {
 	return int(limit)
 }
 
+// ExtraStoragePrice returns the storage output amount needed for an extra of
+// size bytes, or zero when the size fits the general limit.
+func ExtraStoragePrice(size int) Integer {
+	if size <= ExtraSizeGeneralLimit {
+		return NewInteger(0)
+	}
+	cells := (size + ExtraSizeStorageStep - 1) / ExtraSizeStorageStep
+	return NewIntegerFromString(ExtraStoragePriceStep).Mul(cells)
+}
+
 func (tx *SignedTransaction) findStorageOutput() *Output {
 	var so *Output
 	for _, out := range tx.Outputs {
@@ -148,7 +165,7 @@ func (tx *SignedTransaction) findStorageOutput() *Output {
 func validateScriptTransaction(inputs map[string]*UTXO) error {
 	for _, in := range inputs {
 		if in.Type != OutputTypeScript && in.Type != OutputTypeNodeRemove {
-			return fmt.Errorf("invalid utxo type %d", in.Type)
+			return NewErrorf(ErrorCodeInvalidInput, "invalid utxo type %d", in.Type)
 		}
 	}
 	return nil
@@ -156,7 +173,7 @@ func validateScriptTransaction(inputs map[string]*UTXO) error {
 
 func validateReferences(store TransactionReader, tx *SignedTransaction) error {
 	if len(tx.References) > ReferencesCountLimit {
-		return fmt.Errorf("too many references %d", len(tx.References))
+		return NewErrorf(ErrorCodeInvalidTransaction, "too many references %d", len(tx.References))
 	}
 
 	for _, r := range tx.References {
@@ -165,7 +182,7 @@ func validateReferences(store TransactionReader, tx *SignedTransaction) error {
 			return err
 		}
 		if rtx == nil || snap == "" {
-			return fmt.Errorf("reference not found %s", r)
+			return NewErrorf(ErrorCodeReferenceNotFound, "reference not found %s", r)
 		}
 	}
 
@@ -180,7 +197,7 @@ func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Ha
 
 	for i, in := range tx.Inputs {
 		if len(in.Genesis) > 0 {
-			return inputsFilter, inputAmount, fmt.Errorf("invalid genesis %v", in)
+			return inputsFilter, inputAmount, NewInputErrorf(ErrorCodeInvalidInput, i, "invalid genesis %v", in)
 		}
 		if in.Mint != nil {
 			return inputsFilter, in.Mint.Amount, nil
@@ -191,7 +208,7 @@ func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Ha
 
 		fk := fmt.Sprintf("%s:%d", in.Hash.String(), in.Index)
 		if inputsFilter[fk] != nil {
-			return inputsFilter, inputAmount, fmt.Errorf("invalid input %s", fk)
+			return inputsFilter, inputAmount, NewInputErrorf(ErrorCodeInvalidInput, i, "invalid input %s", fk)
 		}
 
 		utxo, err := store.ReadUTXOLock(in.Hash, in.Index)
@@ -199,16 +216,16 @@ func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Ha
 			return inputsFilter, inputAmount, err
 		}
 		if utxo == nil {
-			err := fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+			err := NewInputErrorf(ErrorCodeInputNotFound, i, "input not found %s:%d", in.Hash.String(), in.Index)
 			return inputsFilter, inputAmount, err
 		}
 		if utxo.Asset != tx.Asset {
-			err := fmt.Errorf("invalid input asset %s %s", utxo.Asset.String(), tx.Asset.String())
+			err := NewInputErrorf(ErrorCodeInvalidInput, i, "invalid input asset %s %s", utxo.Asset.String(), tx.Asset.String())
 			return inputsFilter, inputAmount, err
 		}
 		if utxo.LockHash.HasValue() && utxo.LockHash != hash {
 			if !fork {
-				err := fmt.Errorf("input locked for transaction %s", utxo.LockHash)
+				err := NewInputErrorf(ErrorCodeInputLocked, i, "input locked for transaction %s", utxo.LockHash)
 				return inputsFilter, inputAmount, err
 			}
 		}
@@ -226,13 +243,13 @@ func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Ha
 		return inputsFilter, inputAmount, nil
 	}
 	if len(keySigs) < len(tx.Inputs) {
-		err := fmt.Errorf("batch verification not ready %d %d", len(tx.Inputs), len(keySigs))
+		err := NewErrorf(ErrorCodeInvalidSignature, "batch verification not ready %d %d", len(tx.Inputs), len(keySigs))
 		return inputsFilter, inputAmount, err
 	}
 	if as := tx.AggregatedSignature; as != nil {
 		err := crypto.AggregateVerify(&as.Signature, allKeys, as.Signers, hash)
 		if err != nil {
-			err := fmt.Errorf("aggregate verification failure %s", err)
+			err := NewErrorf(ErrorCodeInvalidSignature, "aggregate verification failure %s", err)
 			return inputsFilter, inputAmount, err
 		}
 	} else {
@@ -243,7 +260,7 @@ func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Ha
 			sigs = append(sigs, s)
 		}
 		if !crypto.BatchVerify(hash, keys, sigs) {
-			err := fmt.Errorf("batch verification failure %d %d", len(keys), len(sigs))
+			err := NewErrorf(ErrorCodeInvalidSignature, "batch verification failure %d %d", len(keys), len(sigs))
 			return inputsFilter, inputAmount, err
 		}
 	}
@@ -256,19 +273,19 @@ func (tx *Transaction) validateOutputs(store GhostLocker, hash crypto.Hash, inpu
 	ghostKeys := make([]*crypto.Key, 0)
 	for _, o := range tx.Outputs {
 		if len(o.Keys) > SliceCountLimit {
-			return fmt.Errorf("invalid output keys count %d", len(o.Keys))
+			return NewErrorf(ErrorCodeInvalidOutput, "invalid output keys count %d", len(o.Keys))
 		}
 		if o.Amount.Sign() <= 0 {
-			return fmt.Errorf("invalid output amount %s", o.Amount.String())
+			return NewErrorf(ErrorCodeInvalidOutput, "invalid output amount %s", o.Amount.String())
 		}
 
 		for _, k := range o.Keys {
 			if ghostKeysFilter[*k] {
-				return fmt.Errorf("invalid output key %s", k.String())
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output key %s", k.String())
 			}
 			ghostKeysFilter[*k] = true
 			if !k.CheckKey() {
-				return fmt.Errorf("invalid output key format %s", k.String())
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output key format %s", k.String())
 
 			}
 			ghostKeys = append(ghostKeys, k)
@@ -281,34 +298,34 @@ func (tx *Transaction) validateOutputs(store GhostLocker, hash crypto.Hash, inpu
 			OutputTypeNodeCancel,
 			OutputTypeNodeAccept:
 			if len(o.Keys) != 0 {
-				return fmt.Errorf("invalid output keys count %d for kernel multisig transaction", len(o.Keys))
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output keys count %d for kernel multisig transaction", len(o.Keys))
 			}
 			if len(o.Script) != 0 {
-				return fmt.Errorf("invalid output script %s for kernel multisig transaction", o.Script)
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output script %s for kernel multisig transaction", o.Script)
 			}
 			if o.Mask.HasValue() {
-				return fmt.Errorf("invalid output empty mask %s for kernel multisig transaction", o.Mask)
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output empty mask %s for kernel multisig transaction", o.Mask)
 			}
 		default:
 			err := o.Script.VerifyFormat()
 			if err != nil {
-				return err
+				return NewError(ErrorCodeInvalidOutput, err)
 			}
 			if !o.Mask.HasValue() {
-				return fmt.Errorf("invalid script output empty mask %s", o.Mask)
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid script output empty mask %s", o.Mask)
 			}
 			if !o.Mask.CheckKey() {
-				return fmt.Errorf("invalid output mask format %s", o.Mask)
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid output mask format %s", o.Mask)
 			}
 			if o.Withdrawal != nil {
-				return fmt.Errorf("invalid script output with withdrawal %s", o.Withdrawal.Address)
+				return NewErrorf(ErrorCodeInvalidOutput, "invalid script output with withdrawal %s", o.Withdrawal.Address)
 			}
 		}
 		outputAmount = outputAmount.Add(o.Amount)
 	}
 
 	if inputAmount.Cmp(outputAmount) != 0 {
-		return fmt.Errorf("invalid input output amount %s %s", inputAmount, outputAmount)
+		return NewErrorf(ErrorCodeInvalidOutput, "invalid input output amount %s %s", inputAmount, outputAmount)
 	}
 	err := store.LockGhostKeys(ghostKeys, hash, fork)
 	if err != nil {
@@ -323,7 +340,7 @@ func validateUTXO(index int, utxo *UTXO, sigs []map[uint16]*crypto.Signature, as
 		if as != nil {
 			err := validateAggregatedSigners(as.Signers)
 			if err != nil {
-				return err
+				return classifyInputError(ErrorCodeInvalidSignature, index, err)
 			}
 			signers, limit := 0, offset+len(utxo.Keys)
 			for _, m := range as.Signers {
@@ -335,29 +352,31 @@ func validateUTXO(index int, utxo *UTXO, sigs []map[uint16]*crypto.Signature, as
 				keySigs[utxo.Keys[m-offset]] = nil
 				signers += 1
 			}
-			return utxo.Script.Validate(signers)
+			err = utxo.Script.Validate(signers)
+			return classifyInputError(ErrorCodeInvalidSignature, index, err)
 		} else {
 			for i, sig := range sigs[index] {
 				if int(i) >= len(utxo.Keys) {
-					return fmt.Errorf("invalid signature map index %d %d", i, len(utxo.Keys))
+					return NewInputErrorf(ErrorCodeInvalidSignature, index, "invalid signature map index %d %d", i, len(utxo.Keys))
 				}
 				keySigs[utxo.Keys[i]] = sig
 			}
-			return utxo.Script.Validate(len(sigs[index]))
+			err := utxo.Script.Validate(len(sigs[index]))
+			return classifyInputError(ErrorCodeInvalidSignature, index, err)
 		}
 	case OutputTypeNodePledge:
 		if txType == TransactionTypeNodeAccept || txType == TransactionTypeNodeCancel {
 			return nil
 		}
-		return fmt.Errorf("pledge input used for invalid transaction type %d", txType)
+		return NewInputErrorf(ErrorCodeInvalidInput, index, "pledge input used for invalid transaction type %d", txType)
 	case OutputTypeNodeAccept:
 		if txType == TransactionTypeNodeRemove {
 			return nil
 		}
-		return fmt.Errorf("accept input used for invalid transaction type %d", txType)
+		return NewInputErrorf(ErrorCodeInvalidInput, index, "accept input used for invalid transaction type %d", txType)
 	case OutputTypeNodeCancel:
-		return fmt.Errorf("should do more validation on those %d UTXOs", utxo.Type)
+		return NewInputErrorf(ErrorCodeInvalidInput, index, "should do more validation on those %d UTXOs", utxo.Type)
 	default:
-		return fmt.Errorf("invalid input type %d", utxo.Type)
+		return NewInputErrorf(ErrorCodeInvalidInput, index, "invalid input type %d", utxo.Type)
 	}
 }
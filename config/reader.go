@@ -44,10 +44,15 @@ type Custom struct {
 		KernelOprationPeriod int        `toml:"kernel-operation-period"`
 		MemoryCacheSize      int        `toml:"memory-cache-size"`
 		CacheTTL             int        `toml:"cache-ttl"`
+		DropJournalTTL       int        `toml:"drop-journal-ttl"`
+		DropJournalLimit     int        `toml:"drop-journal-limit"`
+		CosiTraceSize        int        `toml:"cosi-trace-size"`
+		CosiTraceFile        string     `toml:"cosi-trace-file"`
 	} `toml:"node"`
 	Storage struct {
 		ValueLogGC          bool `toml:"value-log-gc"`
 		MaxCompactionLevels int  `toml:"max-compaction-levels"`
+		TransactionIndex    bool `toml:"transaction-index"`
 	} `toml:"storage"`
 	P2P struct {
 		Port    int      `toml:"port"`
@@ -56,10 +61,16 @@ type Custom struct {
 		Metric  bool     `toml:"metric"`
 	} `toml:"p2p"`
 	RPC struct {
-		Port         int  `toml:"port"`
-		Runtime      bool `toml:"runtime"`
-		ObjectServer bool `toml:"object-server"`
+		Port           int    `toml:"port"`
+		Runtime        bool   `toml:"runtime"`
+		ObjectServer   bool   `toml:"object-server"`
+		SnapshotStream bool   `toml:"snapshot-stream"`
+		Metrics        bool   `toml:"metrics"`
+		AdminToken     string `toml:"admin-token"`
 	} `toml:"rpc"`
+	Scanner struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"scanner"`
 	Dev struct {
 		Port int `toml:"port"`
 	} `toml:"dev"`
@@ -89,5 +100,14 @@ func Initialize(file string) (*Custom, error) {
 	if config.Node.CacheTTL == 0 {
 		config.Node.CacheTTL = 3600 * 2
 	}
+	if config.Node.DropJournalTTL == 0 {
+		config.Node.DropJournalTTL = 3600 * 24 * 7
+	}
+	if config.Node.DropJournalLimit == 0 {
+		config.Node.DropJournalLimit = 10000
+	}
+	if config.Node.CosiTraceSize == 0 {
+		config.Node.CosiTraceSize = 1024
+	}
 	return &config, nil
 }
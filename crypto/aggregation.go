@@ -4,6 +4,7 @@ import (
 	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
+	"slices"
 
 	"filippo.io/edwards25519"
 )
@@ -11,6 +12,9 @@ import (
 const (
 	aggregateCoefficientDomain = "mixin-aggregate-coefficient-v1"
 	aggregateNonceDomain       = "mixin-aggregate-nonce-v1"
+
+	aggregateSessionNonceDomain = "mixin-aggregate-session-nonce-v1"
+	aggregateBindingDomain      = "mixin-aggregate-binding-v1"
 )
 
 type aggregateSigner struct {
@@ -205,3 +209,189 @@ func AggregateVerify(sig *Signature, publics []*Key, signers []int, message Hash
 	}
 	return nil
 }
+
+// AggregateNonce generates the two secret nonces and their public commitments
+// of a signer in a multi-party aggregation, where the signers do not share
+// their private keys. The nonces are random, hedged with the private key and
+// message, and must never be used for two partial signatures, otherwise the
+// private key is revealed. The secret nonces should be kept by the signer
+// until its partial signature is made, then erased.
+func AggregateNonce(private *Key, message Hash) (secret, public [2]Key) {
+	for i := range secret {
+		var digest [64]byte
+		random := make([]byte, 64)
+		ReadRand(random)
+		h := sha512.New()
+		h.Write([]byte(aggregateSessionNonceDomain))
+		h.Write(random)
+		h.Write(private[:])
+		h.Write(message[:])
+		h.Write([]byte{byte(i)})
+		h.Sum(digest[:0])
+		secret[i] = NewKeyFromSeed(digest[:])
+		public[i] = secret[i].Public()
+	}
+	return secret, public
+}
+
+// aggregateSession binds the nonce commitments of all signers to the message
+// and aggregate public key as MuSig2, so a signer nonce can't be cancelled by
+// the commitments chosen by the others.
+type aggregateSession struct {
+	A            Key
+	coefficients []*edwards25519.Scalar
+	b            *edwards25519.Scalar
+	R            *edwards25519.Point
+	x            *edwards25519.Scalar
+	commitments  [][2]*edwards25519.Point
+}
+
+func newAggregateSession(publics []*Key, signers []int, commitments [][2]Key, message Hash) (*aggregateSession, error) {
+	if len(commitments) != len(signers) {
+		return nil, fmt.Errorf("invalid aggregation commitments count %d/%d", len(commitments), len(signers))
+	}
+	A, coefficients, _, err := aggregateWeightedPublicKey(publics, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &aggregateSession{A: A, coefficients: coefficients}
+	R1, R2 := edwards25519.NewIdentityPoint(), edwards25519.NewIdentityPoint()
+	for i, c := range commitments {
+		p1, err := decodePoint(c[0][:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregation commitment %d %v", signers[i], err)
+		}
+		p2, err := decodePoint(c[1][:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregation commitment %d %v", signers[i], err)
+		}
+		R1 = R1.Add(R1, p1)
+		R2 = R2.Add(R2, p2)
+		s.commitments = append(s.commitments, [2]*edwards25519.Point{p1, p2})
+	}
+
+	var digest [64]byte
+	h := sha512.New()
+	h.Write([]byte(aggregateBindingDomain))
+	h.Write(R1.Bytes())
+	h.Write(R2.Bytes())
+	h.Write(A[:])
+	h.Write(message[:])
+	h.Sum(digest[:0])
+	s.b, err = edwards25519.NewScalar().SetUniformBytes(digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	s.R = edwards25519.NewIdentityPoint().ScalarMult(s.b, R2)
+	s.R = s.R.Add(s.R, R1)
+	s.x, err = aggregateChallenge(s.R.Bytes(), A[:], message)
+	return s, err
+}
+
+func aggregateSignerPosition(signers []int, signer int) (int, error) {
+	i := slices.Index(signers, signer)
+	if i < 0 {
+		return 0, fmt.Errorf("invalid aggregation signer %d", signer)
+	}
+	return i, nil
+}
+
+// AggregatePartialSign makes the partial signature of the signer, with the
+// secret nonces from AggregateNonce and the commitments of all the signers
+// in the order of signers.
+func AggregatePartialSign(private *Key, secret [2]Key, publics []*Key, signers []int, signer int, commitments [][2]Key, message Hash) (*Key, error) {
+	s, err := newAggregateSession(publics, signers, commitments, message)
+	if err != nil {
+		return nil, err
+	}
+	i, err := aggregateSignerPosition(signers, signer)
+	if err != nil {
+		return nil, err
+	}
+	if private.Public() != *publics[signer] {
+		return nil, fmt.Errorf("aggregation private key does not match signer %d", signer)
+	}
+	if secret[0].Public() != commitments[i][0] || secret[1].Public() != commitments[i][1] {
+		return nil, fmt.Errorf("aggregation nonce does not match signer %d", signer)
+	}
+
+	y, err := edwards25519.NewScalar().SetCanonicalBytes(private[:])
+	if err != nil {
+		return nil, err
+	}
+	k1, err := edwards25519.NewScalar().SetCanonicalBytes(secret[0][:])
+	if err != nil {
+		return nil, err
+	}
+	k2, err := edwards25519.NewScalar().SetCanonicalBytes(secret[1][:])
+	if err != nil {
+		return nil, err
+	}
+
+	weighted := edwards25519.NewScalar().Multiply(s.coefficients[i], y)
+	p := edwards25519.NewScalar().MultiplyAdd(s.x, weighted, k1)
+	p = p.MultiplyAdd(s.b, k2, p)
+	var partial Key
+	copy(partial[:], p.Bytes())
+	return &partial, nil
+}
+
+// AggregatePartialVerify checks the partial signature of the signer, so an
+// invalid one is blamed on its signer instead of failing the aggregation.
+func AggregatePartialVerify(partial *Key, publics []*Key, signers []int, signer int, commitments [][2]Key, message Hash) error {
+	s, err := newAggregateSession(publics, signers, commitments, message)
+	if err != nil {
+		return err
+	}
+	i, err := aggregateSignerPosition(signers, signer)
+	if err != nil {
+		return err
+	}
+	p, err := edwards25519.NewScalar().SetCanonicalBytes(partial[:])
+	if err != nil {
+		return fmt.Errorf("invalid aggregation partial signature %d %v", signer, err)
+	}
+	P, err := decodePoint(publics[signer][:])
+	if err != nil {
+		return err
+	}
+
+	expected := edwards25519.NewIdentityPoint().ScalarMult(s.b, s.commitments[i][1])
+	expected = expected.Add(expected, s.commitments[i][0])
+	xa := edwards25519.NewScalar().Multiply(s.x, s.coefficients[i])
+	expected = expected.Add(expected, edwards25519.NewIdentityPoint().ScalarMult(xa, P))
+	if edwards25519.NewIdentityPoint().ScalarBaseMult(p).Equal(expected) != 1 {
+		return fmt.Errorf("invalid aggregation partial signature %d", signer)
+	}
+	return nil
+}
+
+// AggregateCombine sums the partial signatures of all the signers, in the
+// order of signers, into the aggregate signature verified by AggregateVerify.
+func AggregateCombine(partials []*Key, publics []*Key, signers []int, commitments [][2]Key, message Hash) (*Signature, error) {
+	if len(partials) != len(signers) {
+		return nil, fmt.Errorf("invalid aggregation partial signatures count %d/%d", len(partials), len(signers))
+	}
+	s, err := newAggregateSession(publics, signers, commitments, message)
+	if err != nil {
+		return nil, err
+	}
+	S := edwards25519.NewScalar()
+	for i, partial := range partials {
+		if partial == nil {
+			return nil, fmt.Errorf("nil aggregation partial signature %d", signers[i])
+		}
+		p, err := edwards25519.NewScalar().SetCanonicalBytes(partial[:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregation partial signature %d %v", signers[i], err)
+		}
+		S = S.Add(S, p)
+	}
+
+	var sig Signature
+	copy(sig[:32], s.R.Bytes())
+	copy(sig[32:], S.Bytes())
+	return &sig, AggregateVerify(&sig, publics, signers, message)
+}
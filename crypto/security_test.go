@@ -97,6 +97,56 @@ func TestAggregateSignRejectsKeyCountMismatch(t *testing.T) {
 	require.ErrorContains(AggregateVerify(nil, []*Key{&p1}, []int{0}, msg), "nil signature")
 }
 
+func TestAggregatePartialSign(t *testing.T) {
+	require := require.New(t)
+
+	var privates []*Key
+	var publics []*Key
+	for i := range 4 {
+		priv := NewKeyFromSeed(testSeed(byte(80 + i)))
+		pub := priv.Public()
+		privates = append(privates, &priv)
+		publics = append(publics, &pub)
+	}
+	signers := []int{0, 2, 3}
+	msg := Blake3Hash([]byte("multi-party aggregate"))
+
+	var secrets, commitments [][2]Key
+	for _, m := range signers {
+		secret, public := AggregateNonce(privates[m], msg)
+		secrets = append(secrets, secret)
+		commitments = append(commitments, public)
+	}
+
+	var partials []*Key
+	for i, m := range signers {
+		partial, err := AggregatePartialSign(privates[m], secrets[i], publics, signers, m, commitments, msg)
+		require.Nil(err)
+		err = AggregatePartialVerify(partial, publics, signers, m, commitments, msg)
+		require.Nil(err)
+		partials = append(partials, partial)
+	}
+	sig, err := AggregateCombine(partials, publics, signers, commitments, msg)
+	require.Nil(err)
+	require.Nil(AggregateVerify(sig, publics, signers, msg))
+
+	_, err = AggregatePartialSign(privates[1], secrets[0], publics, signers, 1, commitments, msg)
+	require.ErrorContains(err, "invalid aggregation signer 1")
+	_, err = AggregatePartialSign(privates[0], secrets[1], publics, signers, 0, commitments, msg)
+	require.ErrorContains(err, "aggregation nonce does not match signer 0")
+	_, err = AggregatePartialSign(privates[2], secrets[0], publics, signers, 0, commitments, msg)
+	require.ErrorContains(err, "aggregation private key does not match signer 0")
+
+	err = AggregatePartialVerify(partials[0], publics, signers, 2, commitments, msg)
+	require.ErrorContains(err, "invalid aggregation partial signature 2")
+	partials[1], partials[2] = partials[2], partials[1]
+	_, err = AggregateCombine(partials[:2], publics, signers, commitments, msg)
+	require.ErrorContains(err, "invalid aggregation partial signatures count")
+	partials[0] = partials[1]
+	_, err = AggregateCombine(partials, publics, signers, commitments, msg)
+	require.ErrorContains(err, "signature verify failed")
+}
+
 func TestLowOrderKeysAreRejected(t *testing.T) {
 	require := require.New(t)
 
@@ -17,6 +17,7 @@ func (node *Node) Loop() error {
 	go node.sendGraphToConsensusNodesAndPeers()
 	go node.loopCacheQueue()
 	go node.MintLoop()
+	go node.ScanLoop()
 	node.ElectionLoop()
 	return nil
 }
@@ -26,6 +27,7 @@ func (node *Node) Teardown() {
 	<-node.cqc
 	<-node.mlc
 	<-node.elc
+	<-node.slc
 	node.chains.RLock()
 	for _, c := range node.chains.m {
 		c.Teardown()
@@ -34,6 +36,7 @@ func (node *Node) Teardown() {
 	node.Peer.Teardown()
 	util.CloseOrPanic(node.persistStore)
 	node.cacheStore.Clear()
+	node.cosiTraces.close()
 }
 
 func TestMockReset() {
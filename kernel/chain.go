@@ -80,6 +80,10 @@ type Chain struct {
 	wlc              chan struct{}
 	slc              chan struct{}
 	running          bool
+
+	// pendingTransactions mirrors the transaction entries of CosiVerifiers,
+	// which are only safe to access from the cosi loop.
+	pendingTransactions hashMap
 }
 
 func (node *Node) buildChain(chainId crypto.Hash) *Chain {
@@ -58,12 +58,19 @@ type CosiAggregator struct {
 	FullChallenges map[crypto.Hash]bool
 	Commitments    map[int]*crypto.Key
 	Responses      map[int]*[32]byte
+
+	announcedAt  time.Time
+	challengedAt time.Time
 }
 
 type CosiVerifier struct {
 	Snapshot     *common.Snapshot
 	Announcement *crypto.Key
 	nonce        *crypto.CosiNonce
+
+	announcedAt  time.Time
+	challengedAt time.Time
+	respondedAt  time.Time
 }
 
 func (chain *Chain) cosiAcceptedNodesListShuffle(round, ts uint64) []*CNode {
@@ -466,16 +473,15 @@ func (chain *Chain) cosiSendAnnouncement(m *CosiAction) error {
 		FullChallenges: make(map[crypto.Hash]bool),
 		Commitments:    make(map[int]*crypto.Key),
 		Responses:      make(map[int]*[32]byte),
+		announcedAt:    clock.Now(),
 	}
 
 	v := &CosiVerifier{Snapshot: s, nonce: crypto.CosiCommitNonce(crypto.RandReader())}
 	R := v.nonce.Public()
-	chain.CosiVerifiers[s.Hash] = v
-	for _, txh := range s.Transactions {
-		chain.CosiVerifiers[txh] = v
-	}
+	chain.setCosiVerifier(v)
 	agg.Commitments[cd.CN.ConsensusIndex] = &R
 	chain.CosiAggregators[s.Hash] = agg
+	chain.node.cosiTraces.record(s, CosiPhaseAnnouncement, chain.ChainId)
 	nodes := chain.cosiAcceptedNodesListShuffle(s.RoundNumber, s.Timestamp)
 	for _, cn := range nodes {
 		peerId := cn.IdForNetwork
@@ -515,12 +521,10 @@ func (chain *Chain) cosiHandleAnnouncement(m *CosiAction) error {
 	chain.CosiCommunicatedAt[m.PeerId] = clock.Now()
 
 	s, cd := m.Snapshot, m.data
+	chain.node.cosiTraces.record(s, CosiPhaseAnnouncement, m.PeerId)
 	nonce := crypto.CosiCommitNonce(crypto.RandReader())
-	v := &CosiVerifier{Snapshot: s, Announcement: m.Commitment, nonce: nonce}
-	chain.CosiVerifiers[s.Hash] = v
-	for _, txh := range s.Transactions {
-		chain.CosiVerifiers[txh] = v
-	}
+	v := &CosiVerifier{Snapshot: s, Announcement: m.Commitment, nonce: nonce, announcedAt: clock.Now()}
+	chain.setCosiVerifier(v)
 	err = chain.node.Peer.SendSnapshotCommitmentMessage(s.NodeId, s, nonce.Public(), cd.WantTxs)
 	if err != nil {
 		logger.Verbosef("cosiHandleAnnouncement SendSnapshotCommitmentMessage(%s, %s) ERROR %v\n",
@@ -552,6 +556,7 @@ func (chain *Chain) cosiHandleCommitment(m *CosiAction) error {
 		return nil
 	}
 	ann.Commitments[cd.PN.ConsensusIndex] = m.Commitment
+	chain.node.cosiTraces.record(s, CosiPhaseCommitment, m.PeerId)
 	ann.WantTxs[m.PeerId] = m.WantTxs
 	ann.FullChallenges[m.PeerId] = m.Action == CosiActionSelfFullCommitment
 	logger.Verbosef("cosiHandleCommitment %v NOW %d %d\nn", m, len(ann.Commitments), base)
@@ -559,6 +564,8 @@ func (chain *Chain) cosiHandleCommitment(m *CosiAction) error {
 		return nil
 	}
 	logger.Verbosef("cosiHandleCommitment %v ENOUGH\n", m)
+	ann.challengedAt = clock.Now()
+	chain.node.cosiLatencies.Observe(CosiPhaseCommitment, ann.challengedAt.Sub(ann.announcedAt))
 
 	cosi, err := crypto.CosiAggregateCommitment(ann.Commitments)
 	if err != nil {
@@ -590,6 +597,9 @@ func (chain *Chain) cosiHandleCommitment(m *CosiAction) error {
 				continue
 			}
 			err = chain.node.Peer.SendFullChallengeMessage(id, s, commitment, challenge, txs)
+			if err == nil {
+				chain.node.cosiTraces.record(s, CosiPhaseFullChallenge, id)
+			}
 		} else if wantTxs, found := ann.WantTxs[id]; !found {
 			continue
 		} else {
@@ -602,6 +612,9 @@ func (chain *Chain) cosiHandleCommitment(m *CosiAction) error {
 				wtxs = append(wtxs, tx)
 			}
 			err = chain.node.Peer.SendTransactionChallengeMessage(id, s, cosi, wtxs)
+			if err == nil {
+				chain.node.cosiTraces.record(s, CosiPhaseChallenge, id)
+			}
 		}
 		if err != nil {
 			logger.Verbosef("cosiHandleCommitment SendTransactionChallengeMessage(%s, %s) ERROR %v\n",
@@ -623,11 +636,9 @@ func (chain *Chain) cosiHandleFullChallenge(m *CosiAction) error {
 	}
 
 	s := m.Snapshot
-	v := &CosiVerifier{Snapshot: s, Announcement: m.Commitment, nonce: m.nonce}
-	chain.CosiVerifiers[s.Hash] = v
-	for _, txh := range s.Transactions {
-		chain.CosiVerifiers[txh] = v
-	}
+	v := &CosiVerifier{Snapshot: s, Announcement: m.Commitment, nonce: m.nonce, challengedAt: clock.Now()}
+	chain.setCosiVerifier(v)
+	chain.node.cosiTraces.record(s, CosiPhaseFullChallenge, m.PeerId)
 
 	ccm := &CosiAction{
 		PeerId:       m.PeerId,
@@ -693,6 +704,7 @@ func (chain *Chain) checkAnnouncementOrChallenge(m *CosiAction) (bool, error) {
 		}
 		cache, final = nc, nf
 		chain.CosiVerifiers = make(map[crypto.Hash]*CosiVerifier)
+		chain.pendingTransactions.Reset()
 	}
 	if final.Number+1 != cache.Number {
 		panic(final.Number)
@@ -727,6 +739,10 @@ func (chain *Chain) cosiHandleChallenge(m *CosiAction) error {
 		return nil
 	}
 	chain.CosiCommunicatedAt[m.PeerId] = clock.Now()
+	chain.node.cosiTraces.record(s, CosiPhaseChallenge, m.PeerId)
+	if !v.announcedAt.IsZero() {
+		chain.node.cosiLatencies.Observe(CosiPhaseAnnouncement, clock.Now().Sub(v.announcedAt))
+	}
 
 	priv := chain.node.Signer.PrivateSpendKey
 	response, err := v.nonce.Response(m.Signature, &priv, publics, m.SnapshotHash)
@@ -745,6 +761,12 @@ func (chain *Chain) cosiHandleChallenge(m *CosiAction) error {
 	if err != nil {
 		logger.Verbosef("cosiHandleChallenge SendSnapshotResponseMessage(%s, %s) ERROR %v\n",
 			m.PeerId, m.SnapshotHash, err)
+		return nil
+	}
+	chain.node.cosiTraces.record(s, CosiPhaseResponse, chain.node.IdForNetwork)
+	v.respondedAt = clock.Now()
+	if !v.challengedAt.IsZero() {
+		chain.node.cosiLatencies.Observe(CosiPhaseFullChallenge, v.respondedAt.Sub(v.challengedAt))
 	}
 	return nil
 }
@@ -771,6 +793,7 @@ func (chain *Chain) cosiHandleResponse(m *CosiAction) error {
 
 	base := chain.node.ConsensusThreshold(s.Timestamp, false)
 	agg.Responses[cd.PN.ConsensusIndex] = m.Response
+	chain.node.cosiTraces.record(s, CosiPhaseResponse, m.PeerId)
 	logger.Verbosef("cosiHandleResponse %v NOW %d %d %d\n",
 		m, len(agg.Responses), len(agg.Commitments), base)
 	if len(agg.Responses) != len(agg.Commitments) {
@@ -825,6 +848,8 @@ func (chain *Chain) cosiHandleResponse(m *CosiAction) error {
 			panic(err)
 		}
 	}
+	chain.node.cosiLatencies.Observe(CosiPhaseResponse, clock.Now().Sub(agg.challengedAt))
+	chain.node.cosiTraces.record(s, CosiPhaseFinalization, chain.ChainId)
 
 	nodes := chain.cosiAcceptedNodesListShuffle(s.RoundNumber, s.Timestamp)
 	for _, cn := range nodes {
@@ -850,16 +875,27 @@ func (chain *Chain) cosiHandleResponse(m *CosiAction) error {
 }
 
 func (chain *Chain) abandonCosiSnapshot(s *common.Snapshot) {
+	chain.node.cosiTraces.record(s, CosiPhaseAbandon, chain.ChainId)
 	delete(chain.CosiAggregators, s.Hash)
 	verifier := chain.CosiVerifiers[s.Hash]
 	delete(chain.CosiVerifiers, s.Hash)
 	for _, tx := range s.Transactions {
 		if chain.CosiVerifiers[tx] == verifier {
 			delete(chain.CosiVerifiers, tx)
+			chain.pendingTransactions.Delete(tx, s.Hash)
 		}
 	}
 }
 
+func (chain *Chain) setCosiVerifier(v *CosiVerifier) {
+	s := v.Snapshot
+	chain.CosiVerifiers[s.Hash] = v
+	for _, txh := range s.Transactions {
+		chain.CosiVerifiers[txh] = v
+		chain.pendingTransactions.Set(txh, s.Hash)
+	}
+}
+
 // retryCosiSnapshot is for terminal failures of a locally aggregated proposal.
 // Removing the aggregator first prevents late commitments or responses from
 // reviving it, while CacheRequeueTransaction makes every still-unfinalized
@@ -904,6 +940,7 @@ func (chain *Chain) resetCosiStateForNewRound(owned []crypto.Hash) {
 	}
 	chain.CosiAggregators = make(map[crypto.Hash]*CosiAggregator)
 	chain.CosiVerifiers = make(map[crypto.Hash]*CosiVerifier)
+	chain.pendingTransactions.Reset()
 	chain.node.requeueTransactions(retry)
 }
 
@@ -980,6 +1017,7 @@ func (chain *Chain) prepareFinalization(m *CosiAction) (bool, error) {
 
 func (chain *Chain) cosiHandleFinalization(m *CosiAction) error {
 	logger.Debugf("cosiHandleFinalization %s %v\n", m.PeerId, m.Snapshot)
+	v := chain.CosiVerifiers[m.Snapshot.Hash]
 	valid, err := chain.prepareFinalization(m)
 	if err != nil || !valid {
 		return err
@@ -1010,6 +1048,7 @@ func (chain *Chain) cosiHandleFinalization(m *CosiAction) error {
 		if err != nil {
 			return err
 		}
+		chain.node.cosiTraces.record(s, CosiPhaseFinalization, m.PeerId)
 		tx := slices.Collect(maps.Values(found))[0]
 		return chain.node.reloadConsensusState(s, tx)
 	} else if chain.State == nil {
@@ -1035,6 +1074,10 @@ func (chain *Chain) cosiHandleFinalization(m *CosiAction) error {
 		panic(err)
 	}
 	m.finalized = true
+	chain.node.cosiTraces.record(s, CosiPhaseFinalization, m.PeerId)
+	if v != nil && v.Snapshot.Hash == s.Hash && !v.respondedAt.IsZero() {
+		chain.node.cosiLatencies.Observe(CosiPhaseFinalization, clock.Now().Sub(v.respondedAt))
+	}
 	if len(found) > 1 {
 		return nil
 	}
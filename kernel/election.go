@@ -175,7 +175,7 @@ func (node *Node) tryToSendRemoveTransaction() error {
 	if err != nil {
 		return err
 	}
-	err = node.persistStore.CacheQueueTransaction(tx)
+	err = node.persistStore.CacheQueueTransactionWithOrigin(tx, TransactionOriginSelf)
 	if err != nil {
 		return err
 	}
@@ -319,7 +319,7 @@ func (chain *Chain) tryToSendAcceptTransaction() error {
 	if err != nil {
 		return err
 	}
-	err = chain.node.persistStore.CacheQueueTransaction(ver)
+	err = chain.node.persistStore.CacheQueueTransactionWithOrigin(ver, TransactionOriginSelf)
 	if err != nil {
 		return err
 	}
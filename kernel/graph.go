@@ -3,6 +3,7 @@ package kernel
 import (
 	"encoding/binary"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -365,6 +366,25 @@ func (chain *Chain) ConsensusKeys(round, timestamp uint64) ([]crypto.Hash, []*cr
 	return signers, publics
 }
 
+// SnapshotConsensusKeys returns the consensus nodes and keys to verify the
+// finalized snapshot s, with the node of s added if it was pledging in round 0.
+func (node *Node) SnapshotConsensusKeys(s *common.Snapshot) ([]crypto.Hash, []*crypto.Key) {
+	chain := node.getOrCreateChain(s.NodeId)
+	if chain == nil {
+		return nil, nil
+	}
+	cids, publics := chain.ConsensusKeys(s.RoundNumber, s.Timestamp)
+	if s.RoundNumber != 0 || slices.Contains(cids, s.NodeId) {
+		return cids, publics
+	}
+	for _, cn := range node.NodesListWithoutState(s.Timestamp, false) {
+		if cn.IdForNetwork == s.NodeId && cn.State == common.NodeStatePledging {
+			return append(cids, cn.IdForNetwork), append(publics, &cn.Signer.PublicSpendKey)
+		}
+	}
+	return cids, publics
+}
+
 func (chain *Chain) verifyFinalization(s *common.Snapshot) ([]crypto.Hash, bool) {
 	switch s.Version {
 	case common.SnapshotVersionCommonEncoding:
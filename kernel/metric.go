@@ -0,0 +1,75 @@
+package kernel
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	CosiPhaseAnnouncement  = "announcement"
+	CosiPhaseCommitment    = "commitment"
+	CosiPhaseChallenge     = "challenge"
+	CosiPhaseFullChallenge = "full-challenge"
+	CosiPhaseResponse      = "response"
+	CosiPhaseFinalization  = "finalization"
+	CosiPhaseAbandon       = "abandon"
+)
+
+// LatencyBuckets are the upper bounds in seconds of the latency histograms.
+var LatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type LatencyHistogram struct {
+	Buckets []uint64
+	Count   uint64
+	Sum     float64
+}
+
+type latencyMap struct {
+	mutex sync.Mutex
+	m     map[string]*LatencyHistogram
+}
+
+func (lm *latencyMap) Observe(k string, d time.Duration) {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	if lm.m == nil {
+		lm.m = make(map[string]*LatencyHistogram)
+	}
+	h := lm.m[k]
+	if h == nil {
+		h = &LatencyHistogram{Buckets: make([]uint64, len(LatencyBuckets))}
+		lm.m[k] = h
+	}
+	s := d.Seconds()
+	for i, b := range LatencyBuckets {
+		if s <= b {
+			h.Buckets[i] += 1
+		}
+	}
+	h.Count += 1
+	h.Sum += s
+}
+
+func (lm *latencyMap) Copy() map[string]LatencyHistogram {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	m := make(map[string]LatencyHistogram)
+	for k, h := range lm.m {
+		c := *h
+		c.Buckets = append([]uint64{}, h.Buckets...)
+		m[k] = c
+	}
+	return m
+}
+
+// CosiLatencies returns the cumulative latency histograms of the CoSi phases.
+// As the proposer, the commitment phase lasts from the announcement to enough
+// commitments, and the response phase from the challenge to the finalization.
+// As a verifier, the announcement phase lasts from the announcement to the
+// challenge, the full-challenge phase from the full challenge to the sent
+// response, and the finalization phase from the response to the finalization.
+func (node *Node) CosiLatencies() map[string]LatencyHistogram {
+	return node.cosiLatencies.Copy()
+}
@@ -0,0 +1,30 @@
+package kernel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyMap(t *testing.T) {
+	require := require.New(t)
+
+	var lm latencyMap
+	require.Len(lm.Copy(), 0)
+	lm.Observe(CosiPhaseCommitment, 80*time.Millisecond)
+	lm.Observe(CosiPhaseCommitment, 3*time.Second)
+	lm.Observe(CosiPhaseResponse, time.Minute)
+
+	m := lm.Copy()
+	h := m[CosiPhaseCommitment]
+	require.Equal(uint64(2), h.Count)
+	require.InDelta(3.08, h.Sum, 1e-9)
+	require.Equal([]uint64{0, 1, 1, 1, 1, 1, 2, 2, 2}, h.Buckets)
+	h.Buckets[0] = 100
+	require.Equal(uint64(0), lm.Copy()[CosiPhaseCommitment].Buckets[0])
+
+	h = m[CosiPhaseResponse]
+	require.Equal(uint64(1), h.Count)
+	require.Equal(make([]uint64, len(LatencyBuckets)), h.Buckets)
+}
@@ -159,7 +159,7 @@ func (node *Node) tryToMintUniversal(custodianRequest *common.CustodianUpdateReq
 	if err != nil {
 		return err
 	}
-	err = node.persistStore.CacheQueueTransaction(signed)
+	err = node.persistStore.CacheQueueTransactionWithOrigin(signed, TransactionOriginSelf)
 	if err != nil {
 		return err
 	}
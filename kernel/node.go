@@ -40,6 +40,10 @@ type Node struct {
 	nodeStateSequences         []*NodeStateSequence
 	acceptedNodeStateSequences []*NodeStateSequence
 	chain                      *Chain
+	dispatchedTransactions     timeMap
+	cosiLatencies              latencyMap
+	cosiTraces                 cosiTraceRing
+	transactionWaiters         waiterMap
 
 	genesisNodesMap map[crypto.Hash]bool
 	genesisNodes    []crypto.Hash
@@ -53,7 +57,10 @@ type Node struct {
 	elc       chan struct{}
 	mlc       chan struct{}
 	cqc       chan struct{}
+	slc       chan struct{}
 	queueWake chan struct{}
+	scanWake  chan struct{}
+	scanMutex sync.Mutex
 }
 
 type NodeStateSequence struct {
@@ -84,15 +91,21 @@ func SetupNode(custom *config.Custom, store storage.Store, cache *ristretto.Cach
 		elc:             make(chan struct{}),
 		mlc:             make(chan struct{}),
 		cqc:             make(chan struct{}),
+		slc:             make(chan struct{}),
 		queueWake:       make(chan struct{}, 1),
+		scanWake:        make(chan struct{}, 1),
 	}
 
 	node.loadNodeConfig()
+	err := node.cosiTraces.open(custom.Node.CosiTraceSize, custom.Node.CosiTraceFile)
+	if err != nil {
+		return nil, fmt.Errorf("open cosi trace file %s => %v", custom.Node.CosiTraceFile, err)
+	}
 
 	mint := node.lastMintDistribution()
 	node.LastMint = mint.Batch
 
-	err := node.LoadGenesis(gns)
+	err = node.LoadGenesis(gns)
 	if err != nil {
 		return nil, fmt.Errorf("LoadGenesis(%v) => %v", gns, err)
 	}
@@ -513,7 +526,7 @@ func (node *Node) CacheQueueTransactions(peerId crypto.Hash, txs []*common.Versi
 		if len(finalized) > 0 {
 			continue
 		}
-		err = node.persistStore.CacheQueueTransaction(tx)
+		err = node.persistStore.CacheQueueTransactionWithOrigin(tx, TransactionOriginPeer+peerId.String())
 		if err != nil {
 			return err
 		}
@@ -708,3 +721,75 @@ func (s *syncMap) Map() map[crypto.Hash]*p2p.SyncPoint {
 
 	return maps.Clone(s.m)
 }
+
+type hashMap struct {
+	mutex sync.RWMutex
+	m     map[crypto.Hash]crypto.Hash
+}
+
+func (s *hashMap) Set(k, v crypto.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.m == nil {
+		s.m = make(map[crypto.Hash]crypto.Hash)
+	}
+	s.m[k] = v
+}
+
+func (s *hashMap) Get(k crypto.Hash) (crypto.Hash, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	v, found := s.m[k]
+	return v, found
+}
+
+// Delete removes k only if it is still mapped to v.
+func (s *hashMap) Delete(k, v crypto.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.m[k] == v {
+		delete(s.m, k)
+	}
+}
+
+func (s *hashMap) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.m = nil
+}
+
+type timeMap struct {
+	mutex sync.RWMutex
+	m     map[crypto.Hash]uint64
+}
+
+func (s *timeMap) Set(k crypto.Hash, ts uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.m == nil {
+		s.m = make(map[crypto.Hash]uint64)
+	}
+	s.m[k] = ts
+}
+
+func (s *timeMap) Get(k crypto.Hash) (uint64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	ts, found := s.m[k]
+	return ts, found
+}
+
+func (s *timeMap) Delete(k crypto.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.m, k)
+}
+
+// Expire removes all entries set before ts.
+func (s *timeMap) Expire(ts uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	maps.DeleteFunc(s.m, func(_ crypto.Hash, v uint64) bool {
+		return v < ts
+	})
+}
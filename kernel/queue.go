@@ -1,6 +1,8 @@
 package kernel
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -9,9 +11,21 @@ import (
 	"github.com/MixinNetwork/mixin/kernel/internal/clock"
 	"github.com/MixinNetwork/mixin/logger"
 	"github.com/MixinNetwork/mixin/p2p"
+	"github.com/MixinNetwork/mixin/storage"
+	"github.com/dgraph-io/badger/v4"
 )
 
+// QueueTransaction reports a badger conflict as common.ErrorCodeNodeBusy,
+// because the same transaction may be queued once the competing writes finish.
 func (node *Node) QueueTransaction(tx *common.VersionedTransaction) (string, error) {
+	hash, err := node.queueTransaction(tx)
+	if errors.Is(err, badger.ErrConflict) {
+		return hash, common.NewError(common.ErrorCodeNodeBusy, err)
+	}
+	return hash, err
+}
+
+func (node *Node) queueTransaction(tx *common.VersionedTransaction) (string, error) {
 	hash := tx.PayloadHash()
 	_, finalized, err := node.persistStore.ReadTransaction(hash)
 	if err != nil {
@@ -26,14 +40,14 @@ func (node *Node) QueueTransaction(tx *common.VersionedTransaction) (string, err
 		return "", err
 	}
 	if old != nil {
-		return old.PayloadHash().String(), node.persistStore.CacheQueueTransaction(tx)
+		return old.PayloadHash().String(), node.persistStore.CacheQueueTransactionWithOrigin(tx, TransactionOriginRPC)
 	}
 
 	err = tx.Validate(node.persistStore, clock.NowUnixNano(), false)
 	if err != nil {
 		return "", err
 	}
-	err = node.persistStore.CacheQueueTransaction(tx)
+	err = node.persistStore.CacheQueueTransactionWithOrigin(tx, TransactionOriginRPC)
 	if err != nil {
 		return "", err
 	}
@@ -41,6 +55,48 @@ func (node *Node) QueueTransaction(tx *common.VersionedTransaction) (string, err
 	return tx.PayloadHash().String(), nil
 }
 
+// ValidateTransaction runs the same validation as QueueTransaction against the
+// current graph timestamp, but neither caches nor queues tx, and only checks
+// the ghost key locks instead of acquiring them.
+func (node *Node) ValidateTransaction(tx *common.VersionedTransaction) error {
+	store := &dryRunStore{Store: node.persistStore}
+	return tx.Validate(store, node.GraphTimestamp, false)
+}
+
+type dryRunStore struct {
+	storage.Store
+}
+
+func (s *dryRunStore) LockGhostKeys(keys []*crypto.Key, tx crypto.Hash, fork bool) error {
+	filter := make(map[crypto.Key]bool)
+	for _, ghost := range keys {
+		if filter[*ghost] {
+			return common.NewErrorf(common.ErrorCodeInvalidOutput, "duplicated ghost key %s", ghost.String())
+		}
+		filter[*ghost] = true
+		by, err := s.ReadGhostKeyLock(*ghost)
+		if err != nil {
+			return err
+		}
+		if by != nil && *by != tx {
+			return common.NewErrorf(common.ErrorCodeGhostKeyLocked, "ghost key %s locked for transaction %s", ghost.String(), by.String())
+		}
+	}
+	return nil
+}
+
+func (s *dryRunStore) LockUTXOs(inputs []*common.Input, tx crypto.Hash, fork bool) error {
+	return fmt.Errorf("dry run store lock %s", tx)
+}
+
+func (s *dryRunStore) LockDepositInput(deposit *common.DepositData, tx crypto.Hash, fork bool) error {
+	return fmt.Errorf("dry run store lock %s", tx)
+}
+
+func (s *dryRunStore) LockMintInput(mint *common.MintData, tx crypto.Hash, fork bool) error {
+	return fmt.Errorf("dry run store lock %s", tx)
+}
+
 func (node *Node) loopCacheQueue() {
 	defer close(node.cqc)
 
@@ -102,10 +158,12 @@ func (node *Node) popAndProcessCacheQueue() int {
 
 	now := clock.Now()
 	leadingNodes, leadingFilter := node.filterLeadingNodes(allNodes)
+	node.dispatchedTransactions.Expire(uint64(now.Add(-time.Duration(node.custom.Node.CacheTTL) * time.Second).UnixNano()))
 
 	filter := make(map[crypto.Hash]bool)
 	var batchSize int
 	var stale, batch []crypto.Hash
+	var drops []*common.TransactionDrop
 	for _, tx := range txs {
 		hash := tx.PayloadHash()
 		if filter[hash] {
@@ -126,8 +184,11 @@ func (node *Node) popAndProcessCacheQueue() int {
 			logger.Debugf("LoopCacheQueue Validate ERROR %s %s\n", hash, err)
 			// FIXME not mark invalid tx as stale is to ensure final graph sync
 			// but we need some way to mitigate cache transaction DoS attack from nodes
+			node.dispatchedTransactions.Delete(hash)
+			drops = append(drops, common.NewTransactionDrop(hash, uint64(now.UnixNano()), err))
 			continue
 		}
+		node.dispatchedTransactions.Set(hash, uint64(now.UnixNano()))
 		nbor := node.electSnapshotNode(tx.TransactionType(), uint64(now.UnixNano()))
 		if nbor.HasValue() {
 			node.sendTransactionsToNode([]crypto.Hash{hash}, nbor)
@@ -162,6 +223,17 @@ func (node *Node) popAndProcessCacheQueue() int {
 	if err != nil {
 		logger.Printf("LoopCacheQueue CacheRemoveTransactions ERROR %s\n", err)
 	}
+	if len(drops) > 0 {
+		err = node.persistStore.CacheWriteTransactionDrops(drops)
+		if err != nil {
+			logger.Printf("LoopCacheQueue CacheWriteTransactionDrops ERROR %s\n", err)
+		}
+		dropped := make([]crypto.Hash, len(drops))
+		for i, d := range drops {
+			dropped[i] = d.Hash
+		}
+		node.transactionWaiters.Notify(dropped)
+	}
 	return len(txs)
 }
 
@@ -370,3 +442,105 @@ func (node *Node) QueueState() (uint64, uint64, map[string][2]uint64) {
 	}
 	return caches, finals, state
 }
+
+const (
+	TransactionStateUnknown    = "unknown"
+	TransactionStateCached     = "cached"
+	TransactionStateQueued     = "queued"
+	TransactionStateProcessing = "processing"
+	TransactionStatePending    = "pending"
+	TransactionStateFinalized  = "finalized"
+	TransactionStateDropped    = "dropped"
+)
+
+// The origins recorded with a queued transaction, a peer origin is followed
+// by the node id of the peer.
+const (
+	TransactionOriginRPC  = "rpc"
+	TransactionOriginSelf = "self"
+	TransactionOriginPeer = "peer:"
+)
+
+type TransactionStatus struct {
+	State     string
+	Snapshot  crypto.Hash
+	Topology  uint64
+	Timestamp uint64
+	Drop      *common.TransactionDrop
+}
+
+// ReadTransactionStatus reports the latest lifecycle state of a transaction
+// known to this node. A dropped transaction queued again reports its new state,
+// and processing means the queue has dispatched it to a snapshot node.
+func (node *Node) ReadTransactionStatus(hash crypto.Hash) (*TransactionStatus, error) {
+	tx, finalized, err := node.persistStore.ReadTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(finalized) > 0 {
+		snap, err := crypto.HashFromString(finalized)
+		if err != nil {
+			return nil, err
+		}
+		s, err := node.persistStore.ReadSnapshot(snap)
+		if err != nil || s == nil {
+			return nil, fmt.Errorf("snapshot %s not found for transaction %s %v", finalized, hash, err)
+		}
+		return &TransactionStatus{
+			State:     TransactionStateFinalized,
+			Snapshot:  snap,
+			Topology:  s.TopologicalOrder,
+			Timestamp: s.Timestamp,
+		}, nil
+	}
+
+	if snap, found := node.findPendingSnapshot(hash); found {
+		return &TransactionStatus{State: TransactionStatePending, Snapshot: snap}, nil
+	}
+
+	queued, err := node.persistStore.CacheCheckTransactionQueued(hash)
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		return &TransactionStatus{State: TransactionStateQueued}, nil
+	}
+	if ts, found := node.dispatchedTransactions.Get(hash); found {
+		return &TransactionStatus{State: TransactionStateProcessing, Timestamp: ts}, nil
+	}
+
+	drop, err := node.persistStore.CacheReadTransactionDrop(hash)
+	if err != nil {
+		return nil, err
+	}
+	if drop != nil {
+		return &TransactionStatus{
+			State:     TransactionStateDropped,
+			Timestamp: drop.Timestamp,
+			Drop:      drop,
+		}, nil
+	}
+
+	if tx == nil {
+		tx, err = node.persistStore.CacheGetTransaction(hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tx != nil {
+		return &TransactionStatus{State: TransactionStateCached}, nil
+	}
+	return &TransactionStatus{State: TransactionStateUnknown}, nil
+}
+
+func (node *Node) findPendingSnapshot(hash crypto.Hash) (crypto.Hash, bool) {
+	node.chains.RLock()
+	defer node.chains.RUnlock()
+
+	for _, chain := range node.chains.m {
+		if snap, found := chain.pendingTransactions.Get(hash); found {
+			return snap, true
+		}
+	}
+	return crypto.Hash{}, false
+}
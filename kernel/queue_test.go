@@ -0,0 +1,195 @@
+package kernel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTransactionDryRun(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	seed := make([]byte, 64)
+	key := crypto.NewKeyFromSeed(seed).Public()
+	other := crypto.NewKeyFromSeed(append(seed[:63], 1)).Public()
+	owner := crypto.Blake3Hash([]byte("owner"))
+	spender := crypto.Blake3Hash([]byte("spender"))
+
+	err := node.persistStore.LockGhostKeys([]*crypto.Key{&key}, owner, false)
+	require.Nil(err)
+
+	store := &dryRunStore{Store: node.persistStore}
+	require.Nil(store.LockGhostKeys([]*crypto.Key{&key, &other}, owner, false))
+	err = store.LockGhostKeys([]*crypto.Key{&other, &key}, spender, false)
+	require.ErrorContains(err, "locked for transaction "+owner.String())
+	require.Equal(common.ErrorCodeGhostKeyLocked, common.AsError(err).Code)
+	err = store.LockGhostKeys([]*crypto.Key{&other, &other}, spender, false)
+	require.Equal(common.ErrorCodeInvalidOutput, common.AsError(err).Code)
+	lock, err := node.persistStore.ReadGhostKeyLock(other)
+	require.Nil(err)
+	require.Nil(lock)
+	require.ErrorContains(store.LockUTXOs(nil, spender, false), "dry run store lock "+spender.String())
+	require.ErrorContains(store.LockDepositInput(nil, spender, false), "dry run store lock")
+	require.ErrorContains(store.LockMintInput(nil, spender, false), "dry run store lock")
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("missing")), 0)
+	ver := tx.AsVersioned()
+	err = node.ValidateTransaction(ver)
+	require.ErrorContains(err, "invalid tx inputs or outputs")
+	require.Equal(common.ErrorCodeInvalidTransaction, common.AsError(err).Code)
+	cached, err := node.persistStore.CacheGetTransaction(ver.PayloadHash())
+	require.Nil(err)
+	require.Nil(cached)
+}
+
+func TestReadTransactionStatus(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	snapshots, err := node.persistStore.ReadSnapshotsSinceTopology(0, 1)
+	require.Nil(err)
+	require.Len(snapshots, 1)
+	status, err := node.ReadTransactionStatus(snapshots[0].Transactions[0])
+	require.Nil(err)
+	require.Equal(TransactionStateFinalized, status.State)
+	require.Equal(snapshots[0].PayloadHash(), status.Snapshot)
+	require.Equal(uint64(0), status.Topology)
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("status")), 0)
+	ver := tx.AsVersioned()
+	hash := ver.PayloadHash()
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateUnknown, status.State)
+
+	require.Nil(node.persistStore.CacheStoreTransaction(ver))
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateCached, status.State)
+
+	cause := common.NewInputErrorf(common.ErrorCodeInputNotFound, 0, "input not found")
+	drop := common.NewTransactionDrop(hash, 100, cause)
+	require.Nil(node.persistStore.CacheWriteTransactionDrops([]*common.TransactionDrop{drop}))
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateDropped, status.State)
+	require.Equal(uint64(100), status.Timestamp)
+	require.Equal(common.ErrorCodeInputNotFound, status.Drop.Code)
+	require.Equal("input not found", status.Drop.Reason)
+
+	node.dispatchedTransactions.Set(hash, 200)
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateProcessing, status.State)
+	require.Equal(uint64(200), status.Timestamp)
+	node.dispatchedTransactions.Expire(201)
+	_, found := node.dispatchedTransactions.Get(hash)
+	require.False(found)
+
+	require.Nil(node.persistStore.CacheQueueTransaction(ver))
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateQueued, status.State)
+
+	s := &common.Snapshot{Version: common.SnapshotVersionCommonEncoding, NodeId: node.IdForNetwork}
+	s.AddTransaction(hash)
+	s.Hash = s.PayloadHash()
+	node.chain.setCosiVerifier(&CosiVerifier{Snapshot: s})
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStatePending, status.State)
+	require.Equal(s.Hash, status.Snapshot)
+
+	node.chain.abandonCosiSnapshot(s)
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateQueued, status.State)
+}
+
+func TestReadTransactionStatusNewRound(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("new round")), 0)
+	ver := tx.AsVersioned()
+	hash := ver.PayloadHash()
+	require.Nil(node.persistStore.CacheQueueTransaction(ver))
+	queued, err := node.persistStore.CacheRetrieveTransactions(1)
+	require.Nil(err)
+	require.Len(queued, 1)
+
+	s := &common.Snapshot{Version: common.SnapshotVersionCommonEncoding, NodeId: node.IdForNetwork}
+	s.AddTransaction(hash)
+	s.Hash = s.PayloadHash()
+	node.chain.CosiAggregators[s.Hash] = &CosiAggregator{Snapshot: s}
+	node.chain.setCosiVerifier(&CosiVerifier{Snapshot: s})
+	status, err := node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStatePending, status.State)
+	require.Equal(s.Hash, status.Snapshot)
+
+	node.chain.resetCosiStateForNewRound(nil)
+	status, err = node.ReadTransactionStatus(hash)
+	require.Nil(err)
+	require.Equal(TransactionStateQueued, status.State)
+	require.False(status.Snapshot.HasValue())
+}
+
+func TestWaitTransaction(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	snapshots, err := node.persistStore.ReadSnapshotsSinceTopology(0, 1)
+	require.Nil(err)
+	status, err := node.WaitTransaction(context.Background(), snapshots[0].Transactions[0])
+	require.Nil(err)
+	require.Equal(TransactionStateFinalized, status.State)
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("wait")), 0)
+	hash := tx.AsVersioned().PayloadHash()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	status, err = node.WaitTransaction(ctx, hash)
+	require.Nil(err)
+	require.Equal(TransactionStateUnknown, status.State)
+	require.Len(node.transactionWaiters.m, 0)
+
+	waiting := func() bool {
+		node.transactionWaiters.mutex.Lock()
+		defer node.transactionWaiters.mutex.Unlock()
+		return node.transactionWaiters.m[hash] != nil
+	}
+	result := make(chan *TransactionStatus)
+	go func() {
+		status, _ := node.WaitTransaction(context.Background(), hash)
+		result <- status
+	}()
+	require.Eventually(waiting, time.Second, time.Millisecond)
+	drop := common.NewTransactionDrop(hash, 100, common.NewErrorf(common.ErrorCodeInputNotFound, "input not found"))
+	require.Nil(node.persistStore.CacheWriteTransactionDrops([]*common.TransactionDrop{drop}))
+	node.transactionWaiters.Notify([]crypto.Hash{hash})
+	status = <-result
+	require.Equal(TransactionStateDropped, status.State)
+	require.False(waiting())
+}
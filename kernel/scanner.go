@@ -0,0 +1,173 @@
+package kernel
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/logger"
+)
+
+const (
+	scanBatchSize     = 100
+	scanRetryInterval = time.Minute
+)
+
+// ScanLoop scans the finalized snapshots for the outputs owned by the scan
+// accounts when the scanner is enabled. Each account resumes from its own
+// topology, so a new account catches up without rescanning the others.
+func (node *Node) ScanLoop() {
+	defer close(node.slc)
+
+	if !node.custom.Scanner.Enabled {
+		return
+	}
+	for {
+		written := node.TopologyWritten()
+		count, err := node.scanOutputs()
+		if err != nil {
+			logger.Printf("node.scanOutputs() => %v\n", err)
+		}
+		if err == nil && count == scanBatchSize {
+			select {
+			case <-node.done:
+				return
+			default:
+				continue
+			}
+		}
+
+		timer := time.NewTimer(scanRetryInterval)
+		select {
+		case <-node.done:
+			timer.Stop()
+			return
+		case <-written:
+		case <-node.scanWake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// AddScanAccount registers the account to scan the snapshots since the
+// topology, the private view key is kept in the storage.
+func (node *Node) AddScanAccount(view, spend crypto.Key, since uint64) (*common.ScanAccount, error) {
+	if !node.custom.Scanner.Enabled {
+		return nil, fmt.Errorf("scanner disabled")
+	}
+	if !spend.CheckKey() {
+		return nil, fmt.Errorf("invalid public spend key %s", spend)
+	}
+	account := &common.ScanAccount{ViewKey: view, SpendKey: spend, Topology: since}
+
+	node.scanMutex.Lock()
+	err := node.persistStore.WriteScanAccount(account)
+	node.scanMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case node.scanWake <- struct{}{}:
+	default:
+	}
+	return account, nil
+}
+
+// RemoveScanAccount removes the account and its outputs, and returns the
+// count of removed outputs.
+func (node *Node) RemoveScanAccount(addr common.Address) (int, error) {
+	node.scanMutex.Lock()
+	defer node.scanMutex.Unlock()
+
+	return node.persistStore.RemoveScanAccount(addr)
+}
+
+func (node *Node) ListScanAccounts() ([]*common.ScanAccount, error) {
+	node.scanMutex.Lock()
+	defer node.scanMutex.Unlock()
+
+	return node.persistStore.ReadScanAccounts()
+}
+
+func (node *Node) scanOutputs() (int, error) {
+	node.scanMutex.Lock()
+	defer node.scanMutex.Unlock()
+
+	accounts, err := node.persistStore.ReadScanAccounts()
+	if err != nil || len(accounts) == 0 {
+		return 0, err
+	}
+	offset := accounts[0].Topology
+	for _, a := range accounts {
+		offset = min(offset, a.Topology)
+	}
+	snapshots, transactions, err := node.persistStore.ReadSnapshotWithTransactionsSinceTopology(offset, scanBatchSize)
+	if err != nil || len(snapshots) == 0 {
+		return 0, err
+	}
+
+	var outputs []*common.ScanOutput
+	var spends []*common.ScanSpend
+	for i, s := range snapshots {
+		for _, ver := range transactions[i] {
+			_, finalized, err := node.persistStore.ReadTransaction(ver.PayloadHash())
+			if err != nil {
+				return 0, err
+			}
+			if finalized != s.Hash.String() {
+				continue
+			}
+			for _, in := range ver.Inputs {
+				if in.Hash.HasValue() {
+					spends = append(spends, &common.ScanSpend{Hash: in.Hash, Index: in.Index, Transaction: ver.PayloadHash()})
+				}
+			}
+			outputs = append(outputs, scanTransactionOutputs(accounts, s, ver)...)
+		}
+	}
+
+	next := snapshots[len(snapshots)-1].TopologicalOrder + 1
+	for _, a := range accounts {
+		a.Topology = max(a.Topology, next)
+	}
+	return len(snapshots), node.persistStore.WriteScanResults(accounts, outputs, spends)
+}
+
+func scanTransactionOutputs(accounts []*common.ScanAccount, s *common.SnapshotWithTopologicalOrder, ver *common.VersionedTransaction) []*common.ScanOutput {
+	var outputs []*common.ScanOutput
+	for i, out := range ver.Outputs {
+		if out.Type != common.OutputTypeScript || !out.Mask.CheckKey() {
+			continue
+		}
+		if slices.ContainsFunc(out.Keys, func(k *crypto.Key) bool { return !k.CheckKey() }) {
+			continue
+		}
+		for _, a := range accounts {
+			if a.Topology > s.TopologicalOrder {
+				continue
+			}
+			owned := slices.ContainsFunc(out.Keys, func(k *crypto.Key) bool {
+				return a.Owns(k, &out.Mask, uint64(i))
+			})
+			if !owned {
+				continue
+			}
+			outputs = append(outputs, &common.ScanOutput{
+				Account:     a.Address(),
+				Transaction: ver.PayloadHash(),
+				Index:       uint(i),
+				Asset:       ver.Asset,
+				Amount:      out.Amount,
+				Keys:        out.Keys,
+				Mask:        out.Mask,
+				Script:      out.Script,
+				Snapshot:    s.Hash,
+				Topology:    s.TopologicalOrder,
+			})
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,52 @@
+package kernel
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanTransactionOutputs(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*common.ScanAccount, 2)
+	addresses := make([]common.Address, 3)
+	for i := range addresses {
+		seed := make([]byte, 64)
+		crypto.ReadRand(seed)
+		addresses[i] = common.NewAddressFromSeed(seed)
+	}
+	for i := range accounts {
+		accounts[i] = &common.ScanAccount{ViewKey: addresses[i].PrivateViewKey, SpendKey: addresses[i].PublicSpendKey}
+	}
+	stranger := addresses[2]
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("input")), 0)
+	tx.AddRandomScriptOutput([]*common.Address{&stranger}, common.NewThresholdScript(1), common.NewInteger(1))
+	tx.AddRandomScriptOutput([]*common.Address{&stranger, &addresses[0]}, common.NewThresholdScript(1), common.NewInteger(2))
+	tx.AddRandomScriptOutput([]*common.Address{&addresses[1]}, common.NewThresholdScript(1), common.NewInteger(3))
+	ver := tx.AsVersioned()
+	s := &common.SnapshotWithTopologicalOrder{
+		Snapshot:         &common.Snapshot{Transactions: []crypto.Hash{ver.PayloadHash()}},
+		TopologicalOrder: 7,
+	}
+	s.Hash = crypto.Blake3Hash([]byte("snapshot"))
+
+	outputs := scanTransactionOutputs(accounts, s, ver)
+	require.Len(outputs, 2)
+	require.Equal(addresses[0].String(), outputs[0].Account.String())
+	require.Equal(uint(1), outputs[0].Index)
+	require.Equal("2.00000000", outputs[0].Amount.String())
+	require.Equal(s.Hash, outputs[0].Snapshot)
+	require.Equal(uint64(7), outputs[0].Topology)
+	require.Equal(addresses[1].String(), outputs[1].Account.String())
+	require.Equal(uint(2), outputs[1].Index)
+
+	accounts[1].Topology = 8
+	outputs = scanTransactionOutputs(accounts, s, ver)
+	require.Len(outputs, 1)
+	require.Equal(addresses[0].String(), outputs[0].Account.String())
+}
@@ -0,0 +1,83 @@
+package kernel
+
+import (
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/common"
+)
+
+// XINSupply is the XIN supply at the graph timestamp. Maximum is the mint
+// pool and the liquidity, Pool the part of the mint pool not distributed
+// yet, Pledged the outputs of pledging and accepted nodes, and Burned the
+// finalized outputs never spendable. Kernel is the balance of XIN in the
+// kernel, and the rest of the liquidity stays on Ethereum. Indexed is false
+// if the database was finalized by an older node without rebuilding the
+// asset supply, then Burned misses the outputs before the upgrade.
+//
+//	Total       = Maximum - Burned
+//	Circulating = Total - Pool - Pledged
+type XINSupply struct {
+	Timestamp   uint64
+	Maximum     common.Integer
+	Total       common.Integer
+	Circulating common.Integer
+	Kernel      common.Integer
+	Pool        common.Integer
+	Pledged     common.Integer
+	Burned      common.Integer
+	Indexed     bool
+}
+
+func (node *Node) XINSupply() (*XINSupply, error) {
+	timestamp := node.GraphTimestamp
+	pool, err := node.PoolSize()
+	if err != nil {
+		return nil, err
+	}
+	_, kernel, err := node.persistStore.ReadAssetWithBalance(common.XINAssetId)
+	if err != nil {
+		return nil, err
+	}
+	burned, indexed, err := node.persistStore.ReadAssetBurned(common.XINAssetId)
+	if err != nil {
+		return nil, err
+	}
+
+	pledged := common.Zero
+	for _, n := range node.persistStore.ReadAllNodes(timestamp, false) {
+		switch n.State {
+		case common.NodeStatePledging, common.NodeStateAccepted:
+		default:
+			continue
+		}
+		tx, _, err := node.persistStore.ReadTransaction(n.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil || len(tx.Outputs) == 0 {
+			return nil, fmt.Errorf("node %s transaction %s not found", n.Signer, n.Transaction)
+		}
+		pledged = pledged.Add(tx.Outputs[0].Amount)
+	}
+
+	supply := &XINSupply{
+		Timestamp: timestamp,
+		Maximum:   MintPool.Add(MintLiquidity),
+		Kernel:    kernel,
+		Pool:      pool,
+		Pledged:   pledged,
+		Burned:    burned,
+		Indexed:   indexed,
+	}
+	supply.Total = supply.Maximum
+	if burned.Sign() > 0 {
+		supply.Total = supply.Total.Sub(burned)
+	}
+	supply.Circulating = supply.Total
+	for _, amount := range []common.Integer{pool, pledged} {
+		if amount.Sign() > 0 {
+			supply.Circulating = supply.Circulating.Sub(amount)
+		}
+	}
+	return supply, nil
+}
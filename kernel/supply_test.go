@@ -0,0 +1,52 @@
+package kernel
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXINSupply(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	supply, err := node.XINSupply()
+	require.Nil(err)
+	require.True(supply.Indexed)
+	require.Equal(node.GraphTimestamp, supply.Timestamp)
+	require.Equal("1000000.00000000", supply.Maximum.String())
+	require.Equal(poolSizeUniversal(1706).String(), supply.Pool.String())
+	require.Equal("2700.00000000", supply.Burned.String())
+	require.Equal("997300.00000000", supply.Total.String())
+
+	nodes := node.persistStore.ReadAllNodes(supply.Timestamp, false)
+	require.Greater(len(nodes), 0)
+	pledged := common.Zero
+	for range nodes {
+		pledged = pledged.Add(common.KernelNodePledgeAmount)
+	}
+	require.Equal(pledged.String(), supply.Pledged.String())
+	require.Equal(supply.Total.Sub(supply.Pool).Sub(pledged).String(), supply.Circulating.String())
+	_, kernel, err := node.persistStore.ReadAssetWithBalance(common.XINAssetId)
+	require.Nil(err)
+	require.Equal(kernel.String(), supply.Kernel.String())
+
+	for _, prefix := range []string{"ASSETINDEX", "ASSETBURNED"} {
+		_, err = node.persistStore.RemoveGraphEntries(prefix)
+		require.Nil(err)
+	}
+	supply, err = node.XINSupply()
+	require.Nil(err)
+	require.False(supply.Indexed)
+	require.Equal("0.00000000", supply.Burned.String())
+	_, err = node.persistStore.RebuildAssetSupply()
+	require.Nil(err)
+	supply, err = node.XINSupply()
+	require.Nil(err)
+	require.True(supply.Indexed)
+	require.Equal("2700.00000000", supply.Burned.String())
+}
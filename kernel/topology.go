@@ -26,12 +26,23 @@ type TopologicalSequence struct {
 	tps    float64
 
 	snapshotCounts map[crypto.Hash]uint64
+	written        chan struct{}
 }
 
 func (node *Node) TopologicalOrder() uint64 {
 	return node.TopoCounter.seq
 }
 
+// TopologyWritten returns a channel that is closed after the next snapshot
+// has been persisted with a new topological order. Subscribers should read
+// the store after obtaining the channel, so no write is missed between the
+// read and the wait.
+func (node *Node) TopologyWritten() <-chan struct{} {
+	node.TopoCounter.Lock()
+	defer node.TopoCounter.Unlock()
+	return node.TopoCounter.written
+}
+
 func (node *Node) SPS() float64 {
 	return node.TopoCounter.sps
 }
@@ -87,6 +98,9 @@ func (node *Node) TopoWrite(s *common.Snapshot, signers []crypto.Hash) *common.S
 	if err != nil {
 		panic(err)
 	}
+	close(node.TopoCounter.written)
+	node.TopoCounter.written = make(chan struct{})
+	node.transactionWaiters.Notify(s.Transactions)
 	return topo
 }
 
@@ -130,6 +144,7 @@ func (node *Node) getTopologyCounter(store storage.Store) *TopologicalSequence {
 		seq:            s.TopologicalOrder,
 		filter:         make(map[crypto.Hash]bool),
 		snapshotCounts: make(map[crypto.Hash]uint64),
+		written:        make(chan struct{}),
 	}
 	topo.point = topo.seq
 	go topo.TopoStats(node)
@@ -0,0 +1,71 @@
+package kernel
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyWritten(t *testing.T) {
+	require := require.New(t)
+
+	root := t.TempDir()
+	node := setupTestNode(require, root)
+	require.NotNil(node)
+
+	snaps, err := node.persistStore.ReadSnapshotsSinceTopology(0, 100)
+	require.Nil(err)
+	require.Len(snaps, 28)
+	node.IdForNetwork = snaps[0].NodeId
+
+	amount := common.NewIntegerFromString("89.87671232")
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddUniversalMintInput(uint64(1706), amount)
+	addr, err := common.NewAddressFromString("XINYneY2gomSHxkYF62pxbNdwcdhcayxJRAeyUanJR611q5NWg4QebfFhEF3Me8qCHR8g8tD6QHPHD8naZnnn3GdRrhhiuxi")
+	require.Nil(err)
+	tx.AddScriptOutput([]*common.Address{&addr}, common.NewThresholdScript(1), amount, make([]byte, 64))
+	versioned := tx.AsVersioned()
+	require.Nil(versioned.LockInputs(node.persistStore, false))
+	require.Nil(node.persistStore.WriteTransaction(versioned))
+
+	cache, err := loadHeadRoundForNode(node.persistStore, node.IdForNetwork)
+	require.Nil(err)
+	snap := &common.Snapshot{
+		Version:     common.SnapshotVersionCommonEncoding,
+		NodeId:      node.IdForNetwork,
+		RoundNumber: 1,
+		Timestamp:   snaps[len(snaps)-1].Timestamp + 1,
+		Signature:   &crypto.CosiSignature{Mask: 1},
+		References: &common.RoundLink{
+			Self:     cache.References.Self,
+			External: cache.References.External,
+		},
+	}
+	snap.AddTransaction(versioned.PayloadHash())
+	snap.Hash = snap.PayloadHash()
+
+	written := node.TopologyWritten()
+	require.Equal(written, node.TopologyWritten())
+	topo := node.TopoWrite(snap, []crypto.Hash{snap.NodeId})
+	require.Equal(uint64(28), topo.TopologicalOrder)
+	select {
+	case <-written:
+	default:
+		require.Fail("topology written channel not closed")
+	}
+	next := node.TopologyWritten()
+	require.NotEqual(written, next)
+	select {
+	case <-next:
+		require.Fail("topology written channel closed before the next write")
+	default:
+	}
+
+	snaps, err = node.persistStore.ReadSnapshotsSinceTopology(28, 10)
+	require.Nil(err)
+	require.Len(snaps, 1)
+	require.Equal(snap.Hash, snaps[0].Hash)
+	require.Equal(uint64(28), snaps[0].TopologicalOrder)
+}
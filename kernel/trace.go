@@ -0,0 +1,176 @@
+package kernel
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel/internal/clock"
+	"github.com/MixinNetwork/mixin/logger"
+)
+
+const (
+	CosiTraceSizeDefault = 1024
+	cosiTraceQueueSize   = 1024
+)
+
+// CosiTraceEvent is one CoSi step of a snapshot seen by this node. Peer is the
+// node sending the message, or this node itself for its own announcement and
+// response, and the finalization it aggregates. Late is the nanoseconds of a
+// commitment after the announcement, or of a response after the challenge.
+type CosiTraceEvent struct {
+	Phase     string      `json:"phase"`
+	Peer      crypto.Hash `json:"peer"`
+	Timestamp uint64      `json:"timestamp"`
+	Late      uint64      `json:"late,omitempty"`
+}
+
+type CosiTrace struct {
+	Snapshot crypto.Hash       `json:"snapshot"`
+	NodeId   crypto.Hash       `json:"node"`
+	Round    uint64            `json:"round"`
+	Events   []*CosiTraceEvent `json:"events"`
+}
+
+// cosiTraceRing keeps the traces of the latest snapshots, and writes each
+// finalized or abandoned trace as a JSON line to file if set. The lines are
+// queued to a writer goroutine, so the consensus loop never waits for the
+// disk, and dropped when the queue is full.
+type cosiTraceRing struct {
+	mutex  sync.Mutex
+	size   int
+	traces map[crypto.Hash]*CosiTrace
+	ring   []crypto.Hash
+	next   int
+	lines  chan []byte
+	done   chan struct{}
+}
+
+func (r *cosiTraceRing) open(size int, path string) error {
+	r.size = size
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	r.lines = make(chan []byte, cosiTraceQueueSize)
+	r.done = make(chan struct{})
+	go r.loopWrite(f, r.lines, r.done)
+	return nil
+}
+
+func (r *cosiTraceRing) loopWrite(f *os.File, lines <-chan []byte, done chan<- struct{}) {
+	defer close(done)
+	defer f.Close()
+
+	for b := range lines {
+		_, err := f.Write(b)
+		if err != nil {
+			logger.Printf("cosiTraceRing.Write() ERROR %v\n", err)
+		}
+	}
+}
+
+func (r *cosiTraceRing) close() {
+	r.mutex.Lock()
+	lines, done := r.lines, r.done
+	r.lines = nil
+	r.mutex.Unlock()
+
+	if lines != nil {
+		close(lines)
+		<-done
+	}
+}
+
+func (r *cosiTraceRing) record(s *common.Snapshot, phase string, peer crypto.Hash) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t := r.traces[s.Hash]
+	if t == nil && phase == CosiPhaseAbandon {
+		return
+	}
+	if t == nil {
+		t = r.add(s)
+	}
+	e := &CosiTraceEvent{Phase: phase, Peer: peer, Timestamp: clock.NowUnixNano()}
+	switch phase {
+	case CosiPhaseCommitment:
+		e.Late = t.since(e.Timestamp, CosiPhaseAnnouncement)
+	case CosiPhaseResponse:
+		e.Late = t.since(e.Timestamp, CosiPhaseChallenge, CosiPhaseFullChallenge)
+	}
+	t.Events = append(t.Events, e)
+
+	if r.lines == nil {
+		return
+	}
+	switch phase {
+	case CosiPhaseFinalization, CosiPhaseAbandon:
+		b, err := json.Marshal(t)
+		if err != nil {
+			panic(err)
+		}
+		select {
+		case r.lines <- append(b, '\n'):
+		default:
+			logger.Printf("cosiTraceRing.record(%s) ERROR write queue full\n", s.Hash)
+		}
+	}
+}
+
+func (r *cosiTraceRing) add(s *common.Snapshot) *CosiTrace {
+	if r.traces == nil {
+		if r.size < 1 {
+			r.size = CosiTraceSizeDefault
+		}
+		r.traces = make(map[crypto.Hash]*CosiTrace)
+		r.ring = make([]crypto.Hash, r.size)
+	}
+	delete(r.traces, r.ring[r.next])
+	r.ring[r.next] = s.Hash
+	r.next = (r.next + 1) % r.size
+
+	t := &CosiTrace{Snapshot: s.Hash, NodeId: s.NodeId, Round: s.RoundNumber}
+	r.traces[s.Hash] = t
+	return t
+}
+
+func (r *cosiTraceRing) get(hash crypto.Hash) *CosiTrace {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t := r.traces[hash]
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Events = make([]*CosiTraceEvent, len(t.Events))
+	for i, e := range t.Events {
+		ce := *e
+		c.Events[i] = &ce
+	}
+	return &c
+}
+
+func (t *CosiTrace) since(ts uint64, phases ...string) uint64 {
+	for _, e := range t.Events {
+		for _, p := range phases {
+			if e.Phase == p && ts > e.Timestamp {
+				return ts - e.Timestamp
+			}
+		}
+	}
+	return 0
+}
+
+// CosiTrace returns the CoSi timeline of a recent snapshot seen by this node,
+// or nil if the snapshot is not in the trace ring.
+func (node *Node) CosiTrace(hash crypto.Hash) *CosiTrace {
+	return node.cosiTraces.get(hash)
+}
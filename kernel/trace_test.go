@@ -0,0 +1,91 @@
+package kernel
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel/internal/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosiTraceRing(t *testing.T) {
+	require := require.New(t)
+	clock.Reset()
+	defer clock.Reset()
+
+	path := filepath.Join(t.TempDir(), "cosi.trace")
+	var r cosiTraceRing
+	require.Nil(r.open(2, path))
+	defer r.close()
+
+	self := crypto.Blake3Hash([]byte("self"))
+	peer := crypto.Blake3Hash([]byte("peer"))
+	s := &common.Snapshot{NodeId: self, RoundNumber: 7}
+	s.Hash = crypto.Blake3Hash([]byte("snapshot"))
+
+	r.record(s, CosiPhaseAbandon, self)
+	require.Nil(r.get(s.Hash))
+
+	r.record(s, CosiPhaseAnnouncement, self)
+	clock.MockDiff(time.Second)
+	r.record(s, CosiPhaseCommitment, peer)
+	r.record(s, CosiPhaseChallenge, peer)
+	clock.MockDiff(2 * time.Second)
+	r.record(s, CosiPhaseResponse, peer)
+	r.record(s, CosiPhaseFinalization, self)
+
+	trace := r.get(s.Hash)
+	require.NotNil(trace)
+	require.Equal(self, trace.NodeId)
+	require.Equal(uint64(7), trace.Round)
+	require.Len(trace.Events, 5)
+	require.Equal(CosiPhaseCommitment, trace.Events[1].Phase)
+	require.Equal(peer, trace.Events[1].Peer)
+	require.GreaterOrEqual(trace.Events[1].Late, uint64(time.Second))
+	require.Less(trace.Events[1].Late, uint64(2*time.Second))
+	require.Equal(uint64(0), trace.Events[2].Late)
+	require.GreaterOrEqual(trace.Events[3].Late, uint64(2*time.Second))
+	require.Less(trace.Events[3].Late, uint64(3*time.Second))
+	trace.Events[0].Phase = "changed"
+	require.Equal(CosiPhaseAnnouncement, r.get(s.Hash).Events[0].Phase)
+
+	var lines []string
+	require.Eventually(func() bool {
+		data, err := os.ReadFile(path)
+		require.Nil(err)
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		return len(lines) == 1 && lines[0] != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	var written CosiTrace
+	require.Nil(json.Unmarshal([]byte(lines[0]), &written))
+	require.Equal(s.Hash, written.Snapshot)
+	require.Len(written.Events, 5)
+	require.Equal(CosiPhaseFinalization, written.Events[4].Phase)
+
+	for i := range 2 {
+		o := &common.Snapshot{NodeId: peer, RoundNumber: uint64(i)}
+		o.Hash = crypto.Blake3Hash([]byte{byte(i)})
+		r.record(o, CosiPhaseAnnouncement, peer)
+	}
+	require.Nil(r.get(s.Hash))
+	require.NotNil(r.get(crypto.Blake3Hash([]byte{1})))
+
+	r.record(s, CosiPhaseAnnouncement, self)
+	r.record(s, CosiPhaseAbandon, self)
+	r.close()
+	r.record(s, CosiPhaseFinalization, self)
+	r.close()
+	data, err := os.ReadFile(path)
+	require.Nil(err)
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(lines, 2)
+	require.Nil(json.Unmarshal([]byte(lines[1]), &written))
+	require.Len(written.Events, 2)
+	require.Equal(CosiPhaseAbandon, written.Events[1].Phase)
+}
@@ -0,0 +1,84 @@
+package kernel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+type transactionWaiter struct {
+	c     chan struct{}
+	count int
+}
+
+// waiterMap wakes the callers waiting for transactions, when a snapshot
+// with any of them is written.
+type waiterMap struct {
+	mutex sync.Mutex
+	m     map[crypto.Hash]*transactionWaiter
+}
+
+func (s *waiterMap) Add(k crypto.Hash) *transactionWaiter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.m == nil {
+		s.m = make(map[crypto.Hash]*transactionWaiter)
+	}
+	w := s.m[k]
+	if w == nil {
+		w = &transactionWaiter{c: make(chan struct{})}
+		s.m[k] = w
+	}
+	w.count += 1
+	return w
+}
+
+// Remove drops the waiter unless it has been notified already.
+func (s *waiterMap) Remove(k crypto.Hash, w *transactionWaiter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.m[k] != w {
+		return
+	}
+	w.count -= 1
+	if w.count == 0 {
+		delete(s.m, k)
+	}
+}
+
+func (s *waiterMap) Notify(keys []crypto.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, k := range keys {
+		if w := s.m[k]; w != nil {
+			close(w.c)
+			delete(s.m, k)
+		}
+	}
+}
+
+// WaitTransaction blocks until the transaction is finalized or dropped, or
+// the context is done, then returns its latest status. It is woken by the
+// snapshot writes including the transaction, instead of polling the store.
+func (node *Node) WaitTransaction(ctx context.Context, hash crypto.Hash) (*TransactionStatus, error) {
+	for {
+		w := node.transactionWaiters.Add(hash)
+		status, err := node.ReadTransactionStatus(hash)
+		if err != nil {
+			node.transactionWaiters.Remove(hash, w)
+			return nil, err
+		}
+		switch status.State {
+		case TransactionStateFinalized, TransactionStateDropped:
+			node.transactionWaiters.Remove(hash, w)
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			node.transactionWaiters.Remove(hash, w)
+			return status, nil
+		case <-w.c:
+		}
+	}
+}
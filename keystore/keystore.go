@@ -0,0 +1,204 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const Version = 1
+
+// The argon2id parameters of new accounts, each account keeps its own so
+// they can be raised later without breaking the existing accounts.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+)
+
+// The argon2id parameter limits of a keystore file, to reject the values
+// that would make argon2 panic or exhaust the memory and time of the machine.
+const (
+	argonTimeMaximum   = 64
+	argonMemoryMaximum = 4 * 1024 * 1024
+)
+
+// Account is an address with its private view and spend keys encrypted by
+// the passphrase of the account, with XChaCha20-Poly1305 under an argon2id
+// derived key. The name and address are authenticated but not encrypted.
+type Account struct {
+	Name       string `json:"name"`
+	Address    string `json:"address"`
+	Salt       []byte `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keystore is the file of encrypted accounts, only the private keys need the
+// passphrase to read.
+type Keystore struct {
+	Version  int        `json:"version"`
+	Accounts []*Account `json:"accounts"`
+
+	path string
+}
+
+// Open loads the keystore file at path, or an empty keystore if the file
+// does not exist. The file is written by every Add.
+func Open(path string) (*Keystore, error) {
+	ks := &Keystore{Version: Version, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ks, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, ks)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s %v", path, err)
+	}
+	if ks.Version != Version {
+		return nil, fmt.Errorf("keystore %s version %d", path, ks.Version)
+	}
+	return ks, nil
+}
+
+func (ks *Keystore) Find(name string) *Account {
+	i := slices.IndexFunc(ks.Accounts, func(a *Account) bool { return a.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return ks.Accounts[i]
+}
+
+// Add encrypts the private keys of the address with the passphrase, and
+// writes the keystore file with the new account.
+func (ks *Keystore) Add(name string, addr *common.Address, passphrase []byte) (*Account, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty account name")
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	if ks.Find(name) != nil {
+		return nil, fmt.Errorf("account %s already exists", name)
+	}
+	if addr.PrivateViewKey.Public() != addr.PublicViewKey || addr.PrivateSpendKey.Public() != addr.PublicSpendKey {
+		return nil, fmt.Errorf("invalid private keys of %s", addr)
+	}
+
+	a := &Account{
+		Name:    name,
+		Address: addr.String(),
+		Salt:    make([]byte, 16),
+		Time:    argonTime,
+		Memory:  argonMemory,
+		Threads: argonThreads,
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	crypto.ReadRand(a.Salt)
+	crypto.ReadRand(a.Nonce)
+	aead, err := chacha20poly1305.NewX(a.key(passphrase))
+	if err != nil {
+		panic(err)
+	}
+	plain := append(addr.PrivateViewKey[:], addr.PrivateSpendKey[:]...)
+	a.Ciphertext = aead.Seal(nil, a.Nonce, plain, a.additionalData())
+
+	ks.Accounts = append(ks.Accounts, a)
+	err = ks.write()
+	if err != nil {
+		ks.Accounts = ks.Accounts[:len(ks.Accounts)-1]
+		return nil, err
+	}
+	return a, nil
+}
+
+// Unlock decrypts the private keys of the account with the passphrase.
+func (ks *Keystore) Unlock(name string, passphrase []byte) (*common.Address, error) {
+	a := ks.Find(name)
+	if a == nil {
+		return nil, fmt.Errorf("account %s not found", name)
+	}
+	return a.Unlock(passphrase)
+}
+
+func (a *Account) Unlock(passphrase []byte) (*common.Address, error) {
+	err := a.validateParams()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(a.key(passphrase))
+	if err != nil {
+		panic(err)
+	}
+	if len(a.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid account %s nonce", a.Name)
+	}
+	plain, err := aead.Open(nil, a.Nonce, a.Ciphertext, a.additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase for account %s", a.Name)
+	}
+	if len(plain) != 64 {
+		return nil, fmt.Errorf("invalid account %s keys", a.Name)
+	}
+
+	var addr common.Address
+	copy(addr.PrivateViewKey[:], plain[:32])
+	copy(addr.PrivateSpendKey[:], plain[32:])
+	addr.PublicViewKey = addr.PrivateViewKey.Public()
+	addr.PublicSpendKey = addr.PrivateSpendKey.Public()
+	if addr.String() != a.Address {
+		return nil, fmt.Errorf("account %s address not match %s", a.Name, addr)
+	}
+	return &addr, nil
+}
+
+func (a *Account) validateParams() error {
+	if a.Time < 1 || a.Time > argonTimeMaximum {
+		return fmt.Errorf("invalid account %s argon2 time %d", a.Name, a.Time)
+	}
+	if a.Threads < 1 {
+		return fmt.Errorf("invalid account %s argon2 threads %d", a.Name, a.Threads)
+	}
+	if a.Memory < 8*uint32(a.Threads) || a.Memory > argonMemoryMaximum {
+		return fmt.Errorf("invalid account %s argon2 memory %d", a.Name, a.Memory)
+	}
+	return nil
+}
+
+func (a *Account) key(passphrase []byte) []byte {
+	return argon2.IDKey(passphrase, a.Salt, a.Time, a.Memory, a.Threads, chacha20poly1305.KeySize)
+}
+
+func (a *Account) additionalData() []byte {
+	return []byte(a.Name + ":" + a.Address)
+}
+
+func (ks *Keystore) write() error {
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(ks.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := ks.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
@@ -0,0 +1,83 @@
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeystore(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "mixin", "keystore.json")
+	ks, err := Open(path)
+	require.Nil(err)
+	require.Len(ks.Accounts, 0)
+
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	addr := common.NewAddressFromSeed(seed)
+	_, err = ks.Add("", &addr, []byte("secret"))
+	require.ErrorContains(err, "empty account name")
+	_, err = ks.Add("alice", &addr, nil)
+	require.ErrorContains(err, "empty passphrase")
+	public := common.Address{PublicViewKey: addr.PublicViewKey, PublicSpendKey: addr.PublicSpendKey}
+	_, err = ks.Add("alice", &public, []byte("secret"))
+	require.ErrorContains(err, "invalid private keys")
+
+	a, err := ks.Add("alice", &addr, []byte("secret"))
+	require.Nil(err)
+	require.Equal(addr.String(), a.Address)
+	_, err = ks.Add("alice", &addr, []byte("secret"))
+	require.ErrorContains(err, "already exists")
+	info, err := os.Stat(path)
+	require.Nil(err)
+	require.Equal(os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.Nil(err)
+	require.NotContains(string(data), addr.PrivateSpendKey.String())
+
+	ks, err = Open(path)
+	require.Nil(err)
+	require.Len(ks.Accounts, 1)
+	_, err = ks.Unlock("bob", []byte("secret"))
+	require.ErrorContains(err, "not found")
+	_, err = ks.Unlock("alice", []byte("wrong"))
+	require.ErrorContains(err, "invalid passphrase")
+	unlocked, err := ks.Unlock("alice", []byte("secret"))
+	require.Nil(err)
+	require.Equal(addr.PrivateViewKey, unlocked.PrivateViewKey)
+	require.Equal(addr.PrivateSpendKey, unlocked.PrivateSpendKey)
+	require.Equal(addr.String(), unlocked.String())
+
+	ks.Accounts[0].Name = "bob"
+	_, err = ks.Unlock("bob", []byte("secret"))
+	require.ErrorContains(err, "invalid passphrase")
+
+	for _, c := range []struct {
+		time    uint32
+		memory  uint32
+		threads uint8
+		err     string
+	}{
+		{0, argonMemory, argonThreads, "argon2 time 0"},
+		{argonTimeMaximum + 1, argonMemory, argonThreads, "argon2 time 65"},
+		{argonTime, argonMemory, 0, "argon2 threads 0"},
+		{argonTime, 0, argonThreads, "argon2 memory 0"},
+		{argonTime, argonMemoryMaximum + 1, argonThreads, "argon2 memory 4194305"},
+	} {
+		b := *ks.Accounts[0]
+		b.Time, b.Memory, b.Threads = c.time, c.memory, c.threads
+		_, err = b.Unlock([]byte("secret"))
+		require.ErrorContains(err, c.err)
+	}
+
+	err = os.WriteFile(path, []byte(`{"version":2}`), 0600)
+	require.Nil(err)
+	_, err = Open(path)
+	require.ErrorContains(err, "version 2")
+}
@@ -0,0 +1,196 @@
+package lightclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/rpc"
+	"github.com/MixinNetwork/mixin/verifier"
+)
+
+// State is the verified node operations since genesis, which is all a light
+// client needs to know the consensus nodes at any time until Timestamp.
+type State struct {
+	Network    crypto.Hash      `json:"network"`
+	Timestamp  uint64           `json:"timestamp"`
+	Operations []*verifier.Node `json:"operations"`
+}
+
+// Client follows the consensus membership from genesis by verifying only the
+// node operation snapshots, and verifies snapshots from untrusted RPC nodes.
+// A node may hide the latest operations from Sync, which only makes later
+// snapshots fail to verify, so use several nodes or Sync again on failures.
+type Client struct {
+	RPC *rpc.Client
+
+	mutex   sync.RWMutex
+	path    string
+	genesis *verifier.Checkpoint
+	state   *State
+}
+
+// NewClient loads the state file at path, or starts from genesis if the file
+// does not exist. The state file is written by every successful Sync.
+func NewClient(gns *common.Genesis, path string, client *rpc.Client) (*Client, error) {
+	genesis, err := verifier.GenesisCheckpoint(gns)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		RPC:     client,
+		path:    path,
+		genesis: genesis,
+		state:   &State{Network: genesis.Network, Timestamp: genesis.Timestamp},
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state State
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("lightclient state %s %v", path, err)
+	}
+	if state.Network != genesis.Network {
+		return nil, fmt.Errorf("lightclient state network %s not match %s", state.Network, genesis.Network)
+	}
+	if state.Timestamp < genesis.Timestamp {
+		return nil, fmt.Errorf("lightclient state timestamp %d before genesis %d", state.Timestamp, genesis.Timestamp)
+	}
+	c.state = &state
+	return c, nil
+}
+
+// Timestamp is the snapshot timestamp of the latest verified node operation.
+func (c *Client) Timestamp() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.state.Timestamp
+}
+
+// Checkpoint returns the consensus nodes after the latest verified operation.
+func (c *Client) Checkpoint() *verifier.Checkpoint {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.genesis.At(c.state.Operations, c.state.Timestamp+1)
+}
+
+// Sync fetches the node operations after the state, verifies them in order
+// and writes the new state file. It returns the count of new operations.
+func (c *Client) Sync(ctx context.Context) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	nodes, err := c.RPC.ListAllNodes(ctx, 0, true)
+	if err != nil {
+		return 0, err
+	}
+	var latest *rpc.KernelNode
+	for _, n := range nodes {
+		if n.Timestamp > c.state.Timestamp && (latest == nil || n.Timestamp > latest.Timestamp) {
+			latest = n
+		}
+	}
+	if latest == nil {
+		return 0, nil
+	}
+
+	proof, err := c.RPC.GetTransactionProof(ctx, latest.Transaction, c.state.Timestamp)
+	if err != nil {
+		return 0, err
+	}
+	if proof == nil {
+		return 0, fmt.Errorf("node operation %s not found", latest.Transaction)
+	}
+	if proof.Network != c.state.Network {
+		return 0, fmt.Errorf("proof network %s not match %s", proof.Network, c.state.Network)
+	}
+	cp := c.genesis.At(c.state.Operations, c.state.Timestamp+1)
+	next, operations, err := cp.Follow(append(proof.History, &proof.Finalization))
+	if err != nil {
+		return 0, err
+	}
+
+	state := &State{
+		Network:    c.state.Network,
+		Timestamp:  next.Timestamp,
+		Operations: append(slices.Clone(c.state.Operations), operations...),
+	}
+	err = c.write(state)
+	if err != nil {
+		return 0, err
+	}
+	c.state = state
+	return len(operations), nil
+}
+
+// VerifySnapshot fetches the snapshot and verifies its signature with the
+// consensus nodes at the snapshot. Snapshots after node operations not yet
+// synced fail to verify.
+func (c *Client) VerifySnapshot(ctx context.Context, hash crypto.Hash) (*rpc.Snapshot, error) {
+	s, err := c.RPC.GetSnapshot(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("snapshot %s not found", hash)
+	}
+	if s.Hash != hash {
+		return nil, fmt.Errorf("snapshot %s not match %s", s.Hash, hash)
+	}
+
+	c.mutex.RLock()
+	cp := c.genesis.At(c.state.Operations, s.Timestamp)
+	c.mutex.RUnlock()
+	err = cp.VerifySnapshot(s.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// VerifyTransaction fetches the transaction and verifies its snapshot.
+func (c *Client) VerifyTransaction(ctx context.Context, hash crypto.Hash) (*common.VersionedTransaction, *rpc.Snapshot, error) {
+	tx, sh, err := c.RPC.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx == nil || !sh.HasValue() {
+		return nil, nil, fmt.Errorf("transaction %s not finalized", hash)
+	}
+	if tx.PayloadHash() != hash {
+		return nil, nil, fmt.Errorf("transaction %s not match %s", tx.PayloadHash(), hash)
+	}
+	s, err := c.VerifySnapshot(ctx, sh)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !slices.Contains(s.Transactions, hash) {
+		return nil, nil, fmt.Errorf("transaction %s not in snapshot %s", hash, sh)
+	}
+	return tx, s, nil
+}
+
+func (c *Client) write(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,249 @@
+package lightclient
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/rpc"
+	"github.com/MixinNetwork/mixin/verifier"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLightClient(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	signers := make([]common.Address, 8)
+	payees := make([]common.Address, 8)
+	for i := range signers {
+		signers[i] = testNodeAddress(fmt.Sprintf("signer%d", i))
+		payees[i] = testNodeAddress(fmt.Sprintf("payee%d", i))
+	}
+	gns := testGenesis(signers[:7], payees[:7])
+	network := gns.NetworkId()
+	epoch := gns.EpochTimestamp()
+
+	keys := make(map[crypto.Hash]crypto.Key)
+	all := make([]*verifier.Signer, 8)
+	for i, s := range signers {
+		id := s.Hash().ForNetwork(network)
+		keys[id] = s.PrivateSpendKey
+		all[i] = &verifier.Signer{Id: id, Key: s.PublicSpendKey}
+	}
+	genesis := all[:7]
+	sort.Slice(genesis, func(i, j int) bool { return genesis[i].Id.String() < genesis[j].Id.String() })
+
+	pledge := common.NewTransactionV5(common.XINAssetId)
+	pledge.AddInput(crypto.Blake3Hash([]byte("pledge")), 0)
+	pledge.Outputs = []*common.Output{{Type: common.OutputTypeNodePledge, Amount: common.KernelNodePledgeAmount}}
+	pledge.Extra = append(signers[7].PublicSpendKey[:], payees[7].PublicSpendKey[:]...)
+	accept := common.NewTransactionV5(common.XINAssetId)
+	accept.AddInput(pledge.AsVersioned().PayloadHash(), 0)
+	accept.Outputs = []*common.Output{{Type: common.OutputTypeNodeAccept, Amount: common.KernelNodePledgeAmount}}
+	accept.Extra = pledge.Extra
+	pledgeFinalization := testFinalization(pledge.AsVersioned(), genesis[1].Id, 5, epoch+uint64(2*time.Hour), genesis, keys, 5)
+	acceptFinalization := testFinalization(accept.AsVersioned(), all[7].Id, 0, epoch+uint64(3*time.Hour), all, keys, 5)
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("input")), 0)
+	ver := tx.AsVersioned()
+	f := testFinalization(ver, genesis[2].Id, 9, epoch+uint64(16*time.Hour), all, keys, 8)
+	snap := testSnapshot(f)
+
+	nodes := make([]map[string]any, 0)
+	for _, s := range signers[:7] {
+		nodes = append(nodes, map[string]any{"signer": s, "state": common.NodeStateAccepted, "timestamp": epoch})
+	}
+	nodes = append(nodes, map[string]any{"signer": signers[7], "state": common.NodeStatePledging, "transaction": pledge.AsVersioned().PayloadHash(), "timestamp": epoch + uint64(2*time.Hour)})
+	nodes = append(nodes, map[string]any{"signer": signers[7], "state": common.NodeStateAccepted, "transaction": accept.AsVersioned().PayloadHash(), "timestamp": epoch + uint64(3*time.Hour)})
+	var tamper atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&call)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var data any
+		switch call.Method {
+		case "listallnodes":
+			data = nodes
+		case "gettransactionproof":
+			since := uint64(call.Params[1].(float64))
+			history := []*verifier.Finalization{}
+			if since < epoch+uint64(2*time.Hour) {
+				history = append(history, pledgeFinalization)
+			}
+			data = &verifier.Proof{Network: network, Finalization: *acceptFinalization, History: history}
+		case "gettransaction":
+			data = map[string]any{"hex": hex.EncodeToString(ver.Marshal()), "snapshot": snap.Hash}
+		case "getsnapshot":
+			s := f.Snapshot
+			if tamper.Load() {
+				s = testFinalization(ver, genesis[3].Id, 9, snap.Timestamp, all, keys, 8).Snapshot
+			}
+			data = map[string]any{"hex": s, "topology": 9}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "lightclient.json")
+	client, err := NewClient(gns, path, rpc.NewClient(server.URL))
+	require.Nil(err)
+	require.Equal(epoch, client.Timestamp())
+	require.Len(client.Checkpoint().Nodes, 7)
+	_, _, err = client.VerifyTransaction(ctx, ver.PayloadHash())
+	require.ErrorContains(err, "cosi.FullVerify")
+
+	count, err := client.Sync(ctx)
+	require.Nil(err)
+	require.Equal(2, count)
+	require.Equal(epoch+uint64(3*time.Hour), client.Timestamp())
+	require.Len(client.Checkpoint().Nodes, 8)
+	count, err = client.Sync(ctx)
+	require.Nil(err)
+	require.Equal(0, count)
+
+	got, s, err := client.VerifyTransaction(ctx, ver.PayloadHash())
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), got.PayloadHash())
+	require.Equal(snap.Hash, s.Hash)
+	require.Equal(uint64(9), s.TopologicalOrder)
+	tamper.Store(true)
+	_, _, err = client.VerifyTransaction(ctx, ver.PayloadHash())
+	require.ErrorContains(err, "not match")
+	tamper.Store(false)
+
+	client, err = NewClient(gns, path, rpc.NewClient(server.URL))
+	require.Nil(err)
+	require.Equal(epoch+uint64(3*time.Hour), client.Timestamp())
+	_, err = client.VerifySnapshot(ctx, snap.Hash)
+	require.Nil(err)
+
+	other := testGenesis(signers[1:8], payees[1:8])
+	_, err = NewClient(other, path, rpc.NewClient(server.URL))
+	require.ErrorContains(err, "network")
+	err = os.WriteFile(path, []byte("{"), 0644)
+	require.Nil(err)
+	_, err = NewClient(gns, path, rpc.NewClient(server.URL))
+	require.ErrorContains(err, "lightclient state")
+}
+
+func testFinalization(ver *common.VersionedTransaction, node crypto.Hash, round, timestamp uint64, consensus []*verifier.Signer, keys map[crypto.Hash]crypto.Key, count int) *verifier.Finalization {
+	s := &common.Snapshot{
+		Version:     common.SnapshotVersionCommonEncoding,
+		NodeId:      node,
+		RoundNumber: round,
+		Timestamp:   timestamp,
+	}
+	if round > 0 {
+		s.References = &common.RoundLink{Self: crypto.Blake3Hash([]byte("self")), External: crypto.Blake3Hash([]byte("external"))}
+	}
+	s.AddTransaction(ver.PayloadHash())
+	s.Hash = s.PayloadHash()
+
+	publics := make([]*crypto.Key, len(consensus))
+	for i, c := range consensus {
+		publics[i] = &c.Key
+	}
+	randoms := make(map[int]*crypto.Key)
+	commitments := make(map[int]*crypto.Key)
+	for i := range count {
+		seed := crypto.Blake3Hash(fmt.Appendf(nil, "%s%d", s.Hash, i))
+		r := crypto.NewKeyFromSeed(append(seed[:], seed[:]...))
+		R := r.Public()
+		randoms[i] = &r
+		commitments[i] = &R
+	}
+	cosi, err := crypto.CosiAggregateCommitment(commitments)
+	if err != nil {
+		panic(err)
+	}
+	responses := make(map[int]*[32]byte)
+	for i := range count {
+		priv := keys[consensus[i].Id]
+		res, err := cosi.Response(&priv, randoms[i], publics, s.Hash)
+		if err != nil {
+			panic(err)
+		}
+		responses[i] = res
+	}
+	err = cosi.AggregateResponse(publics, responses, s.Hash, true)
+	if err != nil {
+		panic(err)
+	}
+	s.Signature = cosi
+
+	topo := &common.SnapshotWithTopologicalOrder{Snapshot: s, TopologicalOrder: round}
+	return &verifier.Finalization{
+		Transaction: hex.EncodeToString(ver.Marshal()),
+		Snapshot:    hex.EncodeToString(topo.VersionedMarshal()),
+		Consensus:   consensus,
+	}
+}
+
+func testSnapshot(f *verifier.Finalization) *common.Snapshot {
+	raw, err := hex.DecodeString(f.Snapshot)
+	if err != nil {
+		panic(err)
+	}
+	s, err := common.UnmarshalVersionedSnapshot(raw)
+	if err != nil {
+		panic(err)
+	}
+	s.Hash = s.PayloadHash()
+	return s.Snapshot
+}
+
+func testGenesis(signers, payees []common.Address) *common.Genesis {
+	custodian := testNodeAddress("custodian")
+	nodes := make([]map[string]any, len(signers))
+	for i := range signers {
+		nodes[i] = map[string]any{
+			"signer":    signers[i],
+			"payee":     payees[i],
+			"custodian": custodian,
+			"balance":   common.KernelNodePledgeAmount,
+		}
+	}
+	data, err := json.Marshal(map[string]any{
+		"epoch":     1700006400,
+		"nodes":     nodes,
+		"custodian": custodian,
+	})
+	if err != nil {
+		panic(err)
+	}
+	var gns common.Genesis
+	err = json.Unmarshal(data, &gns)
+	if err != nil {
+		panic(err)
+	}
+	return &gns
+}
+
+func testNodeAddress(seed string) common.Address {
+	h := crypto.Blake3Hash([]byte(seed))
+	priv := crypto.NewKeyFromSeed(append(h[:], h[:]...))
+	addr := common.Address{PrivateSpendKey: priv, PublicSpendKey: priv.Public()}
+	addr.PrivateViewKey = addr.PublicSpendKey.DeterministicHashDerive()
+	addr.PublicViewKey = addr.PrivateViewKey.Public()
+	return addr
+}
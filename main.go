@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -24,6 +26,10 @@ func main() {
 	if defaultRPC == "" {
 		defaultRPC = "http://127.0.0.1:6860"
 	}
+	defaultKeystore := "keystore.json"
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultKeystore = filepath.Join(home, ".mixin", "keystore.json")
+	}
 	if strings.Contains(config.BuildVersion, "BUILD_VERSION") {
 		panic("please build the application using make command.")
 	}
@@ -44,6 +50,17 @@ func main() {
 			Aliases: []string{"d"},
 			Usage:   "the data directory",
 		},
+		&cli.StringFlag{
+			Name:    "keystore",
+			EnvVars: []string{"MIXIN_KEYSTORE"},
+			Value:   defaultKeystore,
+			Usage:   "the encrypted keystore file (defaults to MIXIN_KEYSTORE or ~/.mixin/keystore.json)",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			EnvVars: []string{"MIXIN_KERNEL_RPC_TOKEN"},
+			Usage:   "the RPC admin token (defaults to MIXIN_KERNEL_RPC_TOKEN)",
+		},
 	}
 	app.EnableBashCompletion = true
 	app.Commands = []*cli.Command{
@@ -102,6 +119,50 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:  "keystore",
+			Usage: "Manage the accounts of the encrypted keystore",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "create",
+					Usage:  "Create a random account in the keystore",
+					Action: keystoreCreateCmd,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "the account name",
+						},
+					},
+				},
+				{
+					Name:   "import",
+					Usage:  "Import the private keys read from the standard input as an account",
+					Action: keystoreImportCmd,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "the account name",
+						},
+					},
+				},
+				{
+					Name:   "list",
+					Usage:  "List the account names and addresses",
+					Action: keystoreListCmd,
+				},
+				{
+					Name:   "export",
+					Usage:  "Export the private keys of an account",
+					Action: keystoreExportCmd,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "the account name",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:   "decodeaddress",
 			Usage:  "Decode an address as public view key and public spend key",
@@ -179,6 +240,16 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "rebuildassetsupply",
+			Usage:  "Rebuild the asset supply history and burned totals from the graph data storage",
+			Action: rebuildAssetSupply,
+		},
+		{
+			Name:   "rebuildtransactionindex",
+			Usage:  "Rebuild the transaction reference, asset and snapshot time indexes from the graph data storage",
+			Action: rebuildTransactionIndex,
+		},
 		{
 			Name:   "validategraphentries",
 			Usage:  "Validate transaction-hash integrity",
@@ -196,6 +267,10 @@ func main() {
 			Usage:  "Build a script raw transaction",
 			Action: buildRawTransactionCmd,
 			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "account",
+					Usage: "the keystore account to sign the transaction instead of the private keys",
+				},
 				&cli.StringFlag{
 					Name:  "asset",
 					Usage: "the asset id of the transaction",
@@ -224,6 +299,10 @@ func main() {
 					Name:  "seed",
 					Usage: "the mask seed to hide the recipient public key",
 				},
+				&cli.BoolFlag{
+					Name:  "aggregate",
+					Usage: "sign all inputs with a single aggregated signature",
+				},
 			},
 		},
 		{
@@ -235,6 +314,10 @@ func main() {
 					Name:  "raw",
 					Usage: "the JSON-encoded transaction construction object",
 				},
+				&cli.StringSliceFlag{
+					Name:  "account",
+					Usage: "a keystore account to sign the transaction instead of the private keys",
+				},
 				&cli.StringSliceFlag{
 					Name:  "key",
 					Usage: "a private view key followed by a private spend key, encoded as hex",
@@ -243,6 +326,10 @@ func main() {
 					Name:  "seed",
 					Usage: "the mask seed to hide the recipient public key",
 				},
+				&cli.BoolFlag{
+					Name:  "aggregate",
+					Usage: "sign all inputs with a single aggregated signature",
+				},
 			},
 		},
 		{
@@ -256,6 +343,28 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "sendrawtransactions",
+			Usage:  "Broadcast a batch of hex encoded signed raw transactions",
+			Action: sendTransactionsCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "raws",
+					Usage: "the comma separated hex encoded signed raw transactions",
+				},
+			},
+		},
+		{
+			Name:   "validaterawtransaction",
+			Usage:  "Validate a hex encoded signed raw transaction without broadcasting it",
+			Action: validateTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "raw",
+					Usage: "the hex encoded signed raw transaction",
+				},
+			},
+		},
 		{
 			Name:   "decoderawtransaction",
 			Usage:  "Decode a raw transaction as JSON",
@@ -267,6 +376,123 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "createpartialtransaction",
+			Usage:  "Create a partially signed transaction from a raw transaction and the UTXOs it spends",
+			Action: createPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "raw",
+					Usage: "the hex-encoded raw transaction, with or without signatures",
+				},
+			},
+		},
+		{
+			Name:   "commitpartialtransaction",
+			Usage:  "Commit the aggregation nonces of the signers to a partially signed transaction offline",
+			Action: commitPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "partial",
+					Usage: "the partially signed transaction file",
+				},
+				&cli.StringSliceFlag{
+					Name:  "account",
+					Usage: "a keystore account to commit for instead of the private keys",
+				},
+				&cli.StringSliceFlag{
+					Name:  "key",
+					Usage: "a private view key followed by a private spend key, encoded as hex",
+				},
+				&cli.StringFlag{
+					Name:  "nonces",
+					Usage: "the new file to keep the secret nonces until signpartialtransaction",
+				},
+			},
+		},
+		{
+			Name:   "signpartialtransaction",
+			Usage:  "Add signatures to a partially signed transaction offline",
+			Action: signPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "partial",
+					Usage: "the partially signed transaction file",
+				},
+				&cli.StringSliceFlag{
+					Name:  "account",
+					Usage: "a keystore account to sign the transaction instead of the private keys",
+				},
+				&cli.StringSliceFlag{
+					Name:  "key",
+					Usage: "a private view key followed by a private spend key, encoded as hex",
+				},
+				&cli.StringFlag{
+					Name:  "nonces",
+					Usage: "the secret nonces file of commitpartialtransaction to make the aggregation partial signatures",
+				},
+			},
+		},
+		{
+			Name:   "combinepartialtransactions",
+			Usage:  "Combine the signatures of several copies of a partially signed transaction",
+			Action: combinePartialTransactionsCmd,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "partial",
+					Usage: "a partially signed transaction file",
+				},
+			},
+		},
+		{
+			Name:   "inspectpartialtransaction",
+			Usage:  "Show the signers and the unmet thresholds of a partially signed transaction",
+			Action: inspectPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "partial",
+					Usage: "the partially signed transaction file",
+				},
+			},
+		},
+		{
+			Name:   "finalizepartialtransaction",
+			Usage:  "Encode a partially signed transaction with all thresholds met as a signed raw transaction",
+			Action: finalizePartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "partial",
+					Usage: "the partially signed transaction file",
+				},
+			},
+		},
+		{
+			Name:   "exportpartialtransaction",
+			Usage:  "Encode a partially signed transaction as text frames to show as an animated QR code for an offline signer",
+			Action: exportPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "partial",
+					Usage: "the partially signed transaction file",
+				},
+				&cli.IntFlag{
+					Name:  "size",
+					Value: common.FrameDataSizeDefault,
+					Usage: "the maximum data bytes of a frame",
+				},
+			},
+		},
+		{
+			Name:   "importpartialtransaction",
+			Usage:  "Decode the text frames of exportpartialtransaction back to a partially signed transaction",
+			Action: importPartialTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "frames",
+					Usage: "the file of the scanned frames, one each line in any order",
+				},
+			},
+		},
 		{
 			Name:   "signcustodiandeposit",
 			Usage:  "Sign a deposit transaction with a single custodian key",
@@ -276,6 +502,10 @@ func main() {
 					Name:  "receiver",
 					Usage: "the receiver address of the deposit",
 				},
+				&cli.StringFlag{
+					Name:  "account",
+					Usage: "the keystore account of the custodian instead of the private keys",
+				},
 				&cli.StringFlag{
 					Name:  "custodian",
 					Usage: "the custodian private view and spend key hex",
@@ -311,6 +541,10 @@ func main() {
 			Usage:  "Build the transaction to pledge a node",
 			Action: pledgeNodeCmd,
 			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "account",
+					Usage: "the keystore account to sign the transaction instead of the private keys",
+				},
 				&cli.StringFlag{
 					Name:  "view",
 					Usage: "the private view key to sign the transaction",
@@ -343,6 +577,10 @@ func main() {
 			Usage:  "Build the transaction to cancel a pledging node",
 			Action: cancelNodeCmd,
 			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "account",
+					Usage: "the keystore account to sign the transaction instead of the private keys",
+				},
 				&cli.StringFlag{
 					Name:  "view",
 					Usage: "the private view key which signs the pledging transaction",
@@ -469,6 +707,37 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "listsnapshotsbytime",
+			Usage:  "List finalized snapshots in a timestamp range, requires the transaction index",
+			Action: listSnapshotsByTimeCmd,
+			Flags: []cli.Flag{
+				&cli.Uint64Flag{
+					Name:  "start",
+					Value: 0,
+					Usage: "the timestamp to begin with",
+				},
+				&cli.Uint64Flag{
+					Name:  "end",
+					Value: math.MaxUint64,
+					Usage: "the timestamp to end before",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of snapshots to return (up to 500)",
+				},
+				&cli.BoolFlag{
+					Name:  "sig",
+					Usage: "whether including the signatures",
+				},
+				&cli.BoolFlag{
+					Name:  "tx",
+					Usage: "whether including the transactions",
+				},
+			},
+		},
 		{
 			Name:   "getsnapshot",
 			Usage:  "Get the snapshot by hash",
@@ -481,6 +750,18 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "getsnapshottrace",
+			Usage:  "Get the consensus timeline of a recent snapshot by hash",
+			Action: getSnapshotTraceCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "hash",
+					Aliases: []string{"x"},
+					Usage:   "the snapshot hash",
+				},
+			},
+		},
 		{
 			Name:   "gettransaction",
 			Usage:  "Get a durable transaction by hash",
@@ -505,6 +786,123 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "gettransactionstatus",
+			Usage:  "Get the lifecycle state of a transaction by hash",
+			Action: getTransactionStatusCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "hash",
+					Aliases: []string{"x"},
+					Usage:   "the transaction hash",
+				},
+			},
+		},
+		{
+			Name:   "waittransaction",
+			Usage:  "Wait until a transaction is finalized or dropped",
+			Action: waitTransactionCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "hash",
+					Aliases: []string{"x"},
+					Usage:   "the transaction hash",
+				},
+				&cli.Uint64Flag{
+					Name:  "timeout",
+					Value: 8,
+					Usage: "the seconds to wait at most",
+				},
+			},
+		},
+		{
+			Name:   "listreferencingtransactions",
+			Usage:  "List finalized transactions referencing a transaction",
+			Action: listReferencingTransactionsCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "hash",
+					Aliases: []string{"x"},
+					Usage:   "the referenced transaction hash",
+				},
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the topological order to begin with",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of transactions to return (up to 500)",
+				},
+			},
+		},
+		{
+			Name:   "gettransactionproof",
+			Usage:  "Get the finality proof of a transaction for light clients",
+			Action: getTransactionProofCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "hash",
+					Aliases: []string{"x"},
+					Usage:   "the transaction hash",
+				},
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the checkpoint timestamp in nanoseconds, the node operations after it are included",
+				},
+			},
+		},
+		{
+			Name:   "listcachetransactions",
+			Usage:  "List the transactions waiting in the cache queue",
+			Action: listCacheTransactionsCmd,
+			Flags: []cli.Flag{
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the queued timestamp in nanoseconds to begin with",
+				},
+				&cli.StringFlag{
+					Name:  "after",
+					Usage: "the hash of the last listed transaction queued at since, to continue after it",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of transactions to return (up to 500)",
+				},
+				&cli.BoolFlag{
+					Name:  "conflicts",
+					Usage: "whether only listing the transactions spending the same input as another queued one",
+				},
+			},
+		},
+		{
+			Name:   "listdroppedtransactions",
+			Usage:  "List the transactions dropped by the cache queue",
+			Action: listDroppedTransactionsCmd,
+			Flags: []cli.Flag{
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the drop timestamp in nanoseconds to begin with",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of transactions to return (up to 500)",
+				},
+			},
+		},
 		{
 			Name:   "getdeposittransaction",
 			Usage:  "Get the deposit transaction by external chain transaction",
@@ -554,6 +952,17 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "getutxos",
+			Usage:  "Get a batch of UTXOs by hashes and indexes",
+			Action: getUTXOsCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "outputs",
+					Usage: "the comma separated outputs, each as hash:index",
+				},
+			},
+		},
 		{
 			Name:   "getkey",
 			Usage:  "Get the ghost key",
@@ -566,6 +975,17 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "getkeys",
+			Usage:  "Get the outputs and spent states of ghost keys",
+			Action: getKeysCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "keys",
+					Usage: "the comma separated ghost keys",
+				},
+			},
+		},
 		{
 			Name:   "getasset",
 			Usage:  "Get the asset and balance",
@@ -577,6 +997,70 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "listassets",
+			Usage:  "List the assets and balances",
+			Action: listAssetsCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "offset",
+					Aliases: []string{"o"},
+					Usage:   "the asset id to list after",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of assets to return (up to 500)",
+				},
+			},
+		},
+		{
+			Name:   "listassetsupply",
+			Usage:  "List the balance changes of an asset",
+			Action: listAssetSupplyCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "id",
+					Usage: "the asset id",
+				},
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the timestamp to list changes since",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of changes to return (up to 500)",
+				},
+			},
+		},
+		{
+			Name:   "listtransactionsbyasset",
+			Usage:  "List finalized transactions of an asset, requires the transaction index",
+			Action: listTransactionsByAssetCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "id",
+					Usage: "the asset id",
+				},
+				&cli.Uint64Flag{
+					Name:    "since",
+					Aliases: []string{"s"},
+					Value:   0,
+					Usage:   "the topological order to begin with",
+				},
+				&cli.Uint64Flag{
+					Name:    "count",
+					Aliases: []string{"c"},
+					Value:   10,
+					Usage:   "the maximum number of transactions to return (up to 500)",
+				},
+			},
+		},
 		{
 			Name:   "listcustodianupdates",
 			Usage:  "List all custodian updates",
@@ -641,6 +1125,11 @@ func main() {
 			Usage:  "Get info from the node",
 			Action: getInfoCmd,
 		},
+		{
+			Name:   "getsupply",
+			Usage:  "Get the XIN supply computed by the node",
+			Action: getSupplyCmd,
+		},
 		{
 			Name:   "listpeers",
 			Usage:  "List all the connected peers",
@@ -662,6 +1151,65 @@ func main() {
 			Usage:  "Dump the graph head",
 			Action: dumpGraphHeadCmd,
 		},
+		{
+			Name:   "addscanaccount",
+			Usage:  "Register an account to the node output scanner",
+			Action: addScanAccountCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "view",
+					Usage: "the private view key",
+				},
+				&cli.StringFlag{
+					Name:  "spend",
+					Usage: "the public spend key",
+				},
+				&cli.Uint64Flag{
+					Name:  "since",
+					Usage: "the topology to scan since",
+				},
+			},
+		},
+		{
+			Name:   "removescanaccount",
+			Usage:  "Remove an account and its outputs from the node output scanner",
+			Action: removeScanAccountCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "address",
+					Usage: "the account address",
+				},
+			},
+		},
+		{
+			Name:   "listscanaccounts",
+			Usage:  "List the accounts of the node output scanner",
+			Action: listScanAccountsCmd,
+		},
+		{
+			Name:   "listscanoutputs",
+			Usage:  "List the outputs found by the node output scanner for an account",
+			Action: listScanOutputsCmd,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "address",
+					Usage: "the account address",
+				},
+				&cli.Uint64Flag{
+					Name:  "since",
+					Usage: "the topology to list since",
+				},
+				&cli.Uint64Flag{
+					Name:  "count",
+					Value: 10,
+					Usage: "the up limit of the returned outputs",
+				},
+				&cli.BoolFlag{
+					Name:  "unspent",
+					Usage: "only list the unspent outputs",
+				},
+			},
+		},
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -736,5 +1284,6 @@ func newCache(conf *config.Custom) (*ristretto.Cache[[]byte, any], error) {
 		NumCounters: cost / 1024 * 10,
 		MaxCost:     cost,
 		BufferItems: 64,
+		Metrics:     conf.RPC.Metrics,
 	})
 }
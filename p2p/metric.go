@@ -79,6 +79,20 @@ func (mp *MetricPool) String() string {
 	return string(b)
 }
 
+// Counters returns the message counters keyed by their JSON names.
+func (mp *MetricPool) Counters() map[string]uint32 {
+	b, err := mp.MarshalJSON()
+	if err != nil {
+		panic(err)
+	}
+	var counters map[string]uint32
+	err = json.Unmarshal(b, &counters)
+	if err != nil {
+		panic(err)
+	}
+	return counters
+}
+
 func (mp *MetricPool) MarshalJSON() ([]byte, error) {
 	snapshot := MetricPool{
 		PeerMessageTypePing:                       atomic.LoadUint32(&mp.PeerMessageTypePing),
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// GetAsset returns the asset and its balance, or nil if the asset is unknown.
+func (c *Client) GetAsset(ctx context.Context, id crypto.Hash) (*common.Asset, common.Integer, error) {
+	var out *struct {
+		Chain    crypto.Hash    `json:"chain"`
+		AssetKey string         `json:"asset_key"`
+		Balance  common.Integer `json:"balance"`
+	}
+	err := c.Call(ctx, "getasset", []any{id.String()}, &out)
+	if err != nil || out == nil {
+		return nil, common.Zero, err
+	}
+	return &common.Asset{Chain: out.Chain, AssetKey: out.AssetKey}, out.Balance, nil
+}
+
+type Asset struct {
+	Id       crypto.Hash    `json:"id"`
+	Chain    crypto.Hash    `json:"chain"`
+	AssetKey string         `json:"asset_key"`
+	Balance  common.Integer `json:"balance"`
+	Capacity common.Integer `json:"capacity"`
+}
+
+// ListAssets returns count assets after the offset id, or from the first
+// asset if offset is zero.
+func (c *Client) ListAssets(ctx context.Context, offset crypto.Hash, count uint64) ([]*Asset, error) {
+	var o string
+	if offset.HasValue() {
+		o = offset.String()
+	}
+	var assets []*Asset
+	err := c.Call(ctx, "listassets", []any{o, count}, &assets)
+	return assets, err
+}
+
+func (c *Client) ListAssetSupply(ctx context.Context, id crypto.Hash, since, count uint64) ([]*common.AssetSupplyChange, error) {
+	var changes []*common.AssetSupplyChange
+	err := c.Call(ctx, "listassetsupply", []any{id.String(), since, count}, &changes)
+	for _, sc := range changes {
+		sc.Asset = id
+	}
+	return changes, err
+}
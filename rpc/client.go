@@ -2,11 +2,15 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/util"
 )
 
@@ -19,7 +23,85 @@ var rpcHTTPClient = &http.Client{
 	},
 }
 
+// Client calls the RPC of a list of kernel nodes. A call goes to the node
+// that answered the last call, and fails over to the next node on transport
+// errors, bad statuses or errors the node marks retryable. After all nodes
+// failed, it waits Backoff and tries all nodes again, up to Retries times.
+// Token is sent as the bearer token for the admin methods of the nodes.
+type Client struct {
+	Nodes   []string
+	HTTP    *http.Client
+	Retries int
+	Backoff time.Duration
+	Token   string
+
+	preferred atomic.Uint32
+}
+
+func NewClient(nodes ...string) *Client {
+	return &Client{
+		Nodes:   nodes,
+		HTTP:    rpcHTTPClient,
+		Retries: 2,
+		Backoff: 500 * time.Millisecond,
+	}
+}
+
+// Call sends method with params and decodes the data into result, which is
+// left untouched if the node returns null.
+func (c *Client) Call(ctx context.Context, method string, params []any, result any) error {
+	data, err := c.call(ctx, method, params)
+	if err != nil || data == nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (c *Client) call(ctx context.Context, method string, params []any) ([]byte, error) {
+	if len(c.Nodes) == 0 {
+		return nil, errors.New("rpc client without nodes")
+	}
+	if params == nil {
+		params = []any{}
+	}
+	var err error
+	for r := 0; r <= c.Retries; r++ {
+		if r > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Backoff):
+			}
+		}
+		start := int(c.preferred.Load())
+		for i := range c.Nodes {
+			n := (start + i) % len(c.Nodes)
+			var data []byte
+			var remote bool
+			data, remote, err = callMixinRPC(ctx, c.HTTP, c.Nodes[n], c.Token, method, params)
+			if err == nil {
+				c.preferred.Store(uint32(n))
+				return data, nil
+			}
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if remote && !common.AsError(err).Retryable {
+				return nil, err
+			}
+		}
+	}
+	return nil, err
+}
+
 func CallMixinRPC(node, method string, params []any) ([]byte, error) {
+	data, _, err := callMixinRPC(context.Background(), rpcHTTPClient, node, "", method, params)
+	return data, err
+}
+
+// callMixinRPC also tells whether the error is returned by the node itself,
+// instead of the transport or an unexpected response.
+func callMixinRPC(ctx context.Context, client *http.Client, node, token, method string, params []any) ([]byte, bool, error) {
 	body, err := json.Marshal(map[string]any{
 		"method": method,
 		"params": params,
@@ -27,38 +109,79 @@ func CallMixinRPC(node, method string, params []any) ([]byte, error) {
 	if err != nil {
 		panic(err)
 	}
-	req, err := http.NewRequest("POST", node, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", node, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := rpcHTTPClient.Do(req)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer util.CloseOrPanic(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CallMixinRPC(%s, %s, %s) => status %d", node, method, params, resp.StatusCode)
+		return nil, false, fmt.Errorf("CallMixinRPC(%s, %s, %s) => status %d", node, method, params, resp.StatusCode)
 	}
 
 	var result struct {
-		Data  json.RawMessage `json:"data"`
-		Error any             `json:"error"`
+		Data    json.RawMessage `json:"data"`
+		Error   any             `json:"error"`
+		Details *errorDetails   `json:"details"`
 	}
 	dec := json.NewDecoder(resp.Body)
 	dec.UseNumber()
 	err = dec.Decode(&result)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if result.Error != nil {
-		return nil, fmt.Errorf("CallMixinRPC(%s, %s, %s) => %v", node, method, params, result.Error)
+		err := fmt.Errorf("CallMixinRPC(%s, %s, %s) => %v", node, method, params, result.Error)
+		return nil, true, result.Details.error(err)
 	}
 	if len(result.Data) == 0 || string(result.Data) == "null" {
-		return nil, nil
+		return nil, false, nil
+	}
+
+	return result.Data, false, nil
+}
+
+type errorDetails struct {
+	Code      common.ErrorCode     `json:"code"`
+	Category  common.ErrorCategory `json:"category"`
+	Retryable bool                 `json:"retryable"`
+	Input     *int                 `json:"input"`
+}
+
+// error keeps the classification sent by the node, so callers can inspect the
+// result with common.AsError even for codes this client does not know yet.
+func (d *errorDetails) error(err error) error {
+	if d == nil || d.Code == "" {
+		return err
 	}
+	e := common.NewError(d.Code, err)
+	e.Category = d.Category
+	e.Retryable = d.Retryable
+	if d.Input != nil {
+		e.Input = *d.Input
+	}
+	return e
+}
 
-	return result.Data, nil
+// resultError is the error of a result object that reports a failure without
+// failing the call, e.g. an invalid transaction or a dropped one.
+type resultError struct {
+	Error   string        `json:"error"`
+	Details *errorDetails `json:"details"`
+}
+
+func (re *resultError) err() error {
+	if re.Error == "" {
+		return nil
+	}
+	return re.Details.error(errors.New(re.Error))
 }
@@ -0,0 +1,343 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientFailover(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var down, busy, invalid, ok atomic.Int32
+	downServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		down.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer downServer.Close()
+	busyServer := testRPCServer(func(method string, params []any) any {
+		busy.Add(1)
+		return map[string]any{
+			"error":   "busy",
+			"details": map[string]any{"code": common.ErrorCodeNodeBusy, "category": common.ErrorCategoryUnavailable, "retryable": true},
+		}
+	})
+	defer busyServer.Close()
+	invalidServer := testRPCServer(func(method string, params []any) any {
+		invalid.Add(1)
+		return map[string]any{
+			"error":   "invalid",
+			"details": map[string]any{"code": common.ErrorCodeInvalidEncoding, "category": common.ErrorCategoryRequest, "retryable": false},
+		}
+	})
+	defer invalidServer.Close()
+	okServer := testRPCServer(func(method string, params []any) any {
+		ok.Add(1)
+		return map[string]any{"data": map[string]any{"link": 7}}
+	})
+	defer okServer.Close()
+
+	client := NewClient(downServer.URL, busyServer.URL, okServer.URL)
+	link, err := client.GetRoundLink(ctx, crypto.Hash{}, crypto.Hash{})
+	require.Nil(err)
+	require.Equal(uint64(7), link)
+	require.Equal(int32(1), down.Load())
+	require.Equal(int32(1), busy.Load())
+	require.Equal(int32(1), ok.Load())
+
+	link, err = client.GetRoundLink(ctx, crypto.Hash{}, crypto.Hash{})
+	require.Nil(err)
+	require.Equal(uint64(7), link)
+	require.Equal(int32(1), down.Load())
+	require.Equal(int32(2), ok.Load())
+
+	client = NewClient(invalidServer.URL, okServer.URL)
+	_, err = client.GetRoundLink(ctx, crypto.Hash{}, crypto.Hash{})
+	require.NotNil(err)
+	e := common.AsError(err)
+	require.Equal(common.ErrorCodeInvalidEncoding, e.Code)
+	require.False(e.Retryable)
+	require.Equal(int32(1), invalid.Load())
+	require.Equal(int32(2), ok.Load())
+
+	client = NewClient(downServer.URL, busyServer.URL)
+	client.Retries = 2
+	client.Backoff = time.Millisecond
+	_, err = client.GetRoundLink(ctx, crypto.Hash{}, crypto.Hash{})
+	require.NotNil(err)
+	require.True(common.AsError(err).Retryable)
+	require.Equal(int32(4), down.Load())
+	require.Equal(int32(4), busy.Load())
+
+	client.Backoff = time.Hour
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetRoundLink(cctx, crypto.Hash{}, crypto.Hash{})
+	require.ErrorIs(err, context.DeadlineExceeded)
+
+	_, err = NewClient().GetInfo(ctx)
+	require.NotNil(err)
+}
+
+func TestClientTypedResults(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("input")), 1)
+	tx.Extra = []byte("extra")
+	ver := tx.AsVersioned()
+	snap := crypto.Blake3Hash([]byte("snapshot"))
+	lock := crypto.Blake3Hash([]byte("lock"))
+	id := crypto.Blake3Hash([]byte("node"))
+	s := &common.SnapshotWithTopologicalOrder{
+		Snapshot: &common.Snapshot{
+			Version:      common.SnapshotVersionCommonEncoding,
+			NodeId:       id,
+			RoundNumber:  9,
+			References:   &common.RoundLink{Self: snap, External: lock},
+			Timestamp:    1700000000000000000,
+			Transactions: []crypto.Hash{ver.PayloadHash()},
+		},
+		TopologicalOrder: 77,
+	}
+	s.Hash = s.PayloadHash()
+
+	server := testRPCServer(func(method string, params []any) any {
+		var data any
+		switch method {
+		case "gettransaction":
+			data = map[string]any{"hash": ver.PayloadHash(), "hex": hex.EncodeToString(ver.Marshal()), "snapshot": snap}
+		case "getcachetransaction":
+			data = map[string]any{"hash": ver.PayloadHash(), "hex": hex.EncodeToString(ver.Marshal())}
+		case "validaterawtransaction":
+			return map[string]any{"data": map[string]any{
+				"hash":  ver.PayloadHash(),
+				"type":  ver.TransactionType(),
+				"size":  len(ver.PayloadMarshal()),
+				"extra": map[string]any{"size": 5, "limit": 512, "price": common.ExtraStoragePrice(5)},
+				"valid": false,
+				"error": "input locked",
+				"details": map[string]any{
+					"code": common.ErrorCodeInputLocked, "category": common.ErrorCategoryInput, "retryable": false, "input": 0,
+				},
+			}}
+		case "listdroppedtransactions":
+			data = []any{map[string]any{
+				"hash": ver.PayloadHash(), "timestamp": 123, "origin": "rpc",
+				"error": "input locked", "details": map[string]any{"code": common.ErrorCodeInputLocked, "input": 0},
+			}}
+		case "getkey":
+			if params[0] == (crypto.Key{}).String() {
+				data = map[string]any{"transaction": nil}
+			} else {
+				data = map[string]any{"transaction": lock}
+			}
+		case "getkeys":
+			data = []any{
+				map[string]any{"key": params[0].([]any)[0], "transaction": ver.PayloadHash(), "index": 1, "snapshot": snap, "lock": lock, "spent": true},
+				map[string]any{"key": params[0].([]any)[1], "transaction": nil},
+			}
+		case "getutxos":
+			data = []any{
+				map[string]any{"type": 0, "hash": ver.PayloadHash(), "index": 1, "amount": "2", "lock": lock},
+				nil,
+			}
+		case "sendrawtransactions":
+			data = []any{
+				map[string]any{"hash": ver.PayloadHash()},
+				map[string]any{"error": "invalid", "details": map[string]any{"code": common.ErrorCodeInvalidEncoding}},
+			}
+		case "waittransaction":
+			data = map[string]any{"hash": params[0], "state": "finalized", "snapshot": snap, "topology": 77, "timestamp": 5}
+		case "listmintworks":
+			data = map[string]any{id.String(): [2]uint64{3, 5}}
+		case "listcachetransactions":
+			data = []any{map[string]any{
+				"hash": ver.PayloadHash(), "type": ver.TransactionType(), "asset": ver.Asset, "size": 99, "queued": 42,
+				"inputs":    []any{map[string]any{"hash": ver.Inputs[0].Hash, "index": 1}},
+				"conflicts": []crypto.Hash{lock},
+			}}
+		case "getroundbyhash":
+			data = map[string]any{
+				"node": id, "hash": params[0], "start": s.Timestamp, "end": s.Timestamp, "number": 9,
+				"references": map[string]any{"self": snap, "external": lock},
+				"snapshots": []any{map[string]any{
+					"hash": s.Hash, "hex": hex.EncodeToString(s.VersionedMarshal()), "topology": 77,
+					"witness": map[string]any{"timestamp": 88},
+				}},
+			}
+		case "getasset":
+			data = map[string]any{"id": common.XINAssetId, "chain": common.XINAssetId, "asset_key": "key", "balance": common.NewInteger(10)}
+		}
+		return map[string]any{"data": data}
+	})
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	got, hash, err := client.GetTransaction(ctx, ver.PayloadHash())
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), got.PayloadHash())
+	require.Equal(snap, hash)
+
+	got, err = client.GetCacheTransaction(ctx, ver.PayloadHash())
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), got.PayloadHash())
+
+	got, hash, err = client.GetWithdrawalClaim(ctx, ver.PayloadHash())
+	require.Nil(err)
+	require.Nil(got)
+	require.False(hash.HasValue())
+
+	validation, err := client.ValidateRawTransaction(ctx, hex.EncodeToString(ver.Marshal()))
+	require.Nil(err)
+	require.False(validation.Valid)
+	require.Equal(ver.PayloadHash(), validation.Hash)
+	require.Equal(512, validation.Extra.Limit)
+	require.Equal(common.ExtraStoragePrice(5).String(), validation.Extra.Price.String())
+	e := common.AsError(validation.Err)
+	require.Equal(common.ErrorCodeInputLocked, e.Code)
+	require.Equal(0, e.Input)
+	require.Equal("input locked", e.Error())
+
+	drops, err := client.ListDroppedTransactions(ctx, 0, 10)
+	require.Nil(err)
+	require.Len(drops, 1)
+	require.Equal(ver.PayloadHash(), drops[0].Hash)
+	require.Equal(uint64(123), drops[0].Timestamp)
+	require.Equal("rpc", drops[0].Origin)
+	require.Equal(common.ErrorCodeInputLocked, drops[0].Code)
+	require.Equal("input locked", drops[0].Reason)
+
+	locker, err := client.GetKey(ctx, crypto.Key{})
+	require.Nil(err)
+	require.False(locker.HasValue())
+	locker, err = client.GetKey(ctx, crypto.Key{1})
+	require.Nil(err)
+	require.Equal(lock, locker)
+	ghosts, err := client.GetKeys(ctx, []crypto.Key{{1}, {2}})
+	require.Nil(err)
+	require.Len(ghosts, 2)
+	require.Equal(crypto.Key{1}, ghosts[0].Key)
+	require.Equal(ver.PayloadHash(), *ghosts[0].Transaction)
+	require.Equal(uint(1), *ghosts[0].Index)
+	require.Equal(snap, *ghosts[0].Snapshot)
+	require.Equal(lock, *ghosts[0].Lock)
+	require.True(ghosts[0].Spent)
+	require.Equal(crypto.Key{2}, ghosts[1].Key)
+	require.Nil(ghosts[1].Transaction)
+	require.Nil(ghosts[1].Index)
+
+	utxos, err := client.GetUTXOs(ctx, []*common.Input{{Hash: ver.PayloadHash(), Index: 1}, {Hash: lock}})
+	require.Nil(err)
+	require.Len(utxos, 2)
+	require.Equal(uint(1), utxos[0].Index)
+	require.Equal(lock, utxos[0].LockHash)
+	require.Equal("2.00000000", utxos[0].Amount.String())
+	require.Nil(utxos[1])
+
+	sent, err := client.SendRawTransactions(ctx, []string{hex.EncodeToString(ver.Marshal()), "00"})
+	require.Nil(err)
+	require.Len(sent, 2)
+	require.Equal(ver.PayloadHash(), sent[0].Hash)
+	require.Nil(sent[0].Err)
+	require.False(sent[1].Hash.HasValue())
+	require.Equal(common.ErrorCodeInvalidEncoding, common.AsError(sent[1].Err).Code)
+
+	status, err := client.WaitTransaction(ctx, ver.PayloadHash(), 5)
+	require.Nil(err)
+	require.Equal("finalized", status.State)
+	require.Equal(snap, status.Snapshot)
+	require.Equal(uint64(77), status.Topology)
+	require.Nil(status.Err)
+
+	works, err := client.ListMintWorks(ctx, 0)
+	require.Nil(err)
+	require.Equal([2]uint64{3, 5}, works[id])
+
+	queued, err := client.ListCacheTransactions(ctx, 0, crypto.Hash{}, 10, true)
+	require.Nil(err)
+	require.Len(queued, 1)
+	require.Equal(uint64(42), queued[0].Queued)
+	require.Equal(uint(1), queued[0].Inputs[0].Index)
+	require.Equal([]crypto.Hash{lock}, queued[0].Conflicts)
+
+	round, err := client.GetRoundByHash(ctx, snap)
+	require.Nil(err)
+	require.Equal(id, round.Node)
+	require.Equal(snap, round.Hash)
+	require.Equal(uint64(9), round.Number)
+	require.Equal(lock, round.References.External)
+	require.Len(round.Snapshots, 1)
+	require.Equal(s.Hash, round.Snapshots[0].Hash)
+	require.Equal(uint64(77), round.Snapshots[0].TopologicalOrder)
+	require.Equal([]crypto.Hash{ver.PayloadHash()}, round.Snapshots[0].Transactions)
+	require.Equal(uint64(88), round.Snapshots[0].Witness.Timestamp)
+
+	asset, balance, err := client.GetAsset(ctx, common.XINAssetId)
+	require.Nil(err)
+	require.Equal("key", asset.AssetKey)
+	require.Equal("10.00000000", balance.String())
+
+	trace, err := client.GetSnapshotTrace(ctx, snap)
+	require.Nil(err)
+	require.Nil(trace)
+}
+
+func TestClientAdminToken(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	spent := crypto.Blake3Hash([]byte("spent"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			json.NewEncoder(w).Encode(map[string]any{"error": "unauthorized"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": []any{
+			map[string]any{"transaction": spent, "index": 2, "amount": "1.5", "topology": 9, "spent": spent},
+			map[string]any{"transaction": spent, "index": 3, "amount": "2", "topology": 9, "spent": nil},
+		}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.ListScanOutputs(ctx, common.Address{}, 0, 10, false)
+	require.ErrorContains(err, "unauthorized")
+
+	client.Token = "secret"
+	outputs, err := client.ListScanOutputs(ctx, common.Address{}, 0, 10, false)
+	require.Nil(err)
+	require.Len(outputs, 2)
+	require.Equal(uint(2), outputs[0].Index)
+	require.Equal("1.50000000", outputs[0].Amount.String())
+	require.Equal(spent, *outputs[0].Spent)
+	require.Nil(outputs[1].Spent)
+}
+
+func testRPCServer(handle func(method string, params []any) any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&call)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handle(call.Method, call.Params))
+	}))
+}
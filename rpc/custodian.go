@@ -0,0 +1,15 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/common"
+)
+
+// ListCustodianUpdates returns the custodian history, the Nodes and Signature
+// of each request are not returned by the node.
+func (c *Client) ListCustodianUpdates(ctx context.Context) ([]*common.CustodianUpdateRequest, error) {
+	var curs []*common.CustodianUpdateRequest
+	err := c.Call(ctx, "listcustodianupdates", nil, &curs)
+	return curs, err
+}
@@ -1,10 +1,12 @@
 package rpc
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 
 	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
 )
 
 func GetDepositTransaction(rpc, chain, hash string, index uint64) (*common.VersionedTransaction, string, error) {
@@ -27,3 +29,13 @@ func GetDepositTransaction(rpc, chain, hash string, index uint64) (*common.Versi
 	}
 	return ver, signed["snapshot"].(string), nil
 }
+
+// GetDepositTransaction returns the transaction locking the deposit and the
+// hash of its snapshot, which is zero if the transaction is not finalized yet.
+func (c *Client) GetDepositTransaction(ctx context.Context, chain crypto.Hash, hash string, index uint64) (*common.VersionedTransaction, crypto.Hash, error) {
+	data, err := c.call(ctx, "getdeposittransaction", []any{chain.String(), hash, index})
+	if err != nil || data == nil {
+		return nil, crypto.Hash{}, err
+	}
+	return decodeTransaction(data)
+}
@@ -1,18 +1,89 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/p2p"
 )
 
 type KernelInfo struct {
+	Network   crypto.Hash `json:"network"`
+	Node      crypto.Hash `json:"node"`
+	Version   string      `json:"version"`
+	Uptime    string      `json:"uptime"`
+	Epoch     string      `json:"epoch"`
 	Consensus crypto.Hash `json:"consensus"`
 	Timestamp string      `json:"timestamp"`
 	Mint      struct {
 		PoolSize common.Integer `json:"pool"`
+		Batch    uint64         `json:"batch"`
+		Pledge   common.Integer `json:"pledge"`
 	} `json:"mint"`
+	Graph struct {
+		Consensus []*ConsensusNode       `json:"consensus"`
+		Cache     map[string]*CacheRound `json:"cache"`
+		Final     map[string]*FinalRound `json:"final"`
+		Topology  uint64                 `json:"topology"`
+		SPS       float64                `json:"sps"`
+		SPT       float64                `json:"spt"`
+		TPS       float64                `json:"tps"`
+	} `json:"graph"`
+	Queue struct {
+		Finals uint64               `json:"finals"`
+		Caches uint64               `json:"caches"`
+		State  map[string][2]uint64 `json:"state"`
+	} `json:"queue"`
+	Metric struct {
+		Transport map[string]*p2p.MetricPool `json:"transport"`
+	} `json:"metric"`
+}
+
+type ConsensusNode struct {
+	Node        crypto.Hash    `json:"node"`
+	Signer      common.Address `json:"signer"`
+	Payee       common.Address `json:"payee"`
+	State       string         `json:"state"`
+	Timestamp   uint64         `json:"timestamp"`
+	Transaction crypto.Hash    `json:"transaction"`
+	Aggregator  uint64         `json:"aggregator"`
+	Works       [2]uint64      `json:"works"`
+	Spaces      [2]uint64      `json:"spaces"`
+}
+
+type CacheRound struct {
+	Node       crypto.Hash       `json:"node"`
+	Round      uint64            `json:"round"`
+	Timestamp  uint64            `json:"timestamp"`
+	Snapshots  []*CacheSnapshot  `json:"snapshots"`
+	References *common.RoundLink `json:"references"`
+}
+
+type CacheSnapshot struct {
+	Version      uint8                 `json:"version"`
+	Node         crypto.Hash           `json:"node"`
+	References   *common.RoundLink     `json:"references"`
+	Round        uint64                `json:"round"`
+	Timestamp    uint64                `json:"timestamp"`
+	Hash         crypto.Hash           `json:"hash"`
+	Transactions []crypto.Hash         `json:"transactions"`
+	Signature    *crypto.CosiSignature `json:"signature"`
+}
+
+type FinalRound struct {
+	Node  crypto.Hash `json:"node"`
+	Round uint64      `json:"round"`
+	Start uint64      `json:"start"`
+	End   uint64      `json:"end"`
+	Hash  crypto.Hash `json:"hash"`
+}
+
+type Peer struct {
+	Id      crypto.Hash `json:"id"`
+	Address string      `json:"address"`
+	Relayer bool        `json:"relayer"`
 }
 
 func GetInfo(rpc string) (*KernelInfo, error) {
@@ -27,3 +98,29 @@ func GetInfo(rpc string) (*KernelInfo, error) {
 	}
 	return &info, nil
 }
+
+func (c *Client) GetInfo(ctx context.Context) (*KernelInfo, error) {
+	var info *KernelInfo
+	err := c.Call(ctx, "getinfo", nil, &info)
+	return info, err
+}
+
+// ListPeers returns the neighbors of the node, which only answers this to
+// local requests.
+func (c *Client) ListPeers(ctx context.Context) ([]*Peer, error) {
+	var peers []*Peer
+	err := c.Call(ctx, "listpeers", nil, &peers)
+	return peers, err
+}
+
+func (c *Client) ListRelayers(ctx context.Context, id crypto.Hash) ([]*Peer, error) {
+	var peers []*Peer
+	err := c.Call(ctx, "listrelayers", []any{id.String()}, &peers)
+	return peers, err
+}
+
+func (c *Client) DumpGraphHead(ctx context.Context) ([]*p2p.SyncPoint, error) {
+	var points []*p2p.SyncPoint
+	err := c.Call(ctx, "dumpgraphhead", nil, &points)
+	return points, err
+}
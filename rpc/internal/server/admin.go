@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel"
+	"github.com/MixinNetwork/mixin/storage"
+)
+
+var (
+	errAdminDisabled = errors.New("admin methods disabled")
+	errUnauthorized  = errors.New("unauthorized")
+)
+
+// handleAdminCall serves the methods that require the admin token of the
+// node as an Authorization bearer token.
+func (impl *RPC) handleAdminCall(r *http.Request, call *Call) (any, error) {
+	token := impl.custom.RPC.AdminToken
+	if token == "" {
+		return nil, errAdminDisabled
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		return nil, errUnauthorized
+	}
+
+	switch call.Method {
+	case "addscanaccount":
+		return addScanAccount(impl.Node, call.Params)
+	case "removescanaccount":
+		return removeScanAccount(impl.Node, call.Params)
+	case "listscanaccounts":
+		return listScanAccounts(impl.Node, call.Params)
+	case "listscanoutputs":
+		return listScanOutputs(impl.Store, call.Params)
+	default:
+		return nil, errInvalidMethod
+	}
+}
+
+func addScanAccount(node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 3 {
+		return nil, errInvalidParamsCount
+	}
+	view, err := crypto.KeyFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	spend, err := crypto.KeyFromString(fmt.Sprint(params[1]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := node.AddScanAccount(view, spend, since)
+	if err != nil {
+		return nil, err
+	}
+	return scanAccountToMap(account), nil
+}
+
+func removeScanAccount(node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	addr, err := common.NewAddressFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := node.RemoveScanAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"address": addr.String(), "removed": removed}, nil
+}
+
+func listScanAccounts(node *kernel.Node, params []any) ([]map[string]any, error) {
+	if len(params) != 0 {
+		return nil, errInvalidParamsCount
+	}
+	accounts, err := node.ListScanAccounts()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(accounts))
+	for i, a := range accounts {
+		result[i] = scanAccountToMap(a)
+	}
+	return result, nil
+}
+
+func listScanOutputs(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 4 {
+		return nil, errInvalidParamsCount
+	}
+	addr, err := common.NewAddressFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+	unspent, err := strconv.ParseBool(fmt.Sprint(params[3]))
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := store.ReadScanOutputs(addr, since, int(count), unspent)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(outputs))
+	for i, out := range outputs {
+		item := map[string]any{
+			"transaction": out.Transaction,
+			"index":       out.Index,
+			"asset":       out.Asset,
+			"amount":      out.Amount,
+			"keys":        out.Keys,
+			"mask":        out.Mask,
+			"script":      out.Script,
+			"snapshot":    out.Snapshot,
+			"topology":    out.Topology,
+			"spent":       nil,
+		}
+		if out.Spent.HasValue() {
+			item["spent"] = out.Spent
+		}
+		result[i] = item
+	}
+	return result, nil
+}
+
+// scanAccountToMap never includes the private view key.
+func scanAccountToMap(a *common.ScanAccount) map[string]any {
+	return map[string]any{
+		"address":  a.Address().String(),
+		"topology": a.Topology,
+	}
+}
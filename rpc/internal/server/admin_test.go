@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAuthorization(t *testing.T) {
+	require := require.New(t)
+	impl := &RPC{custom: &config.Custom{}}
+
+	call := func(auth string) string {
+		body := `{"method":"listscanaccounts","params":[1]}`
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		res := httptest.NewRecorder()
+		impl.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+
+	require.Contains(call("Bearer secret"), "admin methods disabled")
+	impl.custom.RPC.AdminToken = "secret"
+	require.Contains(call(""), "unauthorized")
+	require.Contains(call("secret"), "unauthorized")
+	require.Contains(call("Bearer wrong"), "unauthorized")
+	require.Contains(call("Bearer secret"), errInvalidParamsCount.Error())
+}
@@ -1,16 +1,17 @@
 package server
 
 import (
-	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/MixinNetwork/mixin/storage"
 )
 
 func readAsset(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	id, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -21,10 +22,83 @@ func readAsset(store storage.Store, params []any) (map[string]any, error) {
 	if err != nil || asset == nil {
 		return nil, err
 	}
+	return assetToMap(id, asset, balance), nil
+}
+
+func listAssets(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 2 {
+		return nil, errInvalidParamsCount
+	}
+	var offset crypto.Hash
+	if o := fmt.Sprint(params[0]); o != "" {
+		h, err := crypto.HashFromString(o)
+		if err != nil {
+			return nil, err
+		}
+		offset = h
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+
+	ids, assets, balances, err := store.ReadAssetsWithBalance(offset, int(count))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		result[i] = assetToMap(id, assets[i], balances[i])
+	}
+	return result, nil
+}
+
+func listAssetSupply(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 3 {
+		return nil, errInvalidParamsCount
+	}
+	id, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+
+	changes, err := store.ReadAssetSupplyHistory(id, since, int(count))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(changes))
+	for i, c := range changes {
+		result[i] = map[string]any{
+			"transaction": c.Transaction,
+			"timestamp":   c.Timestamp,
+			"type":        c.Type,
+			"amount":      c.Amount,
+			"total":       c.Total,
+		}
+	}
+	return result, nil
+}
+
+func assetToMap(id crypto.Hash, asset *common.Asset, balance common.Integer) map[string]any {
 	return map[string]any{
 		"id":        id,
 		"chain":     asset.Chain,
 		"asset_key": asset.AssetKey,
 		"balance":   balance,
-	}, nil
+		"capacity":  common.GetAssetCapacity(id),
+	}
 }
@@ -2,7 +2,6 @@ package server
 
 import (
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"strconv"
 
@@ -13,7 +12,7 @@ import (
 
 func readDeposit(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 3 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	chain, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/MixinNetwork/mixin/kernel"
@@ -23,6 +25,11 @@ type RPC struct {
 	custom *config.Custom
 }
 
+var (
+	errInvalidMethod      = errors.New("invalid method")
+	errInvalidParamsCount = common.NewError(common.ErrorCodeInvalidParams, errors.New("invalid params count"))
+)
+
 type Call struct {
 	Id     string `json:"id"`
 	Method string `json:"method"`
@@ -50,10 +57,23 @@ func (r *Render) RenderData(data any) {
 }
 
 func (r *Render) RenderError(err error) {
-	body := map[string]any{"error": err.Error()}
+	body := map[string]any{"error": err.Error(), "details": errorDetails(err)}
 	r.render(body)
 }
 
+func errorDetails(err error) map[string]any {
+	e := common.AsError(err)
+	details := map[string]any{
+		"code":      e.Code,
+		"category":  e.Category,
+		"retryable": e.Retryable,
+	}
+	if e.Input >= 0 {
+		details["input"] = e.Input
+	}
+	return details
+}
+
 func (r *Render) render(body map[string]any) {
 	if r.id != "" {
 		body["id"] = r.id
@@ -94,175 +114,153 @@ func (impl *RPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		impl.handleObject(w, r, rdr)
 		return
 	}
+	if r.URL.Path == "/metrics" && r.Method == "GET" && impl.custom.RPC.Metrics {
+		impl.handleMetrics(w)
+		return
+	}
+	if r.URL.Path == "/snapshots/stream" && r.Method == "GET" && impl.custom.RPC.SnapshotStream {
+		impl.handleSnapshotStream(w, r, rdr)
+		return
+	}
 	if r.URL.Path != "/" || r.Method != "POST" {
 		rdr.RenderError(fmt.Errorf("bad request %s %s", r.Method, r.URL.Path))
 		return
 	}
 
-	var call Call
 	r.Body = http.MaxBytesReader(w, r.Body, maxRPCRequestBodySize)
-	dec := json.NewDecoder(r.Body)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rdr.RenderError(common.NewErrorf(common.ErrorCodeInvalidEncoding, "bad request %s", err.Error()))
+		return
+	}
+	if isJSONRPCRequest(body) {
+		impl.serveJSONRPC(w, r, body)
+		return
+	}
+
+	var call Call
+	dec := json.NewDecoder(bytes.NewReader(body))
 	dec.UseNumber()
 	if err := dec.Decode(&call); err != nil {
-		rdr.RenderError(fmt.Errorf("bad request %s", err.Error()))
+		rdr.RenderError(common.NewErrorf(common.ErrorCodeInvalidEncoding, "bad request %s", err.Error()))
 		return
 	}
 	if err := dec.Decode(&struct{}{}); err != io.EOF {
-		rdr.RenderError(fmt.Errorf("bad request trailing data"))
+		rdr.RenderError(common.NewErrorf(common.ErrorCodeInvalidEncoding, "bad request trailing data"))
 		return
 	}
 	rdr.id = call.Id
 	if impl.custom.RPC.Runtime {
 		rdr.start = time.Now()
 	}
+	data, err := impl.handleCall(r, &call)
+	if err != nil {
+		rdr.RenderError(err)
+	} else {
+		rdr.RenderData(data)
+	}
+}
+
+func (impl *RPC) handleCall(r *http.Request, call *Call) (any, error) {
 	switch call.Method {
 	case "getinfo":
-		impl.renderInfo(rdr)
+		return getInfo(impl.Store, impl.Node)
+	case "getsupply":
+		return getSupply(impl.Node)
 	case "listpeers":
 		peers := make([]map[string]any, 0)
 		if strings.HasPrefix(r.RemoteAddr, "127.0.0.1:") {
 			peers = peerNeighbors(impl.Node.Peer.Neighbors())
 		}
-		rdr.RenderData(peers)
+		return peers, nil
 	case "listrelayers":
 		if len(call.Params) != 1 {
-			rdr.RenderError(errors.New("invalid params count"))
-			return
+			return nil, errInvalidParamsCount
 		}
 		peers := make([]map[string]any, 0)
 		if strings.HasPrefix(r.RemoteAddr, "127.0.0.1:") {
 			id, _ := crypto.HashFromString(fmt.Sprint(call.Params[0]))
 			peers = peerNeighbors(impl.Node.Peer.GetRemoteRelayers(id))
 		}
-		rdr.RenderData(peers)
+		return peers, nil
+	case "addscanaccount", "removescanaccount", "listscanaccounts", "listscanoutputs":
+		return impl.handleAdminCall(r, call)
 	case "dumpgraphhead":
-		data, err := dumpGraphHead(impl.Node, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(data)
-		}
+		return dumpGraphHead(impl.Node, call.Params)
 	case "sendrawtransaction":
 		id, err := queueTransaction(impl.Node, call.Params)
 		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(map[string]string{"hash": id})
+			return nil, err
 		}
+		return map[string]string{"hash": id}, nil
+	case "sendrawtransactions":
+		return queueTransactions(impl.Node, call.Params)
+	case "validaterawtransaction":
+		return validateTransaction(impl.Node, call.Params)
 	case "gettransaction":
-		tx, err := getTransaction(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(tx)
-		}
+		return getTransaction(impl.Store, call.Params)
+	case "gettransactionproof":
+		return getTransactionProof(impl.Node, impl.Store, call.Params)
+	case "gettransactionstatus":
+		return getTransactionStatus(impl.Node, call.Params)
+	case "waittransaction":
+		return waitTransaction(r, impl.Node, call.Params)
+	case "listreferencingtransactions":
+		return listReferencingTransactions(impl.Store, call.Params)
 	case "getcachetransaction":
-		tx, err := getCacheTransaction(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(tx)
-		}
+		return getCacheTransaction(impl.Store, call.Params)
+	case "listcachetransactions":
+		return listCacheTransactions(impl.Store, call.Params)
+	case "listdroppedtransactions":
+		return listDroppedTransactions(impl.Store, call.Params)
 	case "getdeposittransaction":
-		tx, err := readDeposit(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(tx)
-		}
+		return readDeposit(impl.Store, call.Params)
 	case "getwithdrawalclaim":
-		tx, err := readWithdrawal(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(tx)
-		}
+		return readWithdrawal(impl.Store, call.Params)
 	case "getutxo":
-		utxo, err := getUTXO(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(utxo)
-		}
+		return getUTXO(impl.Store, call.Params)
+	case "getutxos":
+		return getUTXOs(impl.Store, call.Params)
 	case "getkey":
-		utxo, err := getGhostKey(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(utxo)
-		}
+		return getGhostKey(impl.Store, call.Params)
+	case "getkeys":
+		return getGhostKeys(impl.Store, call.Params)
 	case "getasset":
-		asset, err := readAsset(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(asset)
-		}
+		return readAsset(impl.Store, call.Params)
+	case "listassets":
+		return listAssets(impl.Store, call.Params)
+	case "listassetsupply":
+		return listAssetSupply(impl.Store, call.Params)
+	case "listtransactionsbyasset":
+		return listTransactionsByAsset(impl.Store, call.Params)
 	case "getsnapshot":
-		snap, err := getSnapshot(impl.Node, impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(snap)
-		}
+		return getSnapshot(impl.Node, impl.Store, call.Params)
+	case "getsnapshottrace":
+		return getSnapshotTrace(impl.Node, call.Params)
 	case "listsnapshots":
-		snapshots, err := listSnapshots(impl.Node, impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(snapshots)
-		}
+		return listSnapshots(impl.Node, impl.Store, call.Params)
+	case "listsnapshotsbytime":
+		return listSnapshotsByTime(impl.Node, impl.Store, call.Params)
 	case "listcustodianupdates":
-		curs, err := getCustodianHistory(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(curs)
-		}
+		return getCustodianHistory(impl.Store, call.Params)
 	case "listmintworks":
-		works, err := listMintWorks(impl.Node, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(works)
-		}
+		return listMintWorks(impl.Node, call.Params)
 	case "listmintdistributions":
-		distributions, err := listMintDistributions(impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(distributions)
-		}
+		return listMintDistributions(impl.Store, call.Params)
 	case "listallnodes":
-		nodes, err := listAllNodes(impl.Store, impl.Node, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(nodes)
-		}
+		return listAllNodes(impl.Store, impl.Node, call.Params)
 	case "getroundbynumber":
-		round, err := getRoundByNumber(impl.Node, impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(round)
-		}
+		return getRoundByNumber(impl.Node, impl.Store, call.Params)
 	case "getroundbyhash":
-		round, err := getRoundByHash(impl.Node, impl.Store, call.Params)
-		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(round)
-		}
+		return getRoundByHash(impl.Node, impl.Store, call.Params)
 	case "getroundlink":
 		link, err := getRoundLink(impl.Store, call.Params)
 		if err != nil {
-			rdr.RenderError(err)
-		} else {
-			rdr.RenderData(map[string]any{"link": link})
+			return nil, err
 		}
+		return map[string]any{"link": link}, nil
 	default:
-		rdr.RenderError(fmt.Errorf("invalid method %s", call.Method))
+		return nil, fmt.Errorf("%w %s", errInvalidMethod, call.Method)
 	}
 }
 
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MixinNetwork/mixin/common"
+)
+
+const (
+	jsonRPCVersion      = "2.0"
+	maxJSONRPCBatchSize = 100
+
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+	jsonRPCInternalError  = -32603
+	jsonRPCServerError    = -32000
+)
+
+type jsonRPCCall struct {
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// isJSONRPCRequest reports whether the body opts in to JSON-RPC 2.0, either
+// as a batch array or as an object carrying the jsonrpc member. Any other
+// body keeps the legacy data and error envelope.
+func isJSONRPCRequest(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if len(body) == 0 {
+		return false
+	}
+	if body[0] == '[' {
+		return true
+	}
+	var probe struct {
+		Version any `json:"jsonrpc"`
+	}
+	err := json.NewDecoder(bytes.NewReader(body)).Decode(&probe)
+	return err == nil && probe.Version != nil
+}
+
+func (impl *RPC) serveJSONRPC(w http.ResponseWriter, r *http.Request, body []byte) {
+	var res any
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if body[0] == '[' {
+		res = impl.handleJSONRPCBatch(r, body)
+	} else {
+		res = impl.handleJSONRPCSingle(r, body)
+	}
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", defaultJSONType)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (impl *RPC) handleJSONRPCSingle(r *http.Request, body []byte) any {
+	var raw json.RawMessage
+	err := decodeJSONRPCBody(body, &raw)
+	if err != nil {
+		return jsonRPCErrorResponse(nil, jsonRPCParseError, err.Error())
+	}
+	if res := impl.handleJSONRPCCall(r, raw, false); res != nil {
+		return res
+	}
+	return nil
+}
+
+func (impl *RPC) handleJSONRPCBatch(r *http.Request, body []byte) any {
+	var batch []json.RawMessage
+	err := decodeJSONRPCBody(body, &batch)
+	if err != nil {
+		return jsonRPCErrorResponse(nil, jsonRPCParseError, err.Error())
+	}
+	if len(batch) == 0 {
+		return jsonRPCErrorResponse(nil, jsonRPCInvalidRequest, "empty batch")
+	}
+	if len(batch) > maxJSONRPCBatchSize {
+		msg := fmt.Sprintf("batch size %d too large, the maximum is %d", len(batch), maxJSONRPCBatchSize)
+		return jsonRPCErrorResponse(nil, jsonRPCInvalidRequest, msg)
+	}
+
+	results := make([]map[string]any, 0, len(batch))
+	for _, raw := range batch {
+		res := impl.handleJSONRPCCall(r, raw, true)
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return results
+}
+
+// handleJSONRPCCall returns nil for a valid notification, i.e. a request
+// without an id, which is executed but not answered. A batch runs its calls
+// one after another under a single write timeout, so it rejects waittransaction.
+func (impl *RPC) handleJSONRPCCall(r *http.Request, raw json.RawMessage, batch bool) (res map[string]any) {
+	var call jsonRPCCall
+	err := json.Unmarshal(raw, &call)
+	if err != nil {
+		return jsonRPCErrorResponse(nil, jsonRPCInvalidRequest, err.Error())
+	}
+	if !validJSONRPCId(call.Id) {
+		return jsonRPCErrorResponse(nil, jsonRPCInvalidRequest, "invalid id")
+	}
+	if call.Version != jsonRPCVersion || call.Method == "" {
+		return jsonRPCErrorResponse(call.Id, jsonRPCInvalidRequest, "invalid request")
+	}
+	if batch && call.Method == "waittransaction" {
+		return jsonRPCErrorResponse(call.Id, jsonRPCInvalidRequest, "waittransaction not allowed in batch")
+	}
+
+	params, err := decodeJSONRPCParams(call.Params)
+	if err != nil {
+		return jsonRPCErrorResponse(call.Id, jsonRPCInvalidParams, err.Error())
+	}
+
+	defer func() {
+		if rcv := recover(); rcv != nil && call.Id != nil {
+			res = jsonRPCErrorResponse(call.Id, jsonRPCInternalError, "server error")
+		}
+	}()
+	data, err := impl.handleCall(r, &Call{Method: call.Method, Params: params})
+	if call.Id == nil {
+		return nil
+	}
+	if errors.Is(err, errInvalidMethod) {
+		return jsonRPCErrorResponse(call.Id, jsonRPCMethodNotFound, err.Error())
+	}
+	if err != nil {
+		code := jsonRPCServerError
+		if common.AsError(err).Code == common.ErrorCodeInvalidParams {
+			code = jsonRPCInvalidParams
+		}
+		res = jsonRPCErrorResponse(call.Id, code, err.Error())
+		res["error"].(*jsonRPCError).Data = errorDetails(err)
+		return res
+	}
+	return map[string]any{
+		"jsonrpc": jsonRPCVersion,
+		"id":      call.Id,
+		"result":  data,
+	}
+}
+
+func jsonRPCErrorResponse(id json.RawMessage, code int, msg string) map[string]any {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return map[string]any{
+		"jsonrpc": jsonRPCVersion,
+		"id":      id,
+		"error":   &jsonRPCError{Code: code, Message: msg},
+	}
+}
+
+func decodeJSONRPCBody(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	if dec.Decode(&struct{}{}) != io.EOF {
+		return errors.New("trailing data")
+	}
+	return nil
+}
+
+func decodeJSONRPCParams(raw json.RawMessage) ([]any, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return []any{}, nil
+	}
+	if raw[0] != '[' {
+		return nil, errors.New("params must be a positional array")
+	}
+	var params []any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	err := dec.Decode(&params)
+	return params, err
+}
+
+func validJSONRPCId(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	var v any
+	err := json.Unmarshal(id, &v)
+	if err != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
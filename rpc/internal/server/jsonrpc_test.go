@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRPCRequests(t *testing.T) {
+	require := require.New(t)
+	impl := &RPC{custom: &config.Custom{}}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		res := httptest.NewRecorder()
+		impl.ServeHTTP(res, req)
+		return res
+	}
+
+	res := post(`{"jsonrpc":"2.0","id":1,"method":"unknown","params":[]}`)
+	var single map[string]any
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &single))
+	require.Equal("2.0", single["jsonrpc"])
+	require.Equal(float64(1), single["id"])
+	require.Equal(float64(jsonRPCMethodNotFound), single["error"].(map[string]any)["code"])
+
+	res = post(`{"jsonrpc":"2.0","id":"a","method":"getutxo","params":{"hash":"x"}}`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &single))
+	require.Equal("a", single["id"])
+	require.Equal(float64(jsonRPCInvalidParams), single["error"].(map[string]any)["code"])
+
+	res = post(`{"jsonrpc":"2.0","id":2,"method":"getutxo","params":[]}`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &single))
+	require.Equal(float64(jsonRPCInvalidParams), single["error"].(map[string]any)["code"])
+	require.Equal("invalid params count", single["error"].(map[string]any)["message"])
+	details := single["error"].(map[string]any)["data"].(map[string]any)
+	require.Equal("invalid_params", details["code"])
+	require.Equal("request", details["category"])
+	require.Equal(false, details["retryable"])
+
+	res = post(`[{"jsonrpc":"2.0","id":3,"method":"getutxo"`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &single))
+	require.Nil(single["id"])
+	require.Equal(float64(jsonRPCParseError), single["error"].(map[string]any)["code"])
+
+	res = post(`[]`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &single))
+	require.Equal(float64(jsonRPCInvalidRequest), single["error"].(map[string]any)["code"])
+
+	res = post(`[{"jsonrpc":"2.0","method":"unknown"},{"jsonrpc":"2.0","method":"getutxo"}]`)
+	require.Equal(http.StatusNoContent, res.Code)
+	require.Equal(0, res.Body.Len())
+
+	res = post(`[{"jsonrpc":"2.0","id":1,"method":"unknown"},{"jsonrpc":"2.0","method":"unknown"},{"jsonrpc":"1.0","id":2,"method":"getutxo"},{"jsonrpc":"2.0","id":{},"method":"getutxo"}]`)
+	var batch []map[string]any
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &batch))
+	require.Len(batch, 3)
+	require.Equal(float64(1), batch[0]["id"])
+	require.Equal(float64(jsonRPCMethodNotFound), batch[0]["error"].(map[string]any)["code"])
+	require.Equal(float64(2), batch[1]["id"])
+	require.Equal(float64(jsonRPCInvalidRequest), batch[1]["error"].(map[string]any)["code"])
+	require.Nil(batch[2]["id"])
+	require.Equal(float64(jsonRPCInvalidRequest), batch[2]["error"].(map[string]any)["code"])
+
+	hash := strings.Repeat("0", 64)
+	wait := `{"jsonrpc":"2.0","id":%d,"method":"waittransaction","params":["` + hash + `",8]}`
+	start := time.Now()
+	res = post("[" + fmt.Sprintf(wait, 4) + "," + fmt.Sprintf(wait, 5) + "]")
+	require.Less(time.Since(start), time.Second)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &batch))
+	require.Len(batch, 2)
+	for i, r := range batch {
+		require.Equal(float64(4+i), r["id"])
+		require.Equal(float64(jsonRPCInvalidRequest), r["error"].(map[string]any)["code"])
+		require.Equal("waittransaction not allowed in batch", r["error"].(map[string]any)["message"])
+	}
+
+	res = post(`{"id":"legacy","method":"unknown","params":[]}`)
+	var legacy map[string]any
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &legacy))
+	require.Equal("legacy", legacy["id"])
+	require.Equal("invalid method unknown", legacy["error"])
+	require.Nil(legacy["jsonrpc"])
+
+	res = post(`{"method":"getutxo","params":[]}`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &legacy))
+	require.Equal("invalid params count", legacy["error"])
+	details = legacy["details"].(map[string]any)
+	require.Equal("invalid_params", details["code"])
+	require.Equal("request", details["category"])
+	require.Nil(details["input"])
+
+	res = post(`{"method":"validaterawtransaction","params":["zz"]}`)
+	require.Nil(json.Unmarshal(res.Body.Bytes(), &legacy))
+	require.Equal("invalid_encoding", legacy["details"].(map[string]any)["code"])
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel"
+	"github.com/MixinNetwork/mixin/storage"
+)
+
+// metricsWriter writes the Prometheus text exposition format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one value of name, with labels as key and value pairs.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=\"%s\"", labels[i], escapeMetricLabel(labels[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
+
+func (w *metricsWriter) gauge(name, help string, value float64, labels ...string) {
+	w.family(name, "gauge", help)
+	w.sample(name, value, labels...)
+}
+
+func (w *metricsWriter) counter(name, help string, value float64, labels ...string) {
+	w.family(name, "counter", help)
+	w.sample(name, value, labels...)
+}
+
+func escapeMetricLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+func (impl *RPC) handleMetrics(w http.ResponseWriter) {
+	mw := &metricsWriter{}
+	err := writeMetrics(mw, impl.Store, impl.Node)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(mw.buf.Bytes())
+}
+
+func writeMetrics(w *metricsWriter, store storage.Store, node *kernel.Node) error {
+	w.gauge("mixin_info", "Node identity and version, always 1.", 1,
+		"network", node.NetworkId().String(), "node", node.IdForNetwork.String(), "version", config.BuildVersion)
+	w.gauge("mixin_uptime_seconds", "Seconds since the node started.", node.Uptime().Seconds())
+	w.gauge("mixin_graph_timestamp_seconds", "Timestamp of the local graph.", float64(node.GraphTimestamp)/float64(time.Second))
+	w.gauge("mixin_graph_topology", "Topological order of the last finalized snapshot.", float64(node.TopologicalOrder()))
+	w.gauge("mixin_graph_sps", "Snapshots per second finalized recently.", node.SPS())
+	w.gauge("mixin_graph_spt", "Snapshots per transaction finalized recently.", node.SPT())
+	w.gauge("mixin_graph_tps", "Transactions per second finalized recently.", node.TPS())
+
+	caches, finals, state := node.QueueState()
+	w.gauge("mixin_queue_caches", "Actions waiting in the cache queues of all chains.", float64(caches))
+	w.gauge("mixin_queue_finals", "Actions waiting in the final queues of all chains.", float64(finals))
+	w.family("mixin_chain_queue_size", "gauge", "Actions waiting in the queues of a chain.")
+	for _, id := range slices.Sorted(maps.Keys(state)) {
+		w.sample("mixin_chain_queue_size", float64(state[id][0]), "chain", id, "queue", "cache")
+		w.sample("mixin_chain_queue_size", float64(state[id][1]), "chain", id, "queue", "final")
+	}
+
+	cacheMap, finalMap := node.LoadRoundGraph()
+	w.family("mixin_chain_round", "gauge", "Latest round number of a chain.")
+	for _, id := range sortedHashes(slices.Collect(maps.Keys(cacheMap))) {
+		w.sample("mixin_chain_round", float64(cacheMap[id].Number), "chain", id.String(), "state", "cache")
+	}
+	for _, id := range sortedHashes(slices.Collect(maps.Keys(finalMap))) {
+		w.sample("mixin_chain_round", float64(finalMap[id].Number), "chain", id.String(), "state", "final")
+	}
+
+	w.family("mixin_p2p_messages_total", "counter", "Peer messages sent or received by type, counted when p2p.metric is enabled.")
+	metrics := node.Peer.Metric()
+	for _, direction := range slices.Sorted(maps.Keys(metrics)) {
+		counters := metrics[direction].Counters()
+		for _, typ := range slices.Sorted(maps.Keys(counters)) {
+			w.sample("mixin_p2p_messages_total", float64(counters[typ]), "direction", direction, "type", typ)
+		}
+	}
+
+	cm := node.GetCacheStore().Metrics
+	w.counter("mixin_cache_store_hits_total", "Memory cache store lookups found, counted when rpc.metrics is enabled.", float64(cm.Hits()))
+	w.counter("mixin_cache_store_misses_total", "Memory cache store lookups missed, counted when rpc.metrics is enabled.", float64(cm.Misses()))
+	w.gauge("mixin_cache_store_hit_ratio", "Ratio of memory cache store lookups found.", cm.Ratio())
+
+	w.family("mixin_cosi_phase_duration_seconds", "histogram", "Duration of the CoSi phases of the snapshots proposed or verified by the node.")
+	latencies := node.CosiLatencies()
+	for _, phase := range slices.Sorted(maps.Keys(latencies)) {
+		h := latencies[phase]
+		for i, b := range kernel.LatencyBuckets {
+			le := strconv.FormatFloat(b, 'g', -1, 64)
+			w.sample("mixin_cosi_phase_duration_seconds_bucket", float64(h.Buckets[i]), "phase", phase, "le", le)
+		}
+		w.sample("mixin_cosi_phase_duration_seconds_bucket", float64(h.Count), "phase", phase, "le", "+Inf")
+		w.sample("mixin_cosi_phase_duration_seconds_sum", h.Sum, "phase", phase)
+		w.sample("mixin_cosi_phase_duration_seconds_count", float64(h.Count), "phase", phase)
+	}
+
+	sizes, err := store.DatabaseSizes()
+	if err != nil {
+		return err
+	}
+	w.family("mixin_badger_size_bytes", "gauge", "Size of the badger LSM and value log files.")
+	for _, db := range slices.Sorted(maps.Keys(sizes)) {
+		w.sample("mixin_badger_size_bytes", float64(sizes[db][0]), "db", db, "kind", "lsm")
+		w.sample("mixin_badger_size_bytes", float64(sizes[db][1]), "db", db, "kind", "vlog")
+	}
+
+	pool, err := node.PoolSize()
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseFloat(pool.String(), 64)
+	if err != nil {
+		return err
+	}
+	w.gauge("mixin_mint_batch", "Batch of the last mint distribution.", float64(node.LastMint))
+	w.gauge("mixin_mint_pool", "XIN left in the mint pool.", amount)
+
+	var cids []crypto.Hash
+	for _, n := range node.NodesListWithoutState(node.GraphTimestamp, false) {
+		switch n.State {
+		case common.NodeStateAccepted, common.NodeStatePledging:
+			cids = append(cids, n.IdForNetwork)
+		}
+	}
+	cids = sortedHashes(cids)
+	offsets, err := store.ListWorkOffsets(cids)
+	if err != nil {
+		return err
+	}
+	works, err := store.ListNodeWorks(cids, uint32(node.GraphTimestamp/uint64(time.Hour*24)))
+	if err != nil {
+		return err
+	}
+	w.family("mixin_node_work_offset", "gauge", "Work aggregator offset of a consensus node.")
+	for _, id := range cids {
+		w.sample("mixin_node_work_offset", float64(offsets[id]), "node", id.String())
+	}
+	w.family("mixin_node_works", "gauge", "Snapshots led or signed by a consensus node in the current mint day.")
+	for _, id := range cids {
+		w.sample("mixin_node_works", float64(works[id][0]), "node", id.String(), "kind", "lead")
+		w.sample("mixin_node_works", float64(works[id][1]), "node", id.String(), "kind", "sign")
+	}
+	return nil
+}
+
+func sortedHashes(hashes []crypto.Hash) []crypto.Hash {
+	slices.SortFunc(hashes, func(a, b crypto.Hash) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	return hashes
+}
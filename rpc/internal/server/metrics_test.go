@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsWriter(t *testing.T) {
+	require := require.New(t)
+
+	w := &metricsWriter{}
+	w.gauge("mixin_uptime_seconds", "Seconds since the node started.", 1.5)
+	w.family("mixin_chain_round", "gauge", "Latest round number of a chain.")
+	w.sample("mixin_chain_round", 12, "chain", "a\"b\\c\n", "state", "cache")
+	w.counter("mixin_cache_store_hits_total", "Memory cache store lookups found.", 3)
+	require.Equal(`# HELP mixin_uptime_seconds Seconds since the node started.
+# TYPE mixin_uptime_seconds gauge
+mixin_uptime_seconds 1.5
+# HELP mixin_chain_round Latest round number of a chain.
+# TYPE mixin_chain_round gauge
+mixin_chain_round{chain="a\"b\\c\n",state="cache"} 12
+# HELP mixin_cache_store_hits_total Memory cache store lookups found.
+# TYPE mixin_cache_store_hits_total counter
+mixin_cache_store_hits_total 3
+`, w.buf.String())
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -14,7 +13,7 @@ import (
 
 func listAllNodes(store storage.Store, node *kernel.Node, params []any) ([]map[string]any, error) {
 	if len(params) != 2 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	threshold, err := strconv.ParseUint(fmt.Sprint(params[0]), 10, 64)
 	if err != nil {
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel"
+	"github.com/MixinNetwork/mixin/storage"
+)
+
+func getTransactionProof(node *kernel.Node, store storage.Store, params []any) (map[string]any, error) {
+	if len(params) != 2 {
+		return nil, errInvalidParamsCount
+	}
+	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if since < node.Epoch {
+		since = node.Epoch
+	}
+
+	tx, snap, err := finalizationToMap(node, store, hash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	if snap.Timestamp <= since {
+		return nil, fmt.Errorf("snapshot %s at %d before %d", snap.Hash, snap.Timestamp, since)
+	}
+
+	history := make([]map[string]any, 0)
+	for _, n := range store.ReadAllNodes(snap.Timestamp-1, true) {
+		if n.Timestamp <= since {
+			continue
+		}
+		op, _, err := finalizationToMap(node, store, n.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		if op == nil {
+			return nil, fmt.Errorf("node operation %s not finalized", n.Transaction)
+		}
+		history = append(history, op)
+	}
+
+	tx["network"] = node.NetworkId()
+	tx["history"] = history
+	return tx, nil
+}
+
+func finalizationToMap(node *kernel.Node, store storage.Store, hash crypto.Hash) (map[string]any, *common.SnapshotWithTopologicalOrder, error) {
+	tx, sh, err := store.ReadTransaction(hash)
+	if err != nil || tx == nil {
+		return nil, nil, err
+	}
+	if sh == "" {
+		return nil, nil, fmt.Errorf("transaction %s not finalized", hash)
+	}
+	snapHash, err := crypto.HashFromString(sh)
+	if err != nil {
+		return nil, nil, err
+	}
+	snap, err := store.ReadSnapshot(snapHash)
+	if err != nil || snap == nil {
+		return nil, nil, fmt.Errorf("snapshot %s not found %v", snapHash, err)
+	}
+
+	cids, publics := node.SnapshotConsensusKeys(snap.Snapshot)
+	consensus := make([]map[string]any, len(cids))
+	for i, id := range cids {
+		consensus[i] = map[string]any{
+			"id":  id,
+			"key": publics[i],
+		}
+	}
+	return map[string]any{
+		"transaction": hex.EncodeToString(tx.Marshal()),
+		"snapshot":    hex.EncodeToString(snap.VersionedMarshal()),
+		"consensus":   consensus,
+	}, snap, nil
+}
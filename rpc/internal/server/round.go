@@ -13,7 +13,7 @@ import (
 
 func getRoundLink(store storage.Store, params []any) (uint64, error) {
 	if len(params) != 2 {
-		return 0, errors.New("invalid params count")
+		return 0, errInvalidParamsCount
 	}
 	from, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -28,7 +28,7 @@ func getRoundLink(store storage.Store, params []any) (uint64, error) {
 
 func getRoundByNumber(kn *kernel.Node, store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 2 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	node, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -85,7 +85,7 @@ func getRoundByNumber(kn *kernel.Node, store storage.Store, params []any) (map[s
 
 func getRoundByHash(kn *kernel.Node, store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
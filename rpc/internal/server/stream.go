@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+)
+
+const (
+	defaultEventStreamType = "text/event-stream"
+
+	streamBatchSize         = 100
+	streamKeepAliveInterval = 15 * time.Second
+)
+
+type snapshotStreamQuery struct {
+	offset uint64
+	sig    bool
+	tx     bool
+}
+
+// handleSnapshotStream pushes finalized snapshots as server-sent events. Each
+// event id is the snapshot topology, so a reconnecting client resumes with the
+// Last-Event-ID header, or with since set to the last received topology plus one.
+func (impl *RPC) handleSnapshotStream(w http.ResponseWriter, r *http.Request, rdr *Render) {
+	query, err := parseSnapshotStreamQuery(r)
+	if err != nil {
+		rdr.RenderError(fmt.Errorf("bad request %s", err.Error()))
+		return
+	}
+	rc := http.NewResponseController(w)
+	err = rc.SetReadDeadline(time.Time{})
+	if err == nil {
+		err = rc.SetWriteDeadline(time.Time{})
+	}
+	if err != nil {
+		rdr.RenderError(err)
+		return
+	}
+
+	w.Header().Set("Content-Type", defaultEventStreamType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	offset := query.offset
+	for {
+		written := impl.Node.TopologyWritten()
+		snapshots, transactions, err := impl.readStreamSnapshots(offset, query.tx)
+		if err != nil {
+			writeStreamEvent(w, "error", "", map[string]any{"error": err.Error()})
+			return
+		}
+		items := snapshotsToMap(impl.Node, snapshots, transactions, query.sig)
+		for i, s := range snapshots {
+			err = writeStreamEvent(w, "snapshot", fmt.Sprint(s.TopologicalOrder), items[i])
+			if err != nil {
+				return
+			}
+			offset = s.TopologicalOrder + 1
+		}
+		if rc.Flush() != nil {
+			return
+		}
+		if len(snapshots) == streamBatchSize {
+			continue
+		}
+
+		timer := time.NewTimer(streamKeepAliveInterval)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-written:
+			timer.Stop()
+		case <-timer.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (impl *RPC) readStreamSnapshots(offset uint64, tx bool) ([]*common.SnapshotWithTopologicalOrder, [][]*common.VersionedTransaction, error) {
+	if tx {
+		return impl.Store.ReadSnapshotWithTransactionsSinceTopology(offset, streamBatchSize)
+	}
+	snapshots, err := impl.Store.ReadSnapshotsSinceTopology(offset, streamBatchSize)
+	return snapshots, nil, err
+}
+
+func writeStreamEvent(w http.ResponseWriter, event, id string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	if id != "" {
+		_, err = fmt.Fprintf(w, "id: %s\n", id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+func parseSnapshotStreamQuery(r *http.Request) (*snapshotStreamQuery, error) {
+	var query snapshotStreamQuery
+	values := r.URL.Query()
+	if v := values.Get("since"); v != "" {
+		offset, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		query.offset = offset
+	}
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		last, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		query.offset = last + 1
+	}
+	for _, f := range []struct {
+		name  string
+		value *bool
+	}{{"sig", &query.sig}, {"tx", &query.tx}} {
+		v := values.Get(f.name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+		*f.value = b
+	}
+	return &query, nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel"
+	"github.com/MixinNetwork/mixin/storage"
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotStreamQuery(t *testing.T) {
+	require := require.New(t)
+
+	req := httptest.NewRequest("GET", "/snapshots/stream?since=12&sig=true&tx=1", nil)
+	query, err := parseSnapshotStreamQuery(req)
+	require.Nil(err)
+	require.Equal(uint64(12), query.offset)
+	require.True(query.sig)
+	require.True(query.tx)
+
+	req.Header.Set("Last-Event-ID", "30")
+	query, err = parseSnapshotStreamQuery(req)
+	require.Nil(err)
+	require.Equal(uint64(31), query.offset)
+
+	req = httptest.NewRequest("GET", "/snapshots/stream", nil)
+	query, err = parseSnapshotStreamQuery(req)
+	require.Nil(err)
+	require.Equal(uint64(0), query.offset)
+	require.False(query.sig)
+	require.False(query.tx)
+
+	for _, target := range []string{
+		"/snapshots/stream?since=-1",
+		"/snapshots/stream?tx=maybe",
+	} {
+		_, err = parseSnapshotStreamQuery(httptest.NewRequest("GET", target, nil))
+		require.NotNil(err)
+	}
+}
+
+func TestSnapshotStreamRequiresConfig(t *testing.T) {
+	impl := &RPC{custom: &config.Custom{}}
+	req := httptest.NewRequest("GET", "/snapshots/stream", nil)
+	res := httptest.NewRecorder()
+	impl.ServeHTTP(res, req)
+	require.Contains(t, res.Body.String(), "bad request GET /snapshots/stream")
+}
+
+func TestSnapshotStream(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(dir+"/config.toml", []byte(`[node]
+signer-key = "56a7904a2dfd71c397bb48584033d8cb6ddcde9b46b7d91f07d2ede061723a0b"
+consensus-only = true
+memory-cache-size = 16
+cache-ttl = 7200
+ring-cache-size = 4096
+ring-final-size = 16384
+[network]
+listener = "mixin-node.example.com:7239"
+[rpc]
+snapshot-stream = true`), 0644)
+	require.Nil(err)
+	custom, err := config.Initialize(dir + "/config.toml")
+	require.Nil(err)
+	require.True(custom.RPC.SnapshotStream)
+	gns, err := common.ReadGenesis("../../../config/genesis.json")
+	require.Nil(err)
+	cache, err := ristretto.NewCache(&ristretto.Config[[]byte, any]{
+		NumCounters: 1e7,
+		MaxCost:     1 << 30,
+		BufferItems: 64,
+	})
+	require.Nil(err)
+	store, err := storage.NewBadgerStore(custom, dir)
+	require.Nil(err)
+	defer store.Close()
+	node, err := kernel.SetupNode(custom, store, cache, gns)
+	require.Nil(err)
+
+	impl := &RPC{Store: store, Node: node, custom: custom}
+	done := make(chan struct{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		impl.ServeHTTP(w, r)
+		done <- struct{}{}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := subscribeSnapshotStream(t, ctx, server.URL+"/snapshots/stream?since=26", "")
+	require.Equal("26", readStreamEventId(t, events))
+	require.Equal("27", readStreamEventId(t, events))
+
+	snaps, err := store.ReadSnapshotsSinceTopology(0, 1)
+	require.Nil(err)
+	nodeId := snaps[0].NodeId
+	amount := common.NewIntegerFromString("89.87671232")
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddUniversalMintInput(uint64(1706), amount)
+	addr, err := common.NewAddressFromString("XINYneY2gomSHxkYF62pxbNdwcdhcayxJRAeyUanJR611q5NWg4QebfFhEF3Me8qCHR8g8tD6QHPHD8naZnnn3GdRrhhiuxi")
+	require.Nil(err)
+	tx.AddScriptOutput([]*common.Address{&addr}, common.NewThresholdScript(1), amount, make([]byte, 64))
+	ver := tx.AsVersioned()
+	require.Nil(ver.LockInputs(store, false))
+	require.Nil(store.WriteTransaction(ver))
+	round, err := store.ReadRound(nodeId)
+	require.Nil(err)
+	snap := &common.Snapshot{
+		Version:     common.SnapshotVersionCommonEncoding,
+		NodeId:      nodeId,
+		RoundNumber: round.Number,
+		Timestamp:   round.Timestamp + 1,
+		Signature:   &crypto.CosiSignature{Mask: 1},
+		References:  round.References,
+	}
+	snap.AddTransaction(ver.PayloadHash())
+	snap.Hash = snap.PayloadHash()
+	node.TopoWrite(snap, []crypto.Hash{nodeId})
+	require.Equal("28", readStreamEventId(t, events))
+
+	resumed := subscribeSnapshotStream(t, ctx, server.URL+"/snapshots/stream?since=0", "26")
+	require.Equal("27", readStreamEventId(t, resumed))
+	require.Equal("28", readStreamEventId(t, resumed))
+
+	cancel()
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.Fail("stream handler not returned after the client disconnected")
+		}
+	}
+}
+
+func subscribeSnapshotStream(t *testing.T, ctx context.Context, url, last string) <-chan string {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	require.Nil(t, err)
+	if last != "" {
+		req.Header.Set("Last-Event-ID", last)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, defaultEventStreamType, res.Header.Get("Content-Type"))
+
+	events := make(chan string, 64)
+	go func() {
+		defer res.Body.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(res.Body)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			if id, found := strings.CutPrefix(scanner.Text(), "id: "); found {
+				events <- id
+			}
+		}
+	}()
+	return events
+}
+
+func readStreamEventId(t *testing.T, events <-chan string) string {
+	select {
+	case id := <-events:
+		return id
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "stream event timeout")
+		return ""
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"github.com/MixinNetwork/mixin/kernel"
+)
+
+func getSupply(node *kernel.Node) (map[string]any, error) {
+	supply, err := node.XINSupply()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"timestamp":   supply.Timestamp,
+		"maximum":     supply.Maximum,
+		"total":       supply.Total,
+		"circulating": supply.Circulating,
+		"kernel":      supply.Kernel,
+		"pool":        supply.Pool,
+		"pledged":     supply.Pledged,
+		"burned":      supply.Burned,
+		"indexed":     supply.Indexed,
+	}, nil
+}
@@ -1,11 +1,12 @@
 package server
 
 import (
+	"context"
 	"encoding/hex"
-	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -14,9 +15,13 @@ import (
 	"github.com/MixinNetwork/mixin/storage"
 )
 
+// maxWaitTransactionTimeout keeps waittransaction within the write timeout
+// of the server.
+const maxWaitTransactionTimeout = 8 * time.Second
+
 func getCacheTransaction(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -31,35 +36,268 @@ func getCacheTransaction(store storage.Store, params []any) (map[string]any, err
 	return data, nil
 }
 
+func listCacheTransactions(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 3 && len(params) != 4 {
+		return nil, errInvalidParamsCount
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[0]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+	conflicts, err := strconv.ParseBool(fmt.Sprint(params[2]))
+	if err != nil {
+		return nil, err
+	}
+	var after crypto.Hash
+	if len(params) == 4 {
+		after, err = crypto.HashFromString(fmt.Sprint(params[3]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !conflicts {
+		queued, txs, err := store.CacheListQueuedTransactions(since, after, int(count))
+		if err != nil {
+			return nil, err
+		}
+		result := make([]map[string]any, len(txs))
+		for i, tx := range txs {
+			result[i] = cacheTransactionToMap(queued[i], tx)
+		}
+		return result, nil
+	}
+
+	queued, txs, others, err := store.CacheListConflictTransactions(since, after, int(count))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(txs))
+	for i, tx := range txs {
+		result[i] = cacheTransactionToMap(queued[i], tx)
+		result[i]["conflicts"] = others[i]
+	}
+	return result, nil
+}
+
+func cacheTransactionToMap(queued uint64, tx *common.VersionedTransaction) map[string]any {
+	inputs := make([]map[string]any, 0)
+	for _, in := range tx.Inputs {
+		if in.Hash.HasValue() {
+			inputs = append(inputs, map[string]any{
+				"hash":  in.Hash,
+				"index": in.Index,
+			})
+		}
+	}
+	return map[string]any{
+		"hash":   tx.PayloadHash(),
+		"type":   tx.TransactionType(),
+		"asset":  tx.Asset,
+		"size":   len(tx.PayloadMarshal()),
+		"queued": queued,
+		"inputs": inputs,
+	}
+}
+
 func queueTransaction(node *kernel.Node, params []any) (string, error) {
 	if len(params) != 1 {
-		return "", errors.New("invalid params count")
+		return "", errInvalidParamsCount
 	}
 	raw, err := hex.DecodeString(fmt.Sprint(params[0]))
 	if err != nil {
-		return "", err
+		return "", common.NewError(common.ErrorCodeInvalidEncoding, err)
 	}
 	ver, err := common.UnmarshalVersionedTransaction(raw)
 	if err != nil {
-		return "", err
+		return "", common.NewError(common.ErrorCodeInvalidEncoding, err)
 	}
 	for attempt := range 3 {
 		hash, err := node.QueueTransaction(ver)
 		if err == nil {
 			return hash, nil
 		}
-		if strings.Contains(err.Error(), "Transaction Conflict") {
+		if common.AsError(err).Code == common.ErrorCodeNodeBusy {
 			time.Sleep(time.Duration(attempt+1) * 10 * time.Millisecond)
 			continue
 		}
 		return hash, err
 	}
-	return "", errors.New("transaction conflict retry limit reached")
+	return "", common.NewErrorf(common.ErrorCodeNodeBusy, "transaction conflict retry limit reached")
+}
+
+func queueTransactions(node *kernel.Node, params []any) ([]map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	items, ok := params[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid raw transactions %v", params[0])
+	}
+	if len(items) > 500 {
+		return nil, fmt.Errorf("too many raw transactions %d, the maximum is 500", len(items))
+	}
+
+	result := make([]map[string]any, len(items))
+	for i, item := range items {
+		res := map[string]any{}
+		hash, err := queueTransaction(node, []any{item})
+		if hash != "" {
+			res["hash"] = hash
+		}
+		if err != nil {
+			res["error"] = err.Error()
+			res["details"] = errorDetails(err)
+		}
+		result[i] = res
+	}
+	return result, nil
+}
+
+func validateTransaction(node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	raw, err := hex.DecodeString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInvalidEncoding, err)
+	}
+	ver, err := common.UnmarshalVersionedTransaction(raw)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInvalidEncoding, err)
+	}
+
+	result := map[string]any{
+		"hash": ver.PayloadHash(),
+		"type": ver.TransactionType(),
+		"size": len(ver.PayloadMarshal()),
+		"extra": map[string]any{
+			"size":  len(ver.Extra),
+			"limit": ver.GetExtraLimit(),
+			"price": common.ExtraStoragePrice(len(ver.Extra)),
+		},
+		"valid": true,
+	}
+	err = node.ValidateTransaction(ver)
+	if err != nil {
+		result["valid"] = false
+		result["error"] = err.Error()
+		result["details"] = errorDetails(err)
+	}
+	return result, nil
+}
+
+func getTransactionStatus(node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	status, err := node.ReadTransactionStatus(hash)
+	if err != nil {
+		return nil, err
+	}
+	return transactionStatusToMap(hash, status), nil
+}
+
+func waitTransaction(r *http.Request, node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 2 {
+		return nil, errInvalidParamsCount
+	}
+	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), waitTransactionTimeout(seconds))
+	defer cancel()
+	status, err := node.WaitTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return transactionStatusToMap(hash, status), nil
+}
+
+// waitTransactionTimeout caps the seconds before the conversion, which would
+// overflow to a negative duration for a huge value.
+func waitTransactionTimeout(seconds uint64) time.Duration {
+	return time.Duration(min(seconds, uint64(maxWaitTransactionTimeout/time.Second))) * time.Second
+}
+
+func transactionStatusToMap(hash crypto.Hash, status *kernel.TransactionStatus) map[string]any {
+	result := map[string]any{
+		"hash":  hash,
+		"state": status.State,
+	}
+	if status.Snapshot.HasValue() {
+		result["snapshot"] = status.Snapshot
+	}
+	if status.State == kernel.TransactionStateFinalized {
+		result["topology"] = status.Topology
+	}
+	if status.Timestamp > 0 {
+		result["timestamp"] = status.Timestamp
+	}
+	if d := status.Drop; d != nil {
+		if d.Origin != "" {
+			result["origin"] = d.Origin
+		}
+		err := d.Error()
+		result["error"] = err.Error()
+		result["details"] = errorDetails(err)
+	}
+	return result
+}
+
+func listDroppedTransactions(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 2 {
+		return nil, errInvalidParamsCount
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[0]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+
+	drops, err := store.CacheListTransactionDrops(since, int(count))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(drops))
+	for i, d := range drops {
+		err := d.Error()
+		result[i] = map[string]any{
+			"hash":      d.Hash,
+			"timestamp": d.Timestamp,
+			"origin":    d.Origin,
+			"error":     err.Error(),
+			"details":   errorDetails(err),
+		}
+	}
+	return result, nil
 }
 
 func getTransaction(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -79,7 +317,7 @@ func getTransaction(store storage.Store, params []any) (map[string]any, error) {
 
 func getUTXO(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 2 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -93,7 +331,51 @@ func getUTXO(store storage.Store, params []any) (map[string]any, error) {
 	if err != nil || utxo == nil {
 		return nil, err
 	}
+	return utxoToMap(hash, uint(index), utxo), nil
+}
 
+func getUTXOs(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	items, ok := params[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid outputs %v", params[0])
+	}
+	if len(items) > 500 {
+		return nil, fmt.Errorf("too many outputs %d, the maximum is 500", len(items))
+	}
+	inputs := make([]*common.Input, len(items))
+	for i, item := range items {
+		out, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid output %v", item)
+		}
+		hash, err := crypto.HashFromString(fmt.Sprint(out["hash"]))
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.ParseUint(fmt.Sprint(out["index"]), 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = &common.Input{Hash: hash, Index: uint(index)}
+	}
+
+	utxos, err := store.ReadUTXOLocks(inputs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, len(utxos))
+	for i, utxo := range utxos {
+		if utxo != nil {
+			result[i] = utxoToMap(inputs[i].Hash, inputs[i].Index, utxo)
+		}
+	}
+	return result, nil
+}
+
+func utxoToMap(hash crypto.Hash, index uint, utxo *common.UTXOWithLock) map[string]any {
 	output := map[string]any{
 		"type":   utxo.Type,
 		"hash":   hash,
@@ -112,12 +394,12 @@ func getUTXO(store storage.Store, params []any) (map[string]any, error) {
 	if utxo.LockHash.HasValue() {
 		output["lock"] = utxo.LockHash
 	}
-	return output, nil
+	return output
 }
 
 func getGhostKey(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	key, err := crypto.KeyFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -134,9 +416,76 @@ func getGhostKey(store storage.Store, params []any) (map[string]any, error) {
 	return res, nil
 }
 
+func getGhostKeys(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	items, ok := params[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid ghost keys %v", params[0])
+	}
+	if len(items) > 500 {
+		return nil, fmt.Errorf("too many ghost keys %d, the maximum is 500", len(items))
+	}
+	keys := make([]crypto.Key, len(items))
+	for i, item := range items {
+		key, err := crypto.KeyFromString(fmt.Sprint(item))
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+
+	result := make([]map[string]any, len(keys))
+	for i, key := range keys {
+		res, err := readGhostKeyOutput(store, key)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = res
+	}
+	return result, nil
+}
+
+func readGhostKeyOutput(store storage.Store, key crypto.Key) (map[string]any, error) {
+	res := map[string]any{"key": key, "transaction": nil}
+	by, err := store.ReadGhostKeyLock(key)
+	if err != nil || by == nil {
+		return res, err
+	}
+	res["transaction"] = by.String()
+	tx, snap, err := store.ReadTransaction(*by)
+	if err != nil || tx == nil {
+		return res, err
+	}
+	index := slices.IndexFunc(tx.Outputs, func(out *common.Output) bool {
+		return slices.ContainsFunc(out.Keys, func(k *crypto.Key) bool { return *k == key })
+	})
+	if index < 0 {
+		return res, nil
+	}
+	res["index"] = index
+	res["spent"] = false
+	if snap == "" {
+		return res, nil
+	}
+	res["snapshot"] = snap
+	utxo, err := store.ReadUTXOLock(*by, uint(index))
+	if err != nil || utxo == nil || !utxo.LockHash.HasValue() {
+		return res, err
+	}
+	_, final, err := store.ReadTransaction(utxo.LockHash)
+	if err != nil {
+		return nil, err
+	}
+	res["lock"] = utxo.LockHash
+	res["spent"] = final != ""
+	return res, nil
+}
+
 func getSnapshot(node *kernel.Node, store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
@@ -157,9 +506,40 @@ func getSnapshot(node *kernel.Node, store storage.Store, params []any) (map[stri
 	return snapshotToMap(node, snap, txs, true), nil
 }
 
+func getSnapshotTrace(node *kernel.Node, params []any) (map[string]any, error) {
+	if len(params) != 1 {
+		return nil, errInvalidParamsCount
+	}
+	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	trace := node.CosiTrace(hash)
+	if trace == nil {
+		return nil, nil
+	}
+	events := make([]map[string]any, len(trace.Events))
+	for i, e := range trace.Events {
+		events[i] = map[string]any{
+			"phase":     e.Phase,
+			"peer":      e.Peer,
+			"timestamp": e.Timestamp,
+		}
+		if e.Late > 0 {
+			events[i]["late"] = e.Late
+		}
+	}
+	return map[string]any{
+		"snapshot": trace.Snapshot,
+		"node":     trace.NodeId,
+		"round":    trace.Round,
+		"events":   events,
+	}, nil
+}
+
 func listSnapshots(node *kernel.Node, store storage.Store, params []any) ([]map[string]any, error) {
 	if len(params) != 4 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	offset, err := strconv.ParseUint(fmt.Sprint(params[0]), 10, 64)
 	if err != nil {
@@ -186,6 +566,118 @@ func listSnapshots(node *kernel.Node, store storage.Store, params []any) ([]map[
 	return snapshotsToMap(node, snapshots, nil, sig), err
 }
 
+func listSnapshotsByTime(node *kernel.Node, store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 5 {
+		return nil, errInvalidParamsCount
+	}
+	start, err := strconv.ParseUint(fmt.Sprint(params[0]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	end, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+	sig, err := strconv.ParseBool(fmt.Sprint(params[3]))
+	if err != nil {
+		return nil, err
+	}
+	tx, err := strconv.ParseBool(fmt.Sprint(params[4]))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := store.ReadSnapshotsByTime(start, end, int(count))
+	if err != nil || !tx {
+		return snapshotsToMap(node, snapshots, nil, sig), err
+	}
+	transactions := make([][]*common.VersionedTransaction, len(snapshots))
+	for i, s := range snapshots {
+		transactions[i] = make([]*common.VersionedTransaction, len(s.Transactions))
+		for j, h := range s.Transactions {
+			ver, _, err := store.ReadTransaction(h)
+			if err != nil {
+				return nil, err
+			}
+			transactions[i][j] = ver
+		}
+	}
+	return snapshotsToMap(node, snapshots, transactions, sig), nil
+}
+
+func listTransactionsByAsset(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 3 {
+		return nil, errInvalidParamsCount
+	}
+	id, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+
+	topologies, transactions, snapshots, err := store.ReadTransactionsByAsset(id, since, int(count))
+	if err != nil {
+		return nil, err
+	}
+	return finalizedTransactionsToMap(topologies, transactions, snapshots), nil
+}
+
+func listReferencingTransactions(store storage.Store, params []any) ([]map[string]any, error) {
+	if len(params) != 3 {
+		return nil, errInvalidParamsCount
+	}
+	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
+	if err != nil {
+		return nil, err
+	}
+	since, err := strconv.ParseUint(fmt.Sprint(params[1]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(fmt.Sprint(params[2]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if count > 500 {
+		count = 500
+	}
+
+	topologies, transactions, snapshots, err := store.ReadReferencingTransactions(hash, since, int(count))
+	if err != nil {
+		return nil, err
+	}
+	return finalizedTransactionsToMap(topologies, transactions, snapshots), nil
+}
+
+func finalizedTransactionsToMap(topologies []uint64, transactions []*common.VersionedTransaction, snapshots []crypto.Hash) []map[string]any {
+	result := make([]map[string]any, len(transactions))
+	for i, tx := range transactions {
+		data := transactionToMap(tx)
+		data["hex"] = hex.EncodeToString(tx.Marshal())
+		data["snapshot"] = snapshots[i]
+		data["topology"] = topologies[i]
+		result[i] = data
+	}
+	return result
+}
+
 func snapshotsToMap(node *kernel.Node, snapshots []*common.SnapshotWithTopologicalOrder, transactions [][]*common.VersionedTransaction, sig bool) []map[string]any {
 	tx := len(transactions) == len(snapshots)
 	result := make([]map[string]any, len(snapshots))
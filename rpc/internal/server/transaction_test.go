@@ -0,0 +1,205 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitTransactionTimeout(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(time.Duration(0), waitTransactionTimeout(0))
+	require.Equal(3*time.Second, waitTransactionTimeout(3))
+	require.Equal(maxWaitTransactionTimeout, waitTransactionTimeout(8))
+	require.Equal(maxWaitTransactionTimeout, waitTransactionTimeout(9))
+	require.Equal(maxWaitTransactionTimeout, waitTransactionTimeout(10_000_000_000))
+	require.Equal(maxWaitTransactionTimeout, waitTransactionTimeout(math.MaxUint64))
+}
+
+func TestListCacheTransactions(t *testing.T) {
+	require := require.New(t)
+
+	custom, err := config.Initialize("../../../config/config.example.toml")
+	require.Nil(err)
+	store, err := storage.NewBadgerStore(custom, t.TempDir())
+	require.Nil(err)
+	defer store.Close()
+
+	spent := crypto.Blake3Hash([]byte("conflicts"))
+	var hashes []crypto.Hash
+	for i := range 3 {
+		tx := common.NewTransactionV5(common.XINAssetId)
+		tx.AddInput(spent, uint(i%2))
+		tx.Extra = []byte{byte(i)}
+		ver := tx.AsVersioned()
+		require.Nil(store.CacheQueueTransaction(ver))
+		hashes = append(hashes, ver.PayloadHash())
+	}
+
+	_, err = listCacheTransactions(store, []any{0, 10})
+	require.Equal(errInvalidParamsCount, err)
+
+	all, err := listCacheTransactions(store, []any{0, 10, false})
+	require.Nil(err)
+	require.Len(all, 3)
+	require.Equal(hashes[0], all[0]["hash"])
+	require.Equal(common.XINAssetId, all[0]["asset"])
+	require.Equal(uint8(common.TransactionTypeScript), all[0]["type"])
+	inputs := all[1]["inputs"].([]map[string]any)
+	require.Len(inputs, 1)
+	require.Equal(spent, inputs[0]["hash"])
+	require.Equal(uint(1), inputs[0]["index"])
+	require.Nil(all[0]["conflicts"])
+
+	conflicts, err := listCacheTransactions(store, []any{0, 10, true})
+	require.Nil(err)
+	require.Len(conflicts, 2)
+	require.Equal(hashes[0], conflicts[0]["hash"])
+	require.Equal([]crypto.Hash{hashes[2]}, conflicts[0]["conflicts"])
+	require.Equal(hashes[2], conflicts[1]["hash"])
+	require.Equal([]crypto.Hash{hashes[0]}, conflicts[1]["conflicts"])
+
+	conflicts, err = listCacheTransactions(store, []any{conflicts[0]["queued"], 10, true, hashes[0]})
+	require.Nil(err)
+	require.Len(conflicts, 1)
+	require.Equal(hashes[2], conflicts[0]["hash"])
+	all, err = listCacheTransactions(store, []any{all[0]["queued"], 1, false, hashes[0]})
+	require.Nil(err)
+	require.Len(all, 1)
+	require.Equal(hashes[1], all[0]["hash"])
+	_, err = listCacheTransactions(store, []any{0, 10, true, "invalid"})
+	require.NotNil(err)
+
+	_, err = store.CacheRetrieveTransactions(1)
+	require.Nil(err)
+	conflicts, err = listCacheTransactions(store, []any{0, 10, true})
+	require.Nil(err)
+	require.Len(conflicts, 0)
+}
+
+func TestGetGhostKeys(t *testing.T) {
+	require := require.New(t)
+
+	custom, err := config.Initialize("../../../config/config.example.toml")
+	require.Nil(err)
+	store, err := storage.NewBadgerStore(custom, t.TempDir())
+	require.Nil(err)
+	defer store.Close()
+
+	gns, err := common.ReadGenesis("../../../config/genesis.json")
+	require.Nil(err)
+	rounds, snapshots, transactions, err := gns.BuildSnapshots()
+	require.Nil(err)
+	err = store.LoadGenesis(rounds, snapshots, transactions)
+	require.Nil(err)
+
+	source := transactions[0]
+	key := *source.Outputs[0].Keys[0]
+	unused := crypto.Blake3Hash([]byte("unused"))
+	_, err = getGhostKeys(store, []any{key.String()})
+	require.ErrorContains(err, "invalid ghost keys")
+
+	keys, err := getGhostKeys(store, []any{[]any{key.String(), crypto.Key(unused).String()}})
+	require.Nil(err)
+	require.Len(keys, 2)
+	require.Equal(source.PayloadHash().String(), keys[0]["transaction"])
+	require.Equal(0, keys[0]["index"])
+	require.Equal(snapshots[0].PayloadHash().String(), keys[0]["snapshot"])
+	require.Equal(false, keys[0]["spent"])
+	require.Nil(keys[0]["lock"])
+	require.Nil(keys[1]["transaction"])
+	require.Nil(keys[1]["index"])
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(source.PayloadHash(), 0)
+	spender := tx.AsVersioned()
+	err = store.LockUTXOs(spender.Inputs, spender.PayloadHash(), false)
+	require.Nil(err)
+	err = store.WriteTransaction(spender)
+	require.Nil(err)
+	keys, err = getGhostKeys(store, []any{[]any{key.String()}})
+	require.Nil(err)
+	require.Equal(spender.PayloadHash(), keys[0]["lock"])
+	require.Equal(false, keys[0]["spent"])
+
+	round, err := store.ReadRound(rounds[0].NodeId)
+	require.Nil(err)
+	snap := &common.SnapshotWithTopologicalOrder{
+		Snapshot: &common.Snapshot{
+			Version:      common.SnapshotVersionCommonEncoding,
+			NodeId:       rounds[0].NodeId,
+			RoundNumber:  1,
+			Timestamp:    snapshots[0].Timestamp + 1,
+			Transactions: []crypto.Hash{spender.PayloadHash()},
+			References:   round.References,
+		},
+		TopologicalOrder: uint64(len(snapshots)),
+	}
+	err = store.WriteSnapshot(snap, []crypto.Hash{rounds[0].NodeId})
+	require.Nil(err)
+	keys, err = getGhostKeys(store, []any{[]any{key.String()}})
+	require.Nil(err)
+	require.Equal(spender.PayloadHash(), keys[0]["lock"])
+	require.Equal(true, keys[0]["spent"])
+}
+
+func TestGetUTXOs(t *testing.T) {
+	require := require.New(t)
+
+	custom, err := config.Initialize("../../../config/config.example.toml")
+	require.Nil(err)
+	store, err := storage.NewBadgerStore(custom, t.TempDir())
+	require.Nil(err)
+	defer store.Close()
+
+	gns, err := common.ReadGenesis("../../../config/genesis.json")
+	require.Nil(err)
+	rounds, snapshots, transactions, err := gns.BuildSnapshots()
+	require.Nil(err)
+	err = store.LoadGenesis(rounds, snapshots, transactions)
+	require.Nil(err)
+
+	source := transactions[0].PayloadHash()
+	missing := crypto.Blake3Hash([]byte("missing"))
+	_, err = getUTXOs(store, []any{source.String()})
+	require.ErrorContains(err, "invalid outputs")
+	_, err = getUTXOs(store, []any{[]any{source.String()}})
+	require.ErrorContains(err, "invalid output")
+
+	lock := crypto.Blake3Hash([]byte("lock"))
+	err = store.LockUTXOs([]*common.Input{{Hash: source, Index: 0}}, lock, false)
+	require.Nil(err)
+	utxos, err := getUTXOs(store, []any{[]any{
+		map[string]any{"hash": source.String(), "index": 0},
+		map[string]any{"hash": missing.String(), "index": 0},
+	}})
+	require.Nil(err)
+	require.Len(utxos, 2)
+	require.Equal(source, utxos[0]["hash"])
+	require.Equal(uint(0), utxos[0]["index"])
+	require.Equal(lock, utxos[0]["lock"])
+	require.Nil(utxos[1])
+}
+
+func TestQueueTransactionsEncoding(t *testing.T) {
+	require := require.New(t)
+
+	_, err := queueTransactions(nil, []any{"00"})
+	require.ErrorContains(err, "invalid raw transactions")
+
+	results, err := queueTransactions(nil, []any{[]any{"zz", "00"}})
+	require.Nil(err)
+	require.Len(results, 2)
+	for _, res := range results {
+		require.Nil(res["hash"])
+		require.NotEmpty(res["error"])
+		require.Equal(common.ErrorCodeInvalidEncoding, res["details"].(map[string]any)["code"])
+	}
+}
@@ -2,7 +2,6 @@ package server
 
 import (
 	"encoding/hex"
-	"errors"
 	"fmt"
 
 	"github.com/MixinNetwork/mixin/crypto"
@@ -11,7 +10,7 @@ import (
 
 func readWithdrawal(store storage.Store, params []any) (map[string]any, error) {
 	if len(params) != 1 {
-		return nil, errors.New("invalid params count")
+		return nil, errInvalidParamsCount
 	}
 	hash, err := crypto.HashFromString(fmt.Sprint(params[0]))
 	if err != nil {
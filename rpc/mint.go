@@ -1,9 +1,11 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
 )
 
 func ListMintDistributions(rpc string, offset, count uint64) ([]*common.VersionedTransaction, error) {
@@ -39,3 +41,28 @@ func ListMintDistributions(rpc string, offset, count uint64) ([]*common.Versione
 	}
 	return txs, nil
 }
+
+// ListMintWorks returns the works of the consensus nodes in the mint batch
+// of offset, as lead and sign counts by node id.
+func (c *Client) ListMintWorks(ctx context.Context, offset uint64) (map[crypto.Hash][2]uint64, error) {
+	var out map[string][2]uint64
+	err := c.Call(ctx, "listmintworks", []any{offset}, &out)
+	if err != nil {
+		return nil, err
+	}
+	works := make(map[crypto.Hash][2]uint64, len(out))
+	for id, w := range out {
+		hash, err := crypto.HashFromString(id)
+		if err != nil {
+			return nil, err
+		}
+		works[hash] = w
+	}
+	return works, nil
+}
+
+func (c *Client) ListMintDistributions(ctx context.Context, offset, count uint64) ([]*common.MintDistribution, error) {
+	var mints []*common.MintDistribution
+	err := c.Call(ctx, "listmintdistributions", []any{offset, count, false}, &mints)
+	return mints, err
+}
@@ -0,0 +1,21 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+type KernelNode struct {
+	Id crypto.Hash `json:"id"`
+	common.Node
+}
+
+// ListAllNodes returns the nodes at threshold, or now if threshold is zero,
+// with the complete state history of each signer if history is set.
+func (c *Client) ListAllNodes(ctx context.Context, threshold uint64, history bool) ([]*KernelNode, error) {
+	var nodes []*KernelNode
+	err := c.Call(ctx, "listallnodes", []any{threshold, history}, &nodes)
+	return nodes, err
+}
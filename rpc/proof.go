@@ -0,0 +1,17 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/verifier"
+)
+
+// GetTransactionProof returns the finality proof of a transaction, with the
+// node operations after since, which is the timestamp of the checkpoint to
+// verify the proof from.
+func (c *Client) GetTransactionProof(ctx context.Context, hash crypto.Hash, since uint64) (*verifier.Proof, error) {
+	var proof *verifier.Proof
+	err := c.Call(ctx, "gettransactionproof", []any{hash.String(), since}, &proof)
+	return proof, err
+}
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+type Round struct {
+	Node       crypto.Hash
+	Hash       crypto.Hash
+	Start      uint64
+	End        uint64
+	Number     uint64
+	References *common.RoundLink
+	Snapshots  []*Snapshot
+}
+
+func (c *Client) GetRoundByNumber(ctx context.Context, node crypto.Hash, number uint64) (*Round, error) {
+	data, err := c.call(ctx, "getroundbynumber", []any{node.String(), number})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodeRound(data)
+}
+
+func (c *Client) GetRoundByHash(ctx context.Context, hash crypto.Hash) (*Round, error) {
+	data, err := c.call(ctx, "getroundbyhash", []any{hash.String()})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodeRound(data)
+}
+
+func (c *Client) GetRoundLink(ctx context.Context, from, to crypto.Hash) (uint64, error) {
+	var out struct {
+		Link uint64 `json:"link"`
+	}
+	err := c.Call(ctx, "getroundlink", []any{from.String(), to.String()}, &out)
+	return out.Link, err
+}
+
+func decodeRound(data []byte) (*Round, error) {
+	var out struct {
+		Node       crypto.Hash       `json:"node"`
+		Hash       crypto.Hash       `json:"hash"`
+		Start      uint64            `json:"start"`
+		End        uint64            `json:"end"`
+		Number     uint64            `json:"number"`
+		References *common.RoundLink `json:"references"`
+		Snapshots  []json.RawMessage `json:"snapshots"`
+	}
+	err := json.Unmarshal(data, &out)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := decodeSnapshots(out.Snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return &Round{
+		Node:       out.Node,
+		Hash:       out.Hash,
+		Start:      out.Start,
+		End:        out.End,
+		Number:     out.Number,
+		References: out.References,
+		Snapshots:  snapshots,
+	}, nil
+}
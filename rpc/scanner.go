@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// ScanAccount is an account registered to the output scanner of the node,
+// Topology is the next snapshot to scan for it.
+type ScanAccount struct {
+	Address  common.Address `json:"address"`
+	Topology uint64         `json:"topology"`
+}
+
+// ScanOutput is an output owned by a scan account, Spent is nil until a
+// finalized transaction spends it.
+type ScanOutput struct {
+	Transaction crypto.Hash    `json:"transaction"`
+	Index       uint           `json:"index"`
+	Asset       crypto.Hash    `json:"asset"`
+	Amount      common.Integer `json:"amount"`
+	Keys        []*crypto.Key  `json:"keys"`
+	Mask        crypto.Key     `json:"mask"`
+	Script      common.Script  `json:"script"`
+	Snapshot    crypto.Hash    `json:"snapshot"`
+	Topology    uint64         `json:"topology"`
+	Spent       *crypto.Hash   `json:"spent"`
+}
+
+// AddScanAccount registers the account to the output scanner, which then
+// scans the snapshots since the topology. It requires the admin Token.
+func (c *Client) AddScanAccount(ctx context.Context, view, spend crypto.Key, since uint64) (*ScanAccount, error) {
+	var out ScanAccount
+	err := c.Call(ctx, "addscanaccount", []any{view.String(), spend.String(), since}, &out)
+	return &out, err
+}
+
+// RemoveScanAccount removes the account and returns the count of its removed
+// outputs. It requires the admin Token.
+func (c *Client) RemoveScanAccount(ctx context.Context, addr common.Address) (int, error) {
+	var out struct {
+		Removed int `json:"removed"`
+	}
+	err := c.Call(ctx, "removescanaccount", []any{addr.String()}, &out)
+	return out.Removed, err
+}
+
+func (c *Client) ListScanAccounts(ctx context.Context) ([]*ScanAccount, error) {
+	var out []*ScanAccount
+	err := c.Call(ctx, "listscanaccounts", nil, &out)
+	return out, err
+}
+
+// ListScanOutputs lists at most count outputs of the account found at or
+// after the topology, only the unspent ones if unspent is set. It requires
+// the admin Token.
+func (c *Client) ListScanOutputs(ctx context.Context, addr common.Address, since, count uint64, unspent bool) ([]*ScanOutput, error) {
+	var out []*ScanOutput
+	err := c.Call(ctx, "listscanoutputs", []any{addr.String(), since, count, unspent}, &out)
+	return out, err
+}
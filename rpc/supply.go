@@ -0,0 +1,14 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/kernel"
+)
+
+// GetSupply returns the XIN supply computed by the node at its graph time.
+func (c *Client) GetSupply(ctx context.Context) (*kernel.XINSupply, error) {
+	var supply *kernel.XINSupply
+	err := c.Call(ctx, "getsupply", []any{}, &supply)
+	return supply, err
+}
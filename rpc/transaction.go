@@ -1,11 +1,13 @@
 package rpc
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel"
 )
 
 func GetSnapshot(rpc, hash string) (*common.SnapshotWithTopologicalOrder, error) {
@@ -69,3 +71,351 @@ func SendRawTransaction(rpc, raw string) (crypto.Hash, error) {
 	}
 	return hash, nil
 }
+
+// Snapshot is a finalized snapshot with the witness signed by the node that
+// answered, its Transactions are only the hashes.
+type Snapshot struct {
+	*common.SnapshotWithTopologicalOrder
+	Witness *kernel.SnapshotWitness
+}
+
+type TransactionValidation struct {
+	Hash  crypto.Hash `json:"hash"`
+	Type  uint8       `json:"type"`
+	Size  int         `json:"size"`
+	Extra struct {
+		Size  int            `json:"size"`
+		Limit int            `json:"limit"`
+		Price common.Integer `json:"price"`
+	} `json:"extra"`
+	Valid bool  `json:"valid"`
+	Err   error `json:"-"`
+}
+
+// SentTransaction is the result of a transaction in a batch send, Err is set
+// if the node did not queue it.
+type SentTransaction struct {
+	Hash crypto.Hash `json:"hash"`
+	Err  error       `json:"-"`
+}
+
+type TransactionStatus struct {
+	Hash      crypto.Hash `json:"hash"`
+	State     string      `json:"state"`
+	Snapshot  crypto.Hash `json:"snapshot"`
+	Topology  uint64      `json:"topology"`
+	Timestamp uint64      `json:"timestamp"`
+	Origin    string      `json:"origin"`
+	Err       error       `json:"-"`
+}
+
+// FinalizedTransaction is a finalized transaction with its snapshot and the
+// topology of the snapshot.
+type FinalizedTransaction struct {
+	Transaction *common.VersionedTransaction
+	Snapshot    crypto.Hash
+	Topology    uint64
+}
+
+// GhostKey is the output created with the ghost key. Index is nil if the
+// transaction is not known to the node, and Snapshot is nil until the
+// transaction is finalized. Lock is the candidate transaction spending the
+// output, and Spent is set once it is finalized.
+type GhostKey struct {
+	Key         crypto.Key   `json:"key"`
+	Transaction *crypto.Hash `json:"transaction"`
+	Index       *uint        `json:"index"`
+	Snapshot    *crypto.Hash `json:"snapshot"`
+	Lock        *crypto.Hash `json:"lock"`
+	Spent       bool         `json:"spent"`
+}
+
+type CacheTransaction struct {
+	Hash      crypto.Hash     `json:"hash"`
+	Type      uint8           `json:"type"`
+	Asset     crypto.Hash     `json:"asset"`
+	Size      int             `json:"size"`
+	Queued    uint64          `json:"queued"`
+	Inputs    []*common.Input `json:"inputs"`
+	Conflicts []crypto.Hash   `json:"conflicts"`
+}
+
+func (c *Client) SendRawTransaction(ctx context.Context, raw string) (crypto.Hash, error) {
+	var tx struct {
+		Hash crypto.Hash `json:"hash"`
+	}
+	err := c.Call(ctx, "sendrawtransaction", []any{raw}, &tx)
+	return tx.Hash, err
+}
+
+// SendRawTransactions queues at most 500 transactions, and returns a result
+// for each in the same order. A failed transaction does not fail the others.
+func (c *Client) SendRawTransactions(ctx context.Context, raws []string) ([]*SentTransaction, error) {
+	var out []*struct {
+		SentTransaction
+		resultError
+	}
+	err := c.Call(ctx, "sendrawtransactions", []any{raws}, &out)
+	if err != nil {
+		return nil, err
+	}
+	sent := make([]*SentTransaction, len(out))
+	for i, o := range out {
+		o.SentTransaction.Err = o.resultError.err()
+		sent[i] = &o.SentTransaction
+	}
+	return sent, nil
+}
+
+func (c *Client) ValidateRawTransaction(ctx context.Context, raw string) (*TransactionValidation, error) {
+	var out *struct {
+		TransactionValidation
+		resultError
+	}
+	err := c.Call(ctx, "validaterawtransaction", []any{raw}, &out)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	out.TransactionValidation.Err = out.resultError.err()
+	return &out.TransactionValidation, nil
+}
+
+// GetTransaction returns the transaction and the hash of its snapshot, which
+// is zero if the transaction is not finalized yet.
+func (c *Client) GetTransaction(ctx context.Context, hash crypto.Hash) (*common.VersionedTransaction, crypto.Hash, error) {
+	data, err := c.call(ctx, "gettransaction", []any{hash.String()})
+	if err != nil || data == nil {
+		return nil, crypto.Hash{}, err
+	}
+	return decodeTransaction(data)
+}
+
+func (c *Client) GetTransactionStatus(ctx context.Context, hash crypto.Hash) (*TransactionStatus, error) {
+	return c.readTransactionStatus(ctx, "gettransactionstatus", []any{hash.String()})
+}
+
+// WaitTransaction blocks until the transaction is finalized or dropped, or the
+// timeout in seconds elapses, and returns the latest status. The node limits
+// the timeout to a few seconds, so callers wait in a loop for longer.
+func (c *Client) WaitTransaction(ctx context.Context, hash crypto.Hash, timeout uint64) (*TransactionStatus, error) {
+	return c.readTransactionStatus(ctx, "waittransaction", []any{hash.String(), timeout})
+}
+
+func (c *Client) readTransactionStatus(ctx context.Context, method string, params []any) (*TransactionStatus, error) {
+	var out *struct {
+		TransactionStatus
+		resultError
+	}
+	err := c.Call(ctx, method, params, &out)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	out.TransactionStatus.Err = out.resultError.err()
+	return &out.TransactionStatus, nil
+}
+
+func (c *Client) GetCacheTransaction(ctx context.Context, hash crypto.Hash) (*common.VersionedTransaction, error) {
+	data, err := c.call(ctx, "getcachetransaction", []any{hash.String()})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	ver, _, err := decodeTransaction(data)
+	return ver, err
+}
+
+// ListCacheTransactions pages the cache queue from the since queued time, or
+// after the transaction queued at since if after has value, so continue with
+// the Queued and Hash of the last transaction.
+func (c *Client) ListCacheTransactions(ctx context.Context, since uint64, after crypto.Hash, count uint64, conflictsOnly bool) ([]*CacheTransaction, error) {
+	params := []any{since, count, conflictsOnly}
+	if after.HasValue() {
+		params = append(params, after.String())
+	}
+	var txs []*CacheTransaction
+	err := c.Call(ctx, "listcachetransactions", params, &txs)
+	return txs, err
+}
+
+func (c *Client) ListDroppedTransactions(ctx context.Context, since, count uint64) ([]*common.TransactionDrop, error) {
+	var out []*struct {
+		Hash      crypto.Hash `json:"hash"`
+		Timestamp uint64      `json:"timestamp"`
+		Origin    string      `json:"origin"`
+		resultError
+	}
+	err := c.Call(ctx, "listdroppedtransactions", []any{since, count}, &out)
+	if err != nil {
+		return nil, err
+	}
+	drops := make([]*common.TransactionDrop, len(out))
+	for i, d := range out {
+		e := common.AsError(d.err())
+		drops[i] = &common.TransactionDrop{
+			Hash:      d.Hash,
+			Timestamp: d.Timestamp,
+			Origin:    d.Origin,
+			Code:      e.Code,
+			Input:     e.Input,
+			Reason:    d.Error,
+		}
+	}
+	return drops, nil
+}
+
+// GetKey returns the transaction locking the ghost key, or zero if the key
+// is not used yet.
+func (c *Client) GetKey(ctx context.Context, key crypto.Key) (crypto.Hash, error) {
+	var out struct {
+		Transaction *crypto.Hash `json:"transaction"`
+	}
+	err := c.Call(ctx, "getkey", []any{key.String()}, &out)
+	if err != nil || out.Transaction == nil {
+		return crypto.Hash{}, err
+	}
+	return *out.Transaction, nil
+}
+
+// GetKeys looks up at most 500 ghost keys. Each result has the transaction
+// and output index that created the key, or a nil Transaction if the key is
+// not used yet.
+func (c *Client) GetKeys(ctx context.Context, keys []crypto.Key) ([]*GhostKey, error) {
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = k.String()
+	}
+	var out []*GhostKey
+	err := c.Call(ctx, "getkeys", []any{params}, &out)
+	return out, err
+}
+
+func (c *Client) GetSnapshot(ctx context.Context, hash crypto.Hash) (*Snapshot, error) {
+	data, err := c.call(ctx, "getsnapshot", []any{hash.String()})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func (c *Client) GetSnapshotTrace(ctx context.Context, hash crypto.Hash) (*kernel.CosiTrace, error) {
+	var trace *kernel.CosiTrace
+	err := c.Call(ctx, "getsnapshottrace", []any{hash.String()}, &trace)
+	return trace, err
+}
+
+// ListSnapshots returns count snapshots since the topology, with signatures
+// if sig is set. Use GetTransaction to read the transactions of a snapshot.
+func (c *Client) ListSnapshots(ctx context.Context, since, count uint64, sig bool) ([]*Snapshot, error) {
+	var out []json.RawMessage
+	err := c.Call(ctx, "listsnapshots", []any{since, count, sig, false}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshots(out)
+}
+
+// ListSnapshotsByTime returns at most count snapshots with timestamps in
+// [start, end), it requires the transaction index of the node.
+func (c *Client) ListSnapshotsByTime(ctx context.Context, start, end, count uint64, sig bool) ([]*Snapshot, error) {
+	var out []json.RawMessage
+	err := c.Call(ctx, "listsnapshotsbytime", []any{start, end, count, sig, false}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshots(out)
+}
+
+// ListTransactionsByAsset returns at most count transactions of the asset
+// finalized since the topology, it requires the transaction index of the node.
+func (c *Client) ListTransactionsByAsset(ctx context.Context, id crypto.Hash, since, count uint64) ([]*FinalizedTransaction, error) {
+	var out []json.RawMessage
+	err := c.Call(ctx, "listtransactionsbyasset", []any{id.String(), since, count}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFinalizedTransactions(out)
+}
+
+// ListReferencingTransactions returns at most count transactions referencing
+// the hash, finalized since the topology.
+func (c *Client) ListReferencingTransactions(ctx context.Context, hash crypto.Hash, since, count uint64) ([]*FinalizedTransaction, error) {
+	var out []json.RawMessage
+	err := c.Call(ctx, "listreferencingtransactions", []any{hash.String(), since, count}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFinalizedTransactions(out)
+}
+
+func decodeFinalizedTransactions(out []json.RawMessage) ([]*FinalizedTransaction, error) {
+	txs := make([]*FinalizedTransaction, len(out))
+	for i, data := range out {
+		var topo struct {
+			Topology uint64 `json:"topology"`
+		}
+		err := json.Unmarshal(data, &topo)
+		if err != nil {
+			return nil, err
+		}
+		ver, snap, err := decodeTransaction(data)
+		if err != nil {
+			return nil, err
+		}
+		txs[i] = &FinalizedTransaction{Transaction: ver, Snapshot: snap, Topology: topo.Topology}
+	}
+	return txs, nil
+}
+
+func decodeTransaction(data []byte) (*common.VersionedTransaction, crypto.Hash, error) {
+	var out struct {
+		Hex      string       `json:"hex"`
+		Snapshot *crypto.Hash `json:"snapshot"`
+	}
+	err := json.Unmarshal(data, &out)
+	if err != nil {
+		return nil, crypto.Hash{}, err
+	}
+	raw, err := hex.DecodeString(out.Hex)
+	if err != nil {
+		return nil, crypto.Hash{}, err
+	}
+	ver, err := common.UnmarshalVersionedTransaction(raw)
+	if err != nil || out.Snapshot == nil {
+		return ver, crypto.Hash{}, err
+	}
+	return ver, *out.Snapshot, nil
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	var out struct {
+		Hex      string                  `json:"hex"`
+		Topology uint64                  `json:"topology"`
+		Witness  *kernel.SnapshotWitness `json:"witness"`
+	}
+	err := json.Unmarshal(data, &out)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(out.Hex)
+	if err != nil {
+		return nil, err
+	}
+	s, err := common.UnmarshalVersionedSnapshot(raw)
+	if err != nil {
+		return nil, err
+	}
+	s.Hash = s.PayloadHash()
+	s.TopologicalOrder = out.Topology
+	return &Snapshot{SnapshotWithTopologicalOrder: s, Witness: out.Witness}, nil
+}
+
+func decodeSnapshots(data []json.RawMessage) ([]*Snapshot, error) {
+	snapshots := make([]*Snapshot, len(data))
+	for i, d := range data {
+		s, err := decodeSnapshot(d)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[i] = s
+	}
+	return snapshots, nil
+}
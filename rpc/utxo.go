@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -37,6 +38,47 @@ func GetUTXO(rpc, hash string, index uint64) (*common.UTXOWithLock, error) {
 	if err != nil || data == nil {
 		return nil, err
 	}
+	utxo, err := decodeUTXO(data)
+	if err != nil {
+		panic(string(data))
+	}
+	return utxo, nil
+}
+
+func (c *Client) GetUTXO(ctx context.Context, hash crypto.Hash, index uint) (*common.UTXOWithLock, error) {
+	data, err := c.call(ctx, "getutxo", []any{hash.String(), index})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodeUTXO(data)
+}
+
+// GetUTXOs reads at most 500 outputs from the same view of the node, a
+// missing output is nil.
+func (c *Client) GetUTXOs(ctx context.Context, inputs []*common.Input) ([]*common.UTXOWithLock, error) {
+	params := make([]map[string]any, len(inputs))
+	for i, in := range inputs {
+		params[i] = map[string]any{"hash": in.Hash.String(), "index": in.Index}
+	}
+	var out []json.RawMessage
+	err := c.Call(ctx, "getutxos", []any{params}, &out)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]*common.UTXOWithLock, len(out))
+	for i, data := range out {
+		if string(data) == "null" {
+			continue
+		}
+		utxos[i], err = decodeUTXO(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return utxos, nil
+}
+
+func decodeUTXO(data []byte) (*common.UTXOWithLock, error) {
 	var out struct {
 		Type     uint8          `json:"type"`
 		Hash     crypto.Hash    `json:"hash"`
@@ -47,9 +89,9 @@ func GetUTXO(rpc, hash string, index uint64) (*common.UTXOWithLock, error) {
 		Mask     *crypto.Key    `json:"mask"`
 		LockHash crypto.Hash    `json:"lock"`
 	}
-	err = json.Unmarshal(data, &out)
+	err := json.Unmarshal(data, &out)
 	if err != nil {
-		panic(string(data))
+		return nil, err
 	}
 
 	utxo := &common.UTXOWithLock{LockHash: out.LockHash}
@@ -59,6 +101,8 @@ func GetUTXO(rpc, hash string, index uint64) (*common.UTXOWithLock, error) {
 	utxo.Amount = out.Amount
 	utxo.Keys = out.Keys
 	utxo.Script = out.Script
-	utxo.Mask = *out.Mask
+	if out.Mask != nil {
+		utxo.Mask = *out.Mask
+	}
 	return utxo, nil
 }
@@ -0,0 +1,18 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// GetWithdrawalClaim returns the claim of the withdrawal transaction and the
+// hash of its snapshot, which is zero if the claim is not finalized yet.
+func (c *Client) GetWithdrawalClaim(ctx context.Context, hash crypto.Hash) (*common.VersionedTransaction, crypto.Hash, error) {
+	data, err := c.call(ctx, "getwithdrawalclaim", []any{hash.String()})
+	if err != nil || data == nil {
+		return nil, crypto.Hash{}, err
+	}
+	return decodeTransaction(data)
+}
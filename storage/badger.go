@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -15,6 +17,7 @@ type BadgerStore struct {
 	snapshotsDB *badger.DB
 	cacheDB     *badger.DB
 	custodians  sync.Map
+	drops       cacheDropJournal
 	mutex       *sync.RWMutex
 	closing     bool
 }
@@ -46,6 +49,49 @@ func (store *BadgerStore) Close() error {
 	return store.cacheDB.Close()
 }
 
+// DatabaseSizes returns the LSM and value log sizes in bytes of the snapshots
+// and cache databases. The sizes are summed from the files on disk, because
+// badger only tracks them when its metrics are enabled.
+func (store *BadgerStore) DatabaseSizes() (map[string][2]int64, error) {
+	sizes := make(map[string][2]int64)
+	for name, db := range map[string]*badger.DB{
+		"snapshots": store.snapshotsDB,
+		"cache":     store.cacheDB,
+	} {
+		lsm, err := filesSize(db.Opts().Dir, ".sst")
+		if err != nil {
+			return nil, err
+		}
+		vlog, err := filesSize(db.Opts().ValueDir, ".vlog")
+		if err != nil {
+			return nil, err
+		}
+		sizes[name] = [2]int64{lsm, vlog}
+	}
+	return sizes, nil
+}
+
+func filesSize(dir, ext string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ext {
+			continue
+		}
+		info, err := e.Info()
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
 func openDB(dir string, sync bool, custom *config.Custom) (*badger.DB, error) {
 	opts := badger.DefaultOptions(dir)
 	opts = opts.WithSyncWrites(sync)
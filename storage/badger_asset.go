@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 
@@ -51,32 +52,282 @@ func writeTotalInAsset(txn *badger.Txn, ver *common.VersionedTransaction) error
 		return err
 	}
 
+	typ, amount := assetSupplyChange(ver)
+	switch typ {
+	case "":
+		return nil
+	case common.AssetSupplyWithdrawal:
+		total = total.Sub(amount)
+	default:
+		total = total.Add(amount)
+	}
+
+	max := common.GetAssetCapacity(ver.Asset)
+	if total.Cmp(max) > 0 {
+		panic(total.String())
+	}
+	key := graphAssetTotalKey(ver.Asset)
+	return txn.Set(key, []byte(total.String()))
+}
+
+// assetSupplyChange returns the type and amount of the asset total change by
+// the transaction, or an empty type if the total is not changed.
+func assetSupplyChange(ver *common.VersionedTransaction) (string, common.Integer) {
 	typ := ver.TransactionType()
 	switch { // TODO needs full test code for all kind of transactions
 	case typ == common.TransactionTypeWithdrawalSubmit:
+		amount := common.Zero
 		for _, o := range ver.Outputs {
 			if o.Type == common.OutputTypeWithdrawalSubmit {
-				total = total.Sub(o.Amount)
+				amount = amount.Add(o.Amount)
 			}
 		}
+		return common.AssetSupplyWithdrawal, amount
 	case typ == common.TransactionTypeDeposit:
-		total = total.Add(ver.DepositData().Amount)
+		return common.AssetSupplyDeposit, ver.DepositData().Amount
 	case typ == common.TransactionTypeMint:
-		total = total.Add(ver.Inputs[0].Mint.Amount)
+		return common.AssetSupplyMint, ver.Inputs[0].Mint.Amount
 	case len(ver.Inputs[0].Genesis) > 0:
+		amount := common.Zero
 		for _, out := range ver.Outputs {
-			total = total.Add(out.Amount)
+			amount = amount.Add(out.Amount)
 		}
+		return common.AssetSupplyGenesis, amount
 	default:
+		return "", common.Zero
+	}
+}
+
+func writeAssetSupplyChange(txn *badger.Txn, ver *common.VersionedTransaction, timestamp uint64) error {
+	typ, amount := assetSupplyChange(ver)
+	if typ == "" {
 		return nil
 	}
+	total, err := readTotalInAsset(txn, ver.Asset)
+	if err != nil {
+		return err
+	}
+	return putAssetSupplyChange(txn, &common.AssetSupplyChange{
+		Asset:       ver.Asset,
+		Transaction: ver.PayloadHash(),
+		Timestamp:   timestamp,
+		Type:        typ,
+		Amount:      amount,
+		Total:       total,
+	})
+}
 
-	max := common.GetAssetCapacity(ver.Asset)
-	if total.Cmp(max) > 0 {
-		panic(total.String())
+func putAssetSupplyChange(txn *badger.Txn, change *common.AssetSupplyChange) error {
+	val, err := json.Marshal(change)
+	if err != nil {
+		panic(err)
 	}
-	key := graphAssetTotalKey(ver.Asset)
-	return txn.Set(key, []byte(total.String()))
+	key := graphAssetSupplyKey(change.Asset, change.Timestamp, change.Transaction)
+	return txn.Set(key, val)
+}
+
+func burnedInTransaction(ver *common.VersionedTransaction) common.Integer {
+	burned := common.Zero
+	for _, utxo := range ver.UnspentOutputs() {
+		if utxo.Burned() {
+			burned = burned.Add(utxo.Amount)
+		}
+	}
+	return burned
+}
+
+func writeAssetBurned(txn *badger.Txn, ver *common.VersionedTransaction) error {
+	burned := burnedInTransaction(ver)
+	if burned.Sign() == 0 {
+		return nil
+	}
+	total, err := readAssetBurned(txn, ver.Asset)
+	if err != nil {
+		return err
+	}
+	key := graphAssetBurnedKey(ver.Asset)
+	return txn.Set(key, []byte(total.Add(burned).String()))
+}
+
+// ReadAssetBurned returns the burned total of the asset, and whether the
+// burned outputs are indexed since genesis. A database finalized by an older
+// node is not indexed until RebuildAssetSupply, and its total is too low.
+func (s *BadgerStore) ReadAssetBurned(id crypto.Hash) (common.Integer, bool, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	burned, err := readAssetBurned(txn, id)
+	if err != nil {
+		return common.Zero, false, err
+	}
+	_, err = txn.Get([]byte(graphKeyAssetIndexed))
+	if err == badger.ErrKeyNotFound {
+		return burned, false, nil
+	}
+	return burned, err == nil, err
+}
+
+func readAssetBurned(txn *badger.Txn, id crypto.Hash) (common.Integer, error) {
+	item, err := txn.Get(graphAssetBurnedKey(id))
+	if err == badger.ErrKeyNotFound {
+		return common.Zero, nil
+	} else if err != nil {
+		return common.Zero, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return common.Zero, err
+	}
+	return common.NewIntegerFromString(string(val)), nil
+}
+
+// RebuildAssetSupply removes the supply history and burned totals of all
+// assets, then rebuilds them from the finalized transactions in topology
+// order, for databases finalized before these entries were written.
+func (s *BadgerStore) RebuildAssetSupply() (int, error) {
+	for _, prefix := range []string{graphKeyAssetIndexed, graphPrefixAssetSupply, graphPrefixAssetBurned} {
+		_, err := s.removeGraphPrefix(prefix)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	totals := make(map[crypto.Hash]common.Integer)
+	burned := make(map[crypto.Hash]common.Integer)
+	var count int
+	for offset := uint64(0); ; {
+		snapshots, transactions, err := s.ReadSnapshotWithTransactionsSinceTopology(offset, 500)
+		if err != nil {
+			return count, err
+		}
+		if len(snapshots) == 0 {
+			break
+		}
+		txn := s.snapshotsDB.NewTransaction(true)
+		for i, snap := range snapshots {
+			for _, ver := range transactions[i] {
+				if ver == nil {
+					continue
+				}
+				_, finalized, err := readTransactionAndFinalization(txn, ver.PayloadHash())
+				if err != nil {
+					txn.Discard()
+					return count, err
+				}
+				if finalized != snap.PayloadHash().String() {
+					continue
+				}
+				if b := burnedInTransaction(ver); b.Sign() > 0 {
+					burned[ver.Asset] = burned[ver.Asset].Add(b)
+				}
+				typ, amount := assetSupplyChange(ver)
+				if typ == "" {
+					continue
+				}
+				total := totals[ver.Asset]
+				if typ == common.AssetSupplyWithdrawal {
+					total = total.Sub(amount)
+				} else {
+					total = total.Add(amount)
+				}
+				totals[ver.Asset] = total
+				err = putAssetSupplyChange(txn, &common.AssetSupplyChange{
+					Asset:       ver.Asset,
+					Transaction: ver.PayloadHash(),
+					Timestamp:   snap.Timestamp,
+					Type:        typ,
+					Amount:      amount,
+					Total:       total,
+				})
+				if err != nil {
+					txn.Discard()
+					return count, err
+				}
+				count += 1
+			}
+		}
+		err = txn.Commit()
+		if err != nil {
+			return count, err
+		}
+		offset = snapshots[len(snapshots)-1].TopologicalOrder + 1
+	}
+
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+	for id, b := range burned {
+		err := txn.Set(graphAssetBurnedKey(id), []byte(b.String()))
+		if err != nil {
+			return count, err
+		}
+	}
+	err := txn.Set([]byte(graphKeyAssetIndexed), []byte{})
+	if err != nil {
+		return count, err
+	}
+	return count, txn.Commit()
+}
+
+// ReadAssetsWithBalance lists the assets with ids after offset in order, the
+// zero offset lists from the first asset.
+func (s *BadgerStore) ReadAssetsWithBalance(offset crypto.Hash, limit int) ([]crypto.Hash, []*common.Asset, []common.Integer, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(graphPrefixAssetInfo)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var ids []crypto.Hash
+	var assets []*common.Asset
+	var balances []common.Integer
+	for it.Seek(graphAssetInfoKey(offset)); it.Valid() && len(ids) < limit; it.Next() {
+		var id crypto.Hash
+		copy(id[:], it.Item().Key()[len(graphPrefixAssetInfo):])
+		if offset.HasValue() && id == offset {
+			continue
+		}
+		asset, err := readAssetInfo(txn, id)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		balance, err := readTotalInAsset(txn, id)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ids = append(ids, id)
+		assets = append(assets, asset)
+		balances = append(balances, balance)
+	}
+	return ids, assets, balances, nil
+}
+
+// ReadAssetSupplyHistory lists the changes of the asset total balance at or
+// after the timestamp, in the order of their snapshot timestamps.
+func (s *BadgerStore) ReadAssetSupplyHistory(id crypto.Hash, since uint64, limit int) ([]*common.AssetSupplyChange, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = append([]byte(graphPrefixAssetSupply), id[:]...)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var changes []*common.AssetSupplyChange
+	for it.Seek(graphAssetSupplyKey(id, since, crypto.Hash{})); it.Valid() && len(changes) < limit; it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var c common.AssetSupplyChange
+		err = json.Unmarshal(val, &c)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &c)
+	}
+	return changes, nil
 }
 
 func readAssetInfo(txn *badger.Txn, id crypto.Hash) (*common.Asset, error) {
@@ -136,3 +387,13 @@ func graphAssetInfoKey(id crypto.Hash) []byte {
 func graphAssetTotalKey(id crypto.Hash) []byte {
 	return append([]byte(graphPrefixAssetTotal), id[:]...)
 }
+
+func graphAssetBurnedKey(id crypto.Hash) []byte {
+	return append([]byte(graphPrefixAssetBurned), id[:]...)
+}
+
+func graphAssetSupplyKey(id crypto.Hash, timestamp uint64, tx crypto.Hash) []byte {
+	key := append([]byte(graphPrefixAssetSupply), id[:]...)
+	key = binary.BigEndian.AppendUint64(key, timestamp)
+	return append(key, tx[:]...)
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"encoding/binary"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -14,6 +15,12 @@ const (
 	cachePrefixTransactionQueue = "CACHETRANSACTIONQUEUE"
 	cachePrefixTransactionOrder = "CACHETRANSACTIONORDER"
 	cachePrefixTransactionCache = "CACHETRANSACTIONPAYLOAD"
+	cachePrefixTransactionDrop  = "CACHETRANSACTIONDROP"
+	cachePrefixTransactionTrail = "CACHETRANSACTIONTRAIL"
+	cachePrefixTransactionFrom  = "CACHETRANSACTIONFROM"
+
+	cachePrefixTransactionSpent    = "CACHETRANSACTIONSPENT"
+	cachePrefixTransactionConflict = "CACHETRANSACTIONCONFLICT"
 )
 
 func (s *BadgerStore) CacheRetrieveTransactions(limit int) ([]*common.VersionedTransaction, error) {
@@ -32,9 +39,11 @@ func (s *BadgerStore) CacheRetrieveTransactions(limit int) ([]*common.VersionedT
 		it.Seek(cacheTransactionQueueKey(0, hash))
 		for ; len(txs) < limit && it.Valid(); it.Next() {
 			key := it.Item().KeyCopy(nil)
+			ts := binary.BigEndian.Uint64(key[len(cachePrefixTransactionQueue):])
 			copy(hash[:], key[len(cachePrefixTransactionQueue)+8:])
 			processed = append(processed, key)
 			processed = append(processed, cacheTransactionOrderKey(hash))
+			processed = append(processed, cacheTransactionConflictKey(ts, hash))
 			if filter[hash] {
 				continue
 			}
@@ -43,8 +52,14 @@ func (s *BadgerStore) CacheRetrieveTransactions(limit int) ([]*common.VersionedT
 			if err != nil {
 				return err
 			}
-			if ver != nil {
-				txs = append(txs, ver)
+			if ver == nil {
+				continue
+			}
+			txs = append(txs, ver)
+			for _, in := range ver.Inputs {
+				if in.Hash.HasValue() {
+					processed = append(processed, cacheTransactionSpentKey(in.Hash, in.Index, hash))
+				}
 			}
 		}
 
@@ -60,6 +75,146 @@ func (s *BadgerStore) CacheRetrieveTransactions(limit int) ([]*common.VersionedT
 	return txs, err
 }
 
+// CacheListQueuedTransactions pages through the queue without consuming it,
+// from the inclusive since queued time, or after the transaction queued at
+// since if after has value, and returns the queued time of each transaction.
+// A transaction whose payload has expired is skipped.
+func (s *BadgerStore) CacheListQueuedTransactions(since uint64, after crypto.Hash, limit int) ([]uint64, []*common.VersionedTransaction, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(cachePrefixTransactionQueue)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var queued []uint64
+	var txs []*common.VersionedTransaction
+	var hash crypto.Hash
+	filter := make(map[crypto.Hash]bool)
+	it.Seek(cacheTransactionQueueKey(since, after))
+	for ; len(txs) < limit && it.Valid(); it.Next() {
+		key := it.Item().Key()
+		ts := binary.BigEndian.Uint64(key[len(cachePrefixTransactionQueue):])
+		copy(hash[:], key[len(cachePrefixTransactionQueue)+8:])
+		if after.HasValue() && ts == since && hash == after {
+			continue
+		}
+		if filter[hash] {
+			continue
+		}
+		filter[hash] = true
+		ver, err := s.cacheReadTransaction(txn, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ver != nil {
+			queued = append(queued, ts)
+			txs = append(txs, ver)
+		}
+	}
+	return queued, txs, nil
+}
+
+// CacheListConflictTransactions pages through the queued transactions that
+// spend an input also spent by another queued transaction, with the same
+// cursor as CacheListQueuedTransactions, and returns those other transactions
+// of each. It only visits the transactions marked in the conflict index when
+// queued, so the whole queue is never scanned.
+func (s *BadgerStore) CacheListConflictTransactions(since uint64, after crypto.Hash, limit int) ([]uint64, []*common.VersionedTransaction, [][]crypto.Hash, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(cachePrefixTransactionConflict)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var queued []uint64
+	var txs []*common.VersionedTransaction
+	var conflicts [][]crypto.Hash
+	var hash crypto.Hash
+	it.Seek(cacheTransactionConflictKey(since, after))
+	for ; len(txs) < limit && it.Valid(); it.Next() {
+		key := it.Item().Key()
+		ts := binary.BigEndian.Uint64(key[len(cachePrefixTransactionConflict):])
+		copy(hash[:], key[len(cachePrefixTransactionConflict)+8:])
+		if after.HasValue() && ts == since && hash == after {
+			continue
+		}
+		found, err := cacheCheckTransactionQueued(txn, hash)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !found {
+			continue
+		}
+		ver, err := s.cacheReadTransaction(txn, hash)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if ver == nil {
+			continue
+		}
+		var others []crypto.Hash
+		filter := map[crypto.Hash]bool{hash: true}
+		for _, in := range ver.Inputs {
+			if !in.Hash.HasValue() {
+				continue
+			}
+			spenders, _, err := cacheReadTransactionSpenders(txn, in.Hash, in.Index)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			for _, h := range spenders {
+				if !filter[h] {
+					filter[h] = true
+					others = append(others, h)
+				}
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		queued = append(queued, ts)
+		txs = append(txs, ver)
+		conflicts = append(conflicts, others)
+	}
+	return queued, txs, conflicts, nil
+}
+
+// cacheReadTransactionSpenders reads the queued transactions spending the
+// input, with their queued time.
+func cacheReadTransactionSpenders(txn *badger.Txn, hash crypto.Hash, index uint) ([]crypto.Hash, []uint64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = cacheTransactionSpentPrefix(hash, index)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var spenders []crypto.Hash
+	var queued []uint64
+	for it.Rewind(); it.Valid(); it.Next() {
+		var h crypto.Hash
+		copy(h[:], it.Item().Key()[len(opts.Prefix):])
+		found, err := cacheCheckTransactionQueued(txn, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		spenders = append(spenders, h)
+		queued = append(queued, binary.BigEndian.Uint64(val))
+	}
+	return spenders, queued, nil
+}
+
 func (s *BadgerStore) CacheRemoveTransactions(hashes []crypto.Hash) error {
 	batch := 100
 	for {
@@ -121,9 +276,16 @@ func (s *BadgerStore) cacheStoreTransaction(tx *common.VersionedTransaction) err
 }
 
 func (s *BadgerStore) CacheQueueTransaction(tx *common.VersionedTransaction) error {
+	return s.CacheQueueTransactionWithOrigin(tx, "")
+}
+
+// CacheQueueTransactionWithOrigin records who queues tx, so that the drop
+// journal can tell it if the queue drops tx later. An empty origin keeps the
+// one recorded before.
+func (s *BadgerStore) CacheQueueTransactionWithOrigin(tx *common.VersionedTransaction, origin string) error {
 	var err error
 	for i := range 3 {
-		err = s.cacheQueueTransaction(tx)
+		err = s.cacheQueueTransaction(tx, origin)
 		if !errors.Is(err, badger.ErrConflict) {
 			return err
 		}
@@ -132,7 +294,7 @@ func (s *BadgerStore) CacheQueueTransaction(tx *common.VersionedTransaction) err
 	return err
 }
 
-func (s *BadgerStore) cacheQueueTransaction(tx *common.VersionedTransaction) error {
+func (s *BadgerStore) cacheQueueTransaction(tx *common.VersionedTransaction, origin string) error {
 	txn := s.cacheDB.NewTransaction(true)
 	defer txn.Discard()
 
@@ -156,16 +318,71 @@ func (s *BadgerStore) cacheQueueTransaction(tx *common.VersionedTransaction) err
 		return err
 	}
 
-	key = cacheTransactionQueueKey(uint64(time.Now().UnixNano()), hash)
+	ts := uint64(time.Now().UnixNano())
+	key = cacheTransactionQueueKey(ts, hash)
 	etr = badger.NewEntry(key, []byte{}).WithTTL(time.Duration(s.custom.Node.CacheTTL) * time.Second)
 	err = txn.SetEntry(etr)
 	if err != nil {
 		return err
 	}
 
+	err = s.cacheIndexTransactionConflicts(txn, tx, ts)
+	if err != nil {
+		return err
+	}
+
+	if origin != "" {
+		key = cacheTransactionFromKey(hash)
+		etr = badger.NewEntry(key, []byte(origin)).WithTTL(time.Duration(s.custom.Node.CacheTTL+60) * time.Second)
+		err = txn.SetEntry(etr)
+		if err != nil {
+			return err
+		}
+	}
+
 	return txn.Commit()
 }
 
+// cacheIndexTransactionConflicts indexes the inputs spent by the queued tx,
+// and marks it and the other queued transactions spending any of its inputs
+// in the conflict index, in the queue order.
+func (s *BadgerStore) cacheIndexTransactionConflicts(txn *badger.Txn, tx *common.VersionedTransaction, ts uint64) error {
+	ttl := time.Duration(s.custom.Node.CacheTTL) * time.Second
+	hash := tx.PayloadHash()
+	conflict := false
+	for _, in := range tx.Inputs {
+		if !in.Hash.HasValue() {
+			continue
+		}
+		spenders, queued, err := cacheReadTransactionSpenders(txn, in.Hash, in.Index)
+		if err != nil {
+			return err
+		}
+		for i, h := range spenders {
+			if h == hash {
+				continue
+			}
+			conflict = true
+			etr := badger.NewEntry(cacheTransactionConflictKey(queued[i], h), []byte{}).WithTTL(ttl)
+			err = txn.SetEntry(etr)
+			if err != nil {
+				return err
+			}
+		}
+		val := binary.BigEndian.AppendUint64(nil, ts)
+		etr := badger.NewEntry(cacheTransactionSpentKey(in.Hash, in.Index, hash), val).WithTTL(ttl)
+		err = txn.SetEntry(etr)
+		if err != nil {
+			return err
+		}
+	}
+	if !conflict {
+		return nil
+	}
+	etr := badger.NewEntry(cacheTransactionConflictKey(ts, hash), []byte{}).WithTTL(ttl)
+	return txn.SetEntry(etr)
+}
+
 func (s *BadgerStore) CacheGetTransaction(hash crypto.Hash) (*common.VersionedTransaction, error) {
 	txn := s.cacheDB.NewTransaction(false)
 	defer txn.Discard()
@@ -188,6 +405,207 @@ func (s *BadgerStore) cacheReadTransaction(txn *badger.Txn, tx crypto.Hash) (*co
 	return common.UnmarshalVersionedTransaction(val)
 }
 
+func (s *BadgerStore) CacheCheckTransactionQueued(hash crypto.Hash) (bool, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	return cacheCheckTransactionQueued(txn, hash)
+}
+
+func cacheCheckTransactionQueued(txn *badger.Txn, hash crypto.Hash) (bool, error) {
+	_, err := txn.Get(cacheTransactionOrderKey(hash))
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// cacheDropJournal counts the trail entries of the drop journal, so trimming
+// it to the limit doesn't scan the whole trail on every write. The count is
+// reloaded once per TTL, because the expired entries are never counted out.
+type cacheDropJournal struct {
+	sync.Mutex
+	count   int
+	counted time.Time
+}
+
+// CacheWriteTransactionDrops appends drops to the journal, with the origins
+// recorded when they were queued, and trims the journal to the entries limit.
+// A transaction dropped again replaces its previous entry, so each trail entry
+// has exactly one drop entry to delete with it.
+func (s *BadgerStore) CacheWriteTransactionDrops(drops []*common.TransactionDrop) error {
+	ttl := time.Duration(s.custom.Node.DropJournalTTL) * time.Second
+	s.drops.Lock()
+	defer s.drops.Unlock()
+
+	if time.Since(s.drops.counted) > ttl {
+		count, err := s.cacheCountTransactionDrops()
+		if err != nil {
+			return err
+		}
+		s.drops.count, s.drops.counted = count, time.Now()
+	}
+
+	added := 0
+	err := s.cacheDB.Update(func(txn *badger.Txn) error {
+		for _, d := range drops {
+			old, err := cacheReadTransactionDrop(txn, d.Hash)
+			if err != nil {
+				return err
+			}
+			if old != nil {
+				err = txn.Delete(cacheTransactionTrailKey(old.Timestamp, d.Hash))
+				if err != nil {
+					return err
+				}
+			} else {
+				added += 1
+			}
+			if d.Origin == "" {
+				origin, err := cacheReadTransactionOrigin(txn, d.Hash)
+				if err != nil {
+					return err
+				}
+				d.Origin = origin
+			}
+			val := d.Marshal()
+			etr := badger.NewEntry(cacheTransactionDropKey(d.Hash), val).WithTTL(ttl)
+			err = txn.SetEntry(etr)
+			if err != nil {
+				return err
+			}
+			etr = badger.NewEntry(cacheTransactionTrailKey(d.Timestamp, d.Hash), val).WithTTL(ttl)
+			err = txn.SetEntry(etr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.drops.count += added
+	return s.cacheTrimTransactionDrops(s.custom.Node.DropJournalLimit)
+}
+
+// cacheTrimTransactionDrops deletes the oldest entries over the limit, with
+// their drop entries. It visits only the entries to delete, unless the count
+// includes expired entries, then the count is corrected by the full scan.
+func (s *BadgerStore) cacheTrimTransactionDrops(limit int) error {
+	excess := s.drops.count - limit
+	if excess <= 0 {
+		return nil
+	}
+	return s.cacheDB.Update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(cachePrefixTransactionTrail)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var keys [][]byte
+		for it.Rewind(); it.Valid() && len(keys) < excess; it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		if len(keys) < excess {
+			s.drops.count = len(keys)
+			keys = keys[:max(len(keys)-limit, 0)]
+		}
+
+		var hash crypto.Hash
+		for _, k := range keys {
+			copy(hash[:], k[len(cachePrefixTransactionTrail)+8:])
+			err := txn.Delete(k)
+			if err != nil {
+				return err
+			}
+			err = txn.Delete(cacheTransactionDropKey(hash))
+			if err != nil {
+				return err
+			}
+		}
+		s.drops.count -= len(keys)
+		return nil
+	})
+}
+
+func (s *BadgerStore) cacheCountTransactionDrops() (int, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(cachePrefixTransactionTrail)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	count := 0
+	for it.Rewind(); it.Valid(); it.Next() {
+		count += 1
+	}
+	return count, nil
+}
+
+// CacheListTransactionDrops lists the journal in drop time order, starting at
+// the since timestamp.
+func (s *BadgerStore) CacheListTransactionDrops(since uint64, limit int) ([]*common.TransactionDrop, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(cachePrefixTransactionTrail)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var drops []*common.TransactionDrop
+	it.Seek(cacheTransactionTrailKey(since, crypto.Hash{}))
+	for ; it.Valid() && len(drops) < limit; it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		d, err := common.UnmarshalTransactionDrop(val)
+		if err != nil {
+			return nil, err
+		}
+		drops = append(drops, d)
+	}
+	return drops, nil
+}
+
+func (s *BadgerStore) CacheReadTransactionDrop(hash crypto.Hash) (*common.TransactionDrop, error) {
+	txn := s.cacheDB.NewTransaction(false)
+	defer txn.Discard()
+
+	return cacheReadTransactionDrop(txn, hash)
+}
+
+func cacheReadTransactionDrop(txn *badger.Txn, hash crypto.Hash) (*common.TransactionDrop, error) {
+	item, err := txn.Get(cacheTransactionDropKey(hash))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return common.UnmarshalTransactionDrop(val)
+}
+
+func cacheReadTransactionOrigin(txn *badger.Txn, hash crypto.Hash) (string, error) {
+	item, err := txn.Get(cacheTransactionFromKey(hash))
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	val, err := item.ValueCopy(nil)
+	return string(val), err
+}
+
 func cacheTransactionCacheKey(hash crypto.Hash) []byte {
 	return append([]byte(cachePrefixTransactionCache), hash[:]...)
 }
@@ -201,3 +619,33 @@ func cacheTransactionQueueKey(ts uint64, hash crypto.Hash) []byte {
 func cacheTransactionOrderKey(hash crypto.Hash) []byte {
 	return append([]byte(cachePrefixTransactionOrder), hash[:]...)
 }
+
+func cacheTransactionDropKey(hash crypto.Hash) []byte {
+	return append([]byte(cachePrefixTransactionDrop), hash[:]...)
+}
+
+func cacheTransactionTrailKey(ts uint64, hash crypto.Hash) []byte {
+	key := []byte(cachePrefixTransactionTrail)
+	key = binary.BigEndian.AppendUint64(key, ts)
+	return append(key, hash[:]...)
+}
+
+func cacheTransactionSpentKey(utxo crypto.Hash, index uint, hash crypto.Hash) []byte {
+	key := cacheTransactionSpentPrefix(utxo, index)
+	return append(key, hash[:]...)
+}
+
+func cacheTransactionSpentPrefix(utxo crypto.Hash, index uint) []byte {
+	key := append([]byte(cachePrefixTransactionSpent), utxo[:]...)
+	return binary.BigEndian.AppendUint64(key, uint64(index))
+}
+
+func cacheTransactionConflictKey(ts uint64, hash crypto.Hash) []byte {
+	key := []byte(cachePrefixTransactionConflict)
+	key = binary.BigEndian.AppendUint64(key, ts)
+	return append(key, hash[:]...)
+}
+
+func cacheTransactionFromKey(hash crypto.Hash) []byte {
+	return append([]byte(cachePrefixTransactionFrom), hash[:]...)
+}
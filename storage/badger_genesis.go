@@ -20,6 +20,10 @@ func (s *BadgerStore) LoadGenesis(rounds []*common.Round, snapshots []*common.Sn
 	if err != nil {
 		return err
 	}
+	err = txn.Set([]byte(graphKeyAssetIndexed), []byte{})
+	if err != nil {
+		return err
+	}
 
 	for _, r := range rounds {
 		err := writeRound(txn, r.Hash, r)
@@ -36,6 +40,12 @@ func (s *BadgerStore) LoadGenesis(rounds []*common.Round, snapshots []*common.Sn
 		if err != nil {
 			return err
 		}
+		if s.transactionIndex() {
+			err = writeTransactionIndex(txn, snap)
+			if err != nil {
+				return err
+			}
+		}
 		err = writeSnapshotWork(txn, snap, nil)
 		if err != nil {
 			return err
@@ -35,6 +35,15 @@ const (
 	graphPrefixSpaceQueue        = "SPACEQUEUE"
 	graphPrefixAssetInfo         = "ASSETINFO"
 	graphPrefixAssetTotal        = "ASSETTOTAL"
+	graphPrefixAssetSupply       = "ASSETSUPPLY" // asset|timestamp|transaction, each change of ASSETTOTAL
+	graphPrefixAssetBurned       = "ASSETBURNED" // total of finalized outputs never spendable
+	graphKeyAssetIndexed         = "ASSETINDEX"  // ASSETSUPPLY and ASSETBURNED complete since genesis
+	graphPrefixAssetTopology     = "ASSETTOPO"   // asset|topology|transaction, optional transaction index
+	graphPrefixTimeTopology      = "TIMETOPO"    // timestamp|topology, optional snapshot index
+	graphPrefixReference         = "REFERENCE"   // referenced|topology|transaction, finalized transaction references
+	graphPrefixScanAccount       = "SCANACCOUNT" // spend|view, accounts of the node output scanner
+	graphPrefixScanOutput        = "SCANOUTPUT"  // spend|view|topology|transaction|index, outputs found by the scanner
+	graphPrefixScanUTXO          = "SCANUTXO"    // transaction|index|spend|view, the SCANOUTPUT key to mark spent
 	graphPrefixCustodianUpdate   = "CUSTODIANUPDATE"
 	graphPrefixConsensusSnapshot = "CONSENSUSSNAPSHOT"
 )
@@ -180,6 +189,42 @@ func (s *BadgerStore) RemoveGraphEntries(prefix string) (int, error) {
 	return removed, txn.Commit()
 }
 
+// removeGraphPrefix deletes all the entries with the prefix in committed
+// batches, so an index of any size stays under the transaction limits.
+func (s *BadgerStore) removeGraphPrefix(prefix string) (int, error) {
+	var removed int
+	for {
+		count, err := s.removeGraphPrefixBatch(prefix)
+		if err != nil || count == 0 {
+			return removed, err
+		}
+		removed += count
+	}
+}
+
+func (s *BadgerStore) removeGraphPrefixBatch(prefix string) (int, error) {
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var removed int
+	for it.Seek(opts.Prefix); it.Valid() && removed < scanRemoveBatchSize; it.Next() {
+		err := txn.Delete(it.Item().KeyCopy(nil))
+		if err != nil {
+			return 0, err
+		}
+		removed += 1
+	}
+	it.Close()
+
+	return removed, txn.Commit()
+}
+
 func (s *BadgerStore) ReadSnapshotsForNodeRound(nodeId crypto.Hash, round uint64) ([]*common.SnapshotWithTopologicalOrder, error) {
 	txn := s.snapshotsDB.NewTransaction(false)
 	defer txn.Discard()
@@ -266,6 +311,12 @@ func (s *BadgerStore) WriteSnapshot(snap *common.SnapshotWithTopologicalOrder, s
 	if err != nil {
 		return err
 	}
+	if s.transactionIndex() {
+		err = writeTransactionIndex(txn, snap)
+		if err != nil {
+			return err
+		}
+	}
 	err = writeSnapshotWork(txn, snap, signers)
 	if err != nil {
 		return err
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/dgraph-io/badger/v4"
+)
+
+func (s *BadgerStore) transactionIndex() bool {
+	return s.custom != nil && s.custom.Storage.TransactionIndex
+}
+
+// ReadTransactionsByAsset lists the transactions of the asset finalized at or
+// after the topology, with their topologies and snapshots, in topology order.
+func (s *BadgerStore) ReadTransactionsByAsset(id crypto.Hash, since uint64, limit int) ([]uint64, []*common.VersionedTransaction, []crypto.Hash, error) {
+	if !s.transactionIndex() {
+		return nil, nil, nil, fmt.Errorf("transaction index disabled")
+	}
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	prefix := append([]byte(graphPrefixAssetTopology), id[:]...)
+	return readTopologyIndex(txn, prefix, graphAssetTopologyKey(id, since, crypto.Hash{}), limit)
+}
+
+// ReadSnapshotsByTime lists the snapshots with timestamps in [start, end),
+// in the order of timestamps then topologies.
+func (s *BadgerStore) ReadSnapshotsByTime(start, end uint64, limit int) ([]*common.SnapshotWithTopologicalOrder, error) {
+	if !s.transactionIndex() {
+		return nil, fmt.Errorf("transaction index disabled")
+	}
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(graphPrefixTimeTopology)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var snapshots []*common.SnapshotWithTopologicalOrder
+	for it.Seek(graphTimeTopologyKey(start, 0)); it.Valid() && len(snapshots) < limit; it.Next() {
+		key := it.Item().KeyCopy(nil)
+		if binary.BigEndian.Uint64(key[len(graphPrefixTimeTopology):]) >= end {
+			break
+		}
+		topology := binary.BigEndian.Uint64(key[len(graphPrefixTimeTopology)+8:])
+		snaps, err := readSnapshotsSinceTopology(txn, topology, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(snaps) != 1 || snaps[0].TopologicalOrder != topology {
+			return nil, fmt.Errorf("snapshot topology %d not found", topology)
+		}
+		snapshots = append(snapshots, snaps[0])
+	}
+	return snapshots, nil
+}
+
+// ReadReferencingTransactions lists the transactions referencing the hash
+// finalized at or after the topology, with their topologies and snapshots,
+// in topology order.
+func (s *BadgerStore) ReadReferencingTransactions(hash crypto.Hash, since uint64, limit int) ([]uint64, []*common.VersionedTransaction, []crypto.Hash, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	prefix := append([]byte(graphPrefixReference), hash[:]...)
+	return readTopologyIndex(txn, prefix, graphReferenceKey(hash, since, crypto.Hash{}), limit)
+}
+
+// RebuildTransactionIndex removes the transaction references, and the asset
+// and time indexes if enabled, in committed batches, then rebuilds them from
+// the snapshots in topology order, for databases written before these entries.
+func (s *BadgerStore) RebuildTransactionIndex() (int, error) {
+	prefixes := []string{graphPrefixReference}
+	if s.transactionIndex() {
+		prefixes = append(prefixes, graphPrefixAssetTopology, graphPrefixTimeTopology)
+	}
+	for _, prefix := range prefixes {
+		_, err := s.removeGraphPrefix(prefix)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	for offset := uint64(0); ; {
+		snapshots, err := s.ReadSnapshotsSinceTopology(offset, 500)
+		if err != nil {
+			return count, err
+		}
+		if len(snapshots) == 0 {
+			return count, nil
+		}
+		txn := s.snapshotsDB.NewTransaction(true)
+		for _, snap := range snapshots {
+			err = rebuildSnapshotIndex(txn, snap, s.transactionIndex())
+			if err != nil {
+				txn.Discard()
+				return count, err
+			}
+			count += 1
+		}
+		err = txn.Commit()
+		if err != nil {
+			return count, err
+		}
+		offset = snapshots[len(snapshots)-1].TopologicalOrder + 1
+	}
+}
+
+func rebuildSnapshotIndex(txn *badger.Txn, snap *common.SnapshotWithTopologicalOrder, index bool) error {
+	for _, h := range snap.Transactions {
+		ver, finalized, err := readTransactionAndFinalization(txn, h)
+		if err != nil {
+			return err
+		}
+		if ver == nil || finalized != snap.PayloadHash().String() {
+			continue
+		}
+		err = writeTransactionReferences(txn, ver, snap)
+		if err != nil {
+			return err
+		}
+	}
+	if !index {
+		return nil
+	}
+	return writeTransactionIndex(txn, snap)
+}
+
+func readTopologyIndex(txn *badger.Txn, prefix, seek []byte, limit int) ([]uint64, []*common.VersionedTransaction, []crypto.Hash, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var topologies []uint64
+	var transactions []*common.VersionedTransaction
+	var snapshots []crypto.Hash
+	for it.Seek(seek); it.Valid() && len(topologies) < limit; it.Next() {
+		item := it.Item()
+		key := item.KeyCopy(nil)
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var hash, snap crypto.Hash
+		copy(hash[:], key[len(prefix)+8:])
+		copy(snap[:], val)
+		ver, err := readTransaction(txn, hash)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		topologies = append(topologies, binary.BigEndian.Uint64(key[len(prefix):]))
+		transactions = append(transactions, ver)
+		snapshots = append(snapshots, snap)
+	}
+	return topologies, transactions, snapshots, nil
+}
+
+func writeTransactionReferences(txn *badger.Txn, ver *common.VersionedTransaction, snap *common.SnapshotWithTopologicalOrder) error {
+	hash, snapHash := ver.PayloadHash(), snap.PayloadHash()
+	for _, r := range ver.References {
+		key := graphReferenceKey(r, snap.TopologicalOrder, hash)
+		err := txn.Set(key, snapHash[:])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTransactionIndex(txn *badger.Txn, snap *common.SnapshotWithTopologicalOrder) error {
+	hash := snap.PayloadHash()
+	for _, h := range snap.Transactions {
+		ver, finalized, err := readTransactionAndFinalization(txn, h)
+		if err != nil {
+			return err
+		}
+		if ver == nil || finalized != hash.String() {
+			continue
+		}
+		key := graphAssetTopologyKey(ver.Asset, snap.TopologicalOrder, h)
+		err = txn.Set(key, hash[:])
+		if err != nil {
+			return err
+		}
+	}
+	key := graphTimeTopologyKey(snap.Timestamp, snap.TopologicalOrder)
+	return txn.Set(key, []byte{})
+}
+
+func graphAssetTopologyKey(id crypto.Hash, topology uint64, tx crypto.Hash) []byte {
+	key := append([]byte(graphPrefixAssetTopology), id[:]...)
+	key = binary.BigEndian.AppendUint64(key, topology)
+	return append(key, tx[:]...)
+}
+
+func graphTimeTopologyKey(timestamp, topology uint64) []byte {
+	key := []byte(graphPrefixTimeTopology)
+	key = binary.BigEndian.AppendUint64(key, timestamp)
+	return binary.BigEndian.AppendUint64(key, topology)
+}
+
+func graphReferenceKey(referenced crypto.Hash, topology uint64, tx crypto.Hash) []byte {
+	key := append([]byte(graphPrefixReference), referenced[:]...)
+	key = binary.BigEndian.AppendUint64(key, topology)
+	return append(key, tx[:]...)
+}
@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const scanRemoveBatchSize = 1000
+
+// WriteScanAccount registers the account to the output scanner, which scans
+// the snapshots since the account topology.
+func (s *BadgerStore) WriteScanAccount(account *common.ScanAccount) error {
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+
+	addr := account.Address()
+	key := graphScanAccountKey(addr)
+	_, err := txn.Get(key)
+	if err == nil {
+		return fmt.Errorf("scan account %s already registered", addr.String())
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+	val, err := json.Marshal(account)
+	if err != nil {
+		panic(err)
+	}
+	err = txn.Set(key, val)
+	if err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (s *BadgerStore) ReadScanAccounts() ([]*common.ScanAccount, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(graphPrefixScanAccount)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var accounts []*common.ScanAccount
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var a common.ScanAccount
+		err = json.Unmarshal(val, &a)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, &a)
+	}
+	return accounts, nil
+}
+
+// RemoveScanAccount removes the account and all its scanned outputs, and
+// returns the count of removed outputs.
+func (s *BadgerStore) RemoveScanAccount(addr common.Address) (int, error) {
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+
+	key := graphScanAccountKey(addr)
+	_, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return 0, fmt.Errorf("scan account %s not registered", addr.String())
+	} else if err != nil {
+		return 0, err
+	}
+	err = txn.Delete(key)
+	if err != nil {
+		return 0, err
+	}
+	err = txn.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for {
+		count, err := s.removeScanOutputs(addr)
+		if err != nil || count == 0 {
+			return removed, err
+		}
+		removed += count
+	}
+}
+
+func (s *BadgerStore) removeScanOutputs(addr common.Address) (int, error) {
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = graphScanOutputKey(addr, 0, crypto.Hash{}, 0)[:len(graphPrefixScanOutput)+64]
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var removed int
+	for it.Seek(opts.Prefix); it.Valid() && removed < scanRemoveBatchSize; it.Next() {
+		item := it.Item()
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, err
+		}
+		var out common.ScanOutput
+		err = json.Unmarshal(val, &out)
+		if err != nil {
+			return 0, err
+		}
+		err = txn.Delete(graphScanUTXOKey(out.Transaction, out.Index, addr))
+		if err != nil {
+			return 0, err
+		}
+		err = txn.Delete(item.KeyCopy(nil))
+		if err != nil {
+			return 0, err
+		}
+		removed += 1
+	}
+	it.Close()
+
+	return removed, txn.Commit()
+}
+
+// WriteScanResults writes the outputs and spends found by the scanner and
+// the new topologies of the accounts, all skipped for removed accounts.
+func (s *BadgerStore) WriteScanResults(accounts []*common.ScanAccount, outputs []*common.ScanOutput, spends []*common.ScanSpend) error {
+	txn := s.snapshotsDB.NewTransaction(true)
+	defer txn.Discard()
+
+	removed := make(map[string]bool)
+	for _, a := range accounts {
+		addr := a.Address()
+		key := graphScanAccountKey(addr)
+		_, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			removed[addr.String()] = true
+			continue
+		} else if err != nil {
+			return err
+		}
+		val, err := json.Marshal(a)
+		if err != nil {
+			panic(err)
+		}
+		err = txn.Set(key, val)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, out := range outputs {
+		if removed[out.Account.String()] {
+			continue
+		}
+		key := graphScanOutputKey(out.Account, out.Topology, out.Transaction, out.Index)
+		val, err := json.Marshal(out)
+		if err != nil {
+			panic(err)
+		}
+		err = txn.Set(key, val)
+		if err != nil {
+			return err
+		}
+		err = txn.Set(graphScanUTXOKey(out.Transaction, out.Index, out.Account), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, sp := range spends {
+		err := writeScanSpend(txn, sp)
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+func writeScanSpend(txn *badger.Txn, sp *common.ScanSpend) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = graphScanUTXOKey(sp.Hash, sp.Index, common.Address{})[:len(graphPrefixScanUTXO)+40]
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var keys [][]byte
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	it.Close()
+
+	for _, key := range keys {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		var out common.ScanOutput
+		err = json.Unmarshal(val, &out)
+		if err != nil {
+			return err
+		}
+		out.Spent = sp.Transaction
+		val, err = json.Marshal(out)
+		if err != nil {
+			panic(err)
+		}
+		err = txn.Set(key, val)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadScanOutputs lists the outputs of the account found at or after the
+// topology in topology order, only the unspent ones if unspent is set.
+func (s *BadgerStore) ReadScanOutputs(addr common.Address, since uint64, limit int, unspent bool) ([]*common.ScanOutput, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = graphScanOutputKey(addr, 0, crypto.Hash{}, 0)[:len(graphPrefixScanOutput)+64]
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var outputs []*common.ScanOutput
+	for it.Seek(graphScanOutputKey(addr, since, crypto.Hash{}, 0)); it.Valid() && len(outputs) < limit; it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var out common.ScanOutput
+		err = json.Unmarshal(val, &out)
+		if err != nil {
+			return nil, err
+		}
+		if unspent && out.Spent.HasValue() {
+			continue
+		}
+		outputs = append(outputs, &out)
+	}
+	return outputs, nil
+}
+
+func graphScanAccountKey(addr common.Address) []byte {
+	key := append([]byte(graphPrefixScanAccount), addr.PublicSpendKey[:]...)
+	return append(key, addr.PublicViewKey[:]...)
+}
+
+func graphScanOutputKey(addr common.Address, topology uint64, tx crypto.Hash, index uint) []byte {
+	key := append([]byte(graphPrefixScanOutput), addr.PublicSpendKey[:]...)
+	key = append(key, addr.PublicViewKey[:]...)
+	key = binary.BigEndian.AppendUint64(key, topology)
+	key = append(key, tx[:]...)
+	return binary.BigEndian.AppendUint64(key, uint64(index))
+}
+
+func graphScanUTXOKey(tx crypto.Hash, index uint, addr common.Address) []byte {
+	key := append([]byte(graphPrefixScanUTXO), tx[:]...)
+	key = binary.BigEndian.AppendUint64(key, uint64(index))
+	key = append(key, addr.PublicSpendKey[:]...)
+	return append(key, addr.PublicViewKey[:]...)
+}
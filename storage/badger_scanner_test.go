@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	require := require.New(t)
+
+	custom, err := config.Initialize("../config/config.example.toml")
+	require.Nil(err)
+	store, err := NewBadgerStore(custom, t.TempDir())
+	require.Nil(err)
+	defer util.CloseOrPanic(store)
+
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	addr := common.NewAddressFromSeed(seed)
+	account := &common.ScanAccount{ViewKey: addr.PrivateViewKey, SpendKey: addr.PublicSpendKey, Topology: 5}
+	require.Equal(addr.String(), account.Address().String())
+
+	r := crypto.NewKeyFromSeed(seed)
+	mask := r.Public()
+	ghost := crypto.DeriveGhostPublicKey(&r, &addr.PublicViewKey, &addr.PublicSpendKey, 1)
+	require.True(account.Owns(ghost, &mask, 1))
+	require.False(account.Owns(ghost, &mask, 0))
+
+	err = store.WriteScanAccount(account)
+	require.Nil(err)
+	err = store.WriteScanAccount(account)
+	require.ErrorContains(err, "already registered")
+	accounts, err := store.ReadScanAccounts()
+	require.Nil(err)
+	require.Len(accounts, 1)
+	require.Equal(uint64(5), accounts[0].Topology)
+	require.Equal(addr.PrivateViewKey, accounts[0].ViewKey)
+
+	tx1, tx2, spender := crypto.Blake3Hash([]byte("tx1")), crypto.Blake3Hash([]byte("tx2")), crypto.Blake3Hash([]byte("spender"))
+	outputs := []*common.ScanOutput{
+		{Account: addr, Transaction: tx1, Index: 1, Asset: common.XINAssetId, Amount: common.NewInteger(1), Keys: []*crypto.Key{ghost}, Mask: mask, Topology: 6},
+		{Account: addr, Transaction: tx2, Index: 0, Asset: common.XINAssetId, Amount: common.NewInteger(2), Keys: []*crypto.Key{ghost}, Mask: mask, Topology: 8},
+	}
+	spends := []*common.ScanSpend{{Hash: tx1, Index: 1, Transaction: spender}}
+	accounts[0].Topology = 10
+	err = store.WriteScanResults(accounts, outputs, spends)
+	require.Nil(err)
+
+	accounts, err = store.ReadScanAccounts()
+	require.Nil(err)
+	require.Equal(uint64(10), accounts[0].Topology)
+	all, err := store.ReadScanOutputs(addr, 0, 10, false)
+	require.Nil(err)
+	require.Len(all, 2)
+	require.Equal(tx1, all[0].Transaction)
+	require.Equal(spender, all[0].Spent)
+	require.Equal("1.00000000", all[0].Amount.String())
+	require.False(all[1].Spent.HasValue())
+	unspent, err := store.ReadScanOutputs(addr, 0, 10, true)
+	require.Nil(err)
+	require.Len(unspent, 1)
+	require.Equal(tx2, unspent[0].Transaction)
+	since, err := store.ReadScanOutputs(addr, 7, 10, false)
+	require.Nil(err)
+	require.Len(since, 1)
+	require.Equal(uint64(8), since[0].Topology)
+
+	removed, err := store.RemoveScanAccount(addr)
+	require.Nil(err)
+	require.Equal(2, removed)
+	_, err = store.RemoveScanAccount(addr)
+	require.ErrorContains(err, "not registered")
+	all, err = store.ReadScanOutputs(addr, 0, 10, false)
+	require.Nil(err)
+	require.Len(all, 0)
+
+	err = store.WriteScanResults([]*common.ScanAccount{account}, outputs, nil)
+	require.Nil(err)
+	accounts, err = store.ReadScanAccounts()
+	require.Nil(err)
+	require.Len(accounts, 0)
+	all, err = store.ReadScanOutputs(addr, 0, 10, false)
+	require.Nil(err)
+	require.Len(all, 0)
+}
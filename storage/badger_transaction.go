@@ -179,7 +179,19 @@ func finalizeTransaction(txn *badger.Txn, ver *common.VersionedTransaction, snap
 		}
 	}
 
-	return writeTotalInAsset(txn, ver)
+	err = writeTotalInAsset(txn, ver)
+	if err != nil {
+		return err
+	}
+	err = writeAssetSupplyChange(txn, ver, snap.Timestamp)
+	if err != nil {
+		return err
+	}
+	err = writeTransactionReferences(txn, ver, snap)
+	if err != nil {
+		return err
+	}
+	return writeAssetBurned(txn, ver)
 }
 
 func writeUTXO(txn *badger.Txn, utxo *common.UTXOWithLock, ver *common.VersionedTransaction, timestamp uint64, genesis bool) error {
@@ -33,6 +33,26 @@ func (s *BadgerStore) ReadUTXOLock(hash crypto.Hash, index uint) (*common.UTXOWi
 	return s.readUTXOLock(txn, hash, index)
 }
 
+// ReadUTXOLocks reads the outputs of the inputs in a single transaction, so
+// they are consistent with each other. A missing output is nil.
+func (s *BadgerStore) ReadUTXOLocks(inputs []*common.Input) ([]*common.UTXOWithLock, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	utxos := make([]*common.UTXOWithLock, len(inputs))
+	for i, in := range inputs {
+		utxo, err := s.readUTXOLock(txn, in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		utxos[i] = utxo
+	}
+	return utxos, nil
+}
+
 func (s *BadgerStore) readUTXOLock(txn *badger.Txn, hash crypto.Hash, index uint) (*common.UTXOWithLock, error) {
 	key := graphUtxoKey(hash, index)
 	item, err := txn.Get(key)
@@ -83,7 +103,7 @@ func lockUTXO(txn *badger.Txn, hash crypto.Hash, index uint, tx crypto.Hash, for
 
 	if out.LockHash.HasValue() && out.LockHash != tx {
 		if !fork {
-			return fmt.Errorf("utxo locked for transaction %s", out.LockHash)
+			return common.NewErrorf(common.ErrorCodeInputLocked, "utxo locked for transaction %s", out.LockHash)
 		}
 		err := pruneTransaction(txn, out.LockHash)
 		if err != nil {
@@ -118,7 +138,7 @@ func (s *BadgerStore) LockGhostKeys(keys []*crypto.Key, tx crypto.Hash, fork boo
 		filter := make(map[crypto.Key]bool)
 		for _, ghost := range keys {
 			if filter[*ghost] {
-				return fmt.Errorf("duplicated ghost key %s", ghost.String())
+				return common.NewErrorf(common.ErrorCodeInvalidOutput, "duplicated ghost key %s", ghost.String())
 			}
 			filter[*ghost] = true
 			err := lockGhostKey(txn, ghost, tx, fork)
@@ -155,7 +175,7 @@ func lockGhostKey(txn *badger.Txn, ghost *crypto.Key, tx crypto.Hash, fork bool)
 		return nil
 	}
 	if by != tx {
-		return fmt.Errorf("ghost key %s locked for transaction %s", ghost.String(), by.String())
+		return common.NewErrorf(common.ErrorCodeGhostKeyLocked, "ghost key %s locked for transaction %s", ghost.String(), by.String())
 	}
 	return nil
 }
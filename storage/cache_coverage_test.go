@@ -147,3 +147,200 @@ func TestStorageCorruptionAndKeyBounds(t *testing.T) {
 		graphUtxoKey(crypto.Hash{}, common.InputIndexLimit+1)
 	})
 }
+
+func TestCacheTransactionQueuedAndDrop(t *testing.T) {
+	store := newTestBadgerStore(t)
+	tx := common.NewTransactionV5(common.XINAssetId).AsVersioned()
+	hash := tx.PayloadHash()
+
+	queued, err := store.CacheCheckTransactionQueued(hash)
+	require.NoError(t, err)
+	require.False(t, queued)
+	require.NoError(t, store.CacheQueueTransactionWithOrigin(tx, "rpc"))
+	queued, err = store.CacheCheckTransactionQueued(hash)
+	require.NoError(t, err)
+	require.True(t, queued)
+	_, err = store.CacheRetrieveTransactions(10)
+	require.NoError(t, err)
+	queued, err = store.CacheCheckTransactionQueued(hash)
+	require.NoError(t, err)
+	require.False(t, queued)
+
+	drop, err := store.CacheReadTransactionDrop(hash)
+	require.NoError(t, err)
+	require.Nil(t, drop)
+	cause := common.NewInputErrorf(common.ErrorCodeInputLocked, 1, "input locked for transaction %s", hash)
+	require.NoError(t, store.CacheWriteTransactionDrops([]*common.TransactionDrop{common.NewTransactionDrop(hash, 123, cause)}))
+	drop, err = store.CacheReadTransactionDrop(hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, drop.Hash)
+	require.Equal(t, uint64(123), drop.Timestamp)
+	require.Equal(t, "rpc", drop.Origin)
+	require.Equal(t, common.ErrorCodeInputLocked, drop.Code)
+	require.Equal(t, 1, drop.Input)
+	require.Equal(t, cause.Error(), drop.Reason)
+
+	store.custom.Node.DropJournalLimit = 2
+	var others []*common.TransactionDrop
+	for i := range 2 {
+		h := crypto.Blake3Hash([]byte{byte(i)})
+		others = append(others, common.NewTransactionDrop(h, uint64(200+i), cause))
+	}
+	require.NoError(t, store.CacheWriteTransactionDrops(others))
+	drops, err := store.CacheListTransactionDrops(0, 10)
+	require.NoError(t, err)
+	require.Len(t, drops, 2)
+	require.Equal(t, uint64(200), drops[0].Timestamp)
+	require.Equal(t, "", drops[0].Origin)
+	require.Equal(t, uint64(201), drops[1].Timestamp)
+	drops, err = store.CacheListTransactionDrops(201, 10)
+	require.NoError(t, err)
+	require.Len(t, drops, 1)
+	require.Equal(t, others[1].Hash, drops[0].Hash)
+	drop, err = store.CacheReadTransactionDrop(hash)
+	require.NoError(t, err)
+	require.Nil(t, drop)
+
+	again := common.NewTransactionDrop(others[0].Hash, 300, cause)
+	require.NoError(t, store.CacheWriteTransactionDrops([]*common.TransactionDrop{again}))
+	require.Equal(t, 2, store.drops.count)
+	drops, err = store.CacheListTransactionDrops(0, 10)
+	require.NoError(t, err)
+	require.Len(t, drops, 2)
+	require.Equal(t, others[1].Hash, drops[0].Hash)
+	require.Equal(t, others[0].Hash, drops[1].Hash)
+	require.Equal(t, uint64(300), drops[1].Timestamp)
+
+	store.drops.count = 10
+	extra := common.NewTransactionDrop(crypto.Blake3Hash([]byte{2}), 400, cause)
+	require.NoError(t, store.CacheWriteTransactionDrops([]*common.TransactionDrop{extra}))
+	require.Equal(t, 2, store.drops.count)
+	drops, err = store.CacheListTransactionDrops(0, 10)
+	require.NoError(t, err)
+	require.Len(t, drops, 2)
+	require.Equal(t, uint64(300), drops[0].Timestamp)
+	require.Equal(t, uint64(400), drops[1].Timestamp)
+	drop, err = store.CacheReadTransactionDrop(others[1].Hash)
+	require.NoError(t, err)
+	require.Nil(t, drop)
+
+	err = store.cacheDB.Update(func(txn *badger.Txn) error {
+		return txn.Set(cacheTransactionDropKey(hash), []byte{0xff})
+	})
+	require.NoError(t, err)
+	drop, err = store.CacheReadTransactionDrop(hash)
+	require.Error(t, err)
+	require.Nil(t, drop)
+}
+
+func TestCacheListQueuedTransactions(t *testing.T) {
+	store := newTestBadgerStore(t)
+
+	var hashes []crypto.Hash
+	for i := range 3 {
+		tx := common.NewTransactionV5(common.XINAssetId)
+		tx.AddInput(crypto.Blake3Hash([]byte("queued")), uint(i))
+		ver := tx.AsVersioned()
+		require.NoError(t, store.CacheQueueTransaction(ver))
+		hashes = append(hashes, ver.PayloadHash())
+	}
+
+	queued, txs, err := store.CacheListQueuedTransactions(0, crypto.Hash{}, 2)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Len(t, queued, 2)
+	require.Equal(t, hashes[0], txs[0].PayloadHash())
+	require.Equal(t, hashes[1], txs[1].PayloadHash())
+	require.Less(t, queued[0], queued[1])
+	shared := queued[0]
+
+	_, txs, err = store.CacheListQueuedTransactions(queued[1], hashes[1], 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, hashes[2], txs[0].PayloadHash())
+
+	// transactions queued at the same time are paged by hash
+	err = store.cacheDB.Update(func(txn *badger.Txn) error {
+		for _, h := range hashes[1:] {
+			err := txn.Set(cacheTransactionQueueKey(shared, h), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	_, page, err := store.CacheListQueuedTransactions(shared, crypto.Hash{}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	last := page[1].PayloadHash()
+	_, rest, err := store.CacheListQueuedTransactions(shared, last, 10)
+	require.NoError(t, err)
+	seen := map[crypto.Hash]bool{page[0].PayloadHash(): true, last: true}
+	for _, tx := range rest {
+		seen[tx.PayloadHash()] = true
+	}
+	require.Len(t, seen, 3)
+
+	retrieved, err := store.CacheRetrieveTransactions(10)
+	require.NoError(t, err)
+	require.Len(t, retrieved, 3)
+	_, txs, err = store.CacheListQueuedTransactions(0, crypto.Hash{}, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 0)
+}
+
+func TestCacheListConflictTransactions(t *testing.T) {
+	store := newTestBadgerStore(t)
+
+	spent := crypto.Blake3Hash([]byte("conflicts"))
+	var hashes []crypto.Hash
+	for i := range 4 {
+		tx := common.NewTransactionV5(common.XINAssetId)
+		tx.AddInput(spent, uint(i%2))
+		tx.AddInput(crypto.Blake3Hash([]byte{byte(i)}), 0)
+		ver := tx.AsVersioned()
+		require.NoError(t, store.CacheQueueTransaction(ver))
+		hashes = append(hashes, ver.PayloadHash())
+	}
+
+	queued, txs, others, err := store.CacheListConflictTransactions(0, crypto.Hash{}, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 4)
+	for i, tx := range txs {
+		require.Equal(t, hashes[i], tx.PayloadHash())
+		require.Equal(t, []crypto.Hash{hashes[(i+2)%4]}, others[i])
+	}
+	_, txs, _, err = store.CacheListConflictTransactions(queued[1], hashes[1], 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, hashes[2], txs[0].PayloadHash())
+
+	retrieved, err := store.CacheRetrieveTransactions(1)
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+	_, txs, others, err = store.CacheListConflictTransactions(0, crypto.Hash{}, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, hashes[1], txs[0].PayloadHash())
+	require.Equal(t, []crypto.Hash{hashes[3]}, others[0])
+	require.Equal(t, hashes[3], txs[1].PayloadHash())
+
+	require.NoError(t, store.CacheQueueTransaction(retrieved[0]))
+	_, txs, _, err = store.CacheListConflictTransactions(0, crypto.Hash{}, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 4)
+	require.Equal(t, hashes[0], txs[3].PayloadHash())
+}
+
+func TestDatabaseSizes(t *testing.T) {
+	store := newTestBadgerStore(t)
+	require.NoError(t, store.CacheQueueTransaction(common.NewTransactionV5(common.XINAssetId).AsVersioned()))
+
+	sizes, err := store.DatabaseSizes()
+	require.NoError(t, err)
+	require.Len(t, sizes, 2)
+	require.Contains(t, sizes, "snapshots")
+	require.Contains(t, sizes, "cache")
+	require.Greater(t, sizes["cache"][1], int64(0))
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -457,6 +458,23 @@ func TestTopologyAndTransactionReaders(t *testing.T) {
 	removed, err := store.RemoveGraphEntries(graphPrefixUnique)
 	require.Nil(err)
 	require.Equal(1, removed)
+
+	err = store.snapshotsDB.Update(func(txn *badger.Txn) error {
+		for i := 0; i < scanRemoveBatchSize*2+1; i++ {
+			err := txn.Set([]byte(fmt.Sprintf("REMOVE-GRAPH%08d", i)), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.Nil(err)
+	removed, err = store.removeGraphPrefix("REMOVE-GRAPH")
+	require.Nil(err)
+	require.Equal(scanRemoveBatchSize*2+1, removed)
+	removed, err = store.removeGraphPrefix("REMOVE-GRAPH")
+	require.Nil(err)
+	require.Equal(0, removed)
 }
 
 func TestNodeRoundWorkAndSpaceHelpers(t *testing.T) {
@@ -7,10 +7,14 @@ import (
 
 type Store interface {
 	Close() error
+	DatabaseSizes() (map[string][2]int64, error)
 
 	CheckGenesisLoad(snapshots []*common.SnapshotWithTopologicalOrder) (bool, error)
 	LoadGenesis(rounds []*common.Round, snapshots []*common.SnapshotWithTopologicalOrder, transactions []*common.VersionedTransaction) error
 	ReadAssetWithBalance(id crypto.Hash) (*common.Asset, common.Integer, error)
+	ReadAssetsWithBalance(offset crypto.Hash, limit int) ([]crypto.Hash, []*common.Asset, []common.Integer, error)
+	ReadAssetSupplyHistory(id crypto.Hash, since uint64, limit int) ([]*common.AssetSupplyChange, error)
+	ReadAssetBurned(id crypto.Hash) (common.Integer, bool, error)
 	ReadAllNodes(threshold uint64, withState bool) []*common.Node
 	AddNodeOperation(tx *common.VersionedTransaction, timestamp, threshold uint64, finalized bool) error
 	ReadTransaction(hash crypto.Hash) (*common.VersionedTransaction, string, error)
@@ -23,6 +27,7 @@ type Store interface {
 
 	ReadUTXOKeys(hash crypto.Hash, index uint) (*common.UTXOKeys, error)
 	ReadUTXOLock(hash crypto.Hash, index uint) (*common.UTXOWithLock, error)
+	ReadUTXOLocks(inputs []*common.Input) ([]*common.UTXOWithLock, error)
 	LockUTXOs(inputs []*common.Input, tx crypto.Hash, fork bool) error
 	ReadDepositLock(deposit *common.DepositData) (crypto.Hash, error)
 	LockDepositInput(deposit *common.DepositData, tx crypto.Hash, fork bool) error
@@ -32,6 +37,9 @@ type Store interface {
 	ReadSnapshot(hash crypto.Hash) (*common.SnapshotWithTopologicalOrder, error)
 	ReadSnapshotsSinceTopology(offset, count uint64) ([]*common.SnapshotWithTopologicalOrder, error)
 	ReadSnapshotWithTransactionsSinceTopology(topologyOffset, count uint64) ([]*common.SnapshotWithTopologicalOrder, [][]*common.VersionedTransaction, error)
+	ReadSnapshotsByTime(start, end uint64, limit int) ([]*common.SnapshotWithTopologicalOrder, error)
+	ReadTransactionsByAsset(id crypto.Hash, since uint64, limit int) ([]uint64, []*common.VersionedTransaction, []crypto.Hash, error)
+	ReadReferencingTransactions(hash crypto.Hash, since uint64, limit int) ([]uint64, []*common.VersionedTransaction, []crypto.Hash, error)
 	ReadSnapshotsForNodeRound(nodeIdWithNetwork crypto.Hash, round uint64) ([]*common.SnapshotWithTopologicalOrder, error)
 	ReadRound(hash crypto.Hash) (*common.Round, error)
 	ReadLink(from, to crypto.Hash) (uint64, error)
@@ -41,9 +49,16 @@ type Store interface {
 
 	CacheStoreTransaction(tx *common.VersionedTransaction) error
 	CacheQueueTransaction(tx *common.VersionedTransaction) error
+	CacheListQueuedTransactions(since uint64, after crypto.Hash, limit int) ([]uint64, []*common.VersionedTransaction, error)
+	CacheListConflictTransactions(since uint64, after crypto.Hash, limit int) ([]uint64, []*common.VersionedTransaction, [][]crypto.Hash, error)
 	CacheGetTransaction(hash crypto.Hash) (*common.VersionedTransaction, error)
 	CacheRetrieveTransactions(limit int) ([]*common.VersionedTransaction, error)
 	CacheRemoveTransactions([]crypto.Hash) error
+	CacheCheckTransactionQueued(hash crypto.Hash) (bool, error)
+	CacheQueueTransactionWithOrigin(tx *common.VersionedTransaction, origin string) error
+	CacheWriteTransactionDrops(drops []*common.TransactionDrop) error
+	CacheListTransactionDrops(since uint64, limit int) ([]*common.TransactionDrop, error)
+	CacheReadTransactionDrop(hash crypto.Hash) (*common.TransactionDrop, error)
 
 	ReadLastMintDistribution(batch uint64) (*common.MintDistribution, error)
 	LockMintInput(mint *common.MintData, tx crypto.Hash, fork bool) error
@@ -59,6 +74,14 @@ type Store interface {
 	ListAggregatedRoundSpaceCheckpoints(cids []crypto.Hash) (map[crypto.Hash]*common.RoundSpace, error)
 	ReadNodeRoundSpacesForBatch(nodeId crypto.Hash, batch uint64) ([]*common.RoundSpace, error)
 
+	WriteScanAccount(account *common.ScanAccount) error
+	ReadScanAccounts() ([]*common.ScanAccount, error)
+	RemoveScanAccount(addr common.Address) (int, error)
+	WriteScanResults(accounts []*common.ScanAccount, outputs []*common.ScanOutput, spends []*common.ScanSpend) error
+	ReadScanOutputs(addr common.Address, since uint64, limit int, unspent bool) ([]*common.ScanOutput, error)
+
 	RemoveGraphEntries(prefix string) (int, error)
+	RebuildAssetSupply() (int, error)
+	RebuildTransactionIndex() (int, error)
 	ValidateGraphEntries(networkId crypto.Hash, depth uint64) (int, int, error)
 }
@@ -17,6 +17,7 @@ func TestTransaction(t *testing.T) {
 
 	custom, err := config.Initialize("../config/config.example.toml")
 	require.Nil(err)
+	custom.Storage.TransactionIndex = true
 
 	root := t.TempDir()
 
@@ -187,6 +188,128 @@ func TestTransaction(t *testing.T) {
 	require.Nil(err)
 	require.Equal("365562.00000000", balance.String())
 
+	ids, assets, balances, err := store.ReadAssetsWithBalance(crypto.Hash{}, 10)
+	require.Nil(err)
+	require.Equal([]crypto.Hash{common.XINAssetId}, ids)
+	require.Equal(common.XINAsset.AssetKey, assets[0].AssetKey)
+	require.Equal("365562.00000000", balances[0].String())
+	ids, _, _, err = store.ReadAssetsWithBalance(common.XINAssetId, 10)
+	require.Nil(err)
+	require.Len(ids, 0)
+
+	changes, err := store.ReadAssetSupplyHistory(common.XINAssetId, 0, 100)
+	require.Nil(err)
+	require.Len(changes, len(transactions)+2)
+	require.Equal(common.AssetSupplyGenesis, changes[0].Type)
+	deposited, withdrawn := changes[len(changes)-2], changes[len(changes)-1]
+	require.Equal(common.AssetSupplyDeposit, deposited.Type)
+	require.Equal(deposit.AsVersioned().PayloadHash(), deposited.Transaction)
+	require.Equal("10.00000000", deposited.Amount.String())
+	require.Equal("365563.00000000", deposited.Total.String())
+	require.Equal(common.AssetSupplyWithdrawal, withdrawn.Type)
+	require.Equal(submit.AsVersioned().PayloadHash(), withdrawn.Transaction)
+	require.Equal("1.00000000", withdrawn.Amount.String())
+	require.Equal("365562.00000000", withdrawn.Total.String())
+	changes, err = store.ReadAssetSupplyHistory(common.XINAssetId, withdrawn.Timestamp, 100)
+	require.Nil(err)
+	require.Len(changes, 1)
+	changes, err = store.ReadAssetSupplyHistory(common.BitcoinAssetId, 0, 100)
+	require.Nil(err)
+	require.Len(changes, 0)
+
+	burned, indexed, err := store.ReadAssetBurned(common.XINAssetId)
+	require.Nil(err)
+	require.True(indexed)
+	require.Equal("2700.00000000", burned.String())
+	history, err := store.ReadAssetSupplyHistory(common.XINAssetId, 0, 100)
+	require.Nil(err)
+	for _, prefix := range []string{graphPrefixAssetBurned, graphKeyAssetIndexed} {
+		_, err = store.RemoveGraphEntries(prefix)
+		require.Nil(err)
+	}
+	burned, indexed, err = store.ReadAssetBurned(common.XINAssetId)
+	require.Nil(err)
+	require.False(indexed)
+	require.Equal("0.00000000", burned.String())
+	count, err := store.RebuildAssetSupply()
+	require.Nil(err)
+	require.Equal(len(history), count)
+	changes, err = store.ReadAssetSupplyHistory(common.XINAssetId, 0, 100)
+	require.Nil(err)
+	require.Equal(history, changes)
+	burned, indexed, err = store.ReadAssetBurned(common.XINAssetId)
+	require.Nil(err)
+	require.True(indexed)
+	require.Equal("2700.00000000", burned.String())
+
+	topologies, txs, hashes, err := store.ReadTransactionsByAsset(common.XINAssetId, 0, 100)
+	require.Nil(err)
+	require.Len(txs, len(transactions)+3)
+	require.Equal(deposit.AsVersioned().PayloadHash(), txs[len(transactions)].PayloadHash())
+	require.Equal(uint64(len(snapshots)), topologies[len(transactions)])
+	require.Equal(claim.AsVersioned().PayloadHash(), txs[len(txs)-1].PayloadHash())
+	require.Equal(topo.PayloadHash(), hashes[len(hashes)-1])
+	_, txs, _, err = store.ReadTransactionsByAsset(common.XINAssetId, uint64(len(snapshots))+1, 100)
+	require.Nil(err)
+	require.Len(txs, 2)
+	require.Equal(submit.AsVersioned().PayloadHash(), txs[0].PayloadHash())
+	_, txs, _, err = store.ReadTransactionsByAsset(common.BitcoinAssetId, 0, 100)
+	require.Nil(err)
+	require.Len(txs, 0)
+
+	byTime, err := store.ReadSnapshotsByTime(0, ^uint64(0), 100)
+	require.Nil(err)
+	require.Len(byTime, len(snapshots)+3)
+	require.Equal(topo.PayloadHash(), byTime[len(byTime)-1].Hash)
+	byTime, err = store.ReadSnapshotsByTime(topo.Timestamp, topo.Timestamp+1, 100)
+	require.Nil(err)
+	require.Len(byTime, 1)
+	require.Equal(topo.TopologicalOrder, byTime[0].TopologicalOrder)
+	byTime, err = store.ReadSnapshotsByTime(0, snapshots[0].Timestamp, 100)
+	require.Nil(err)
+	require.Len(byTime, 0)
+
+	topologies, txs, hashes, err = store.ReadReferencingTransactions(submit.AsVersioned().PayloadHash(), 0, 100)
+	require.Nil(err)
+	require.Len(txs, 1)
+	require.Equal(claim.AsVersioned().PayloadHash(), txs[0].PayloadHash())
+	require.Equal(topo.TopologicalOrder, topologies[0])
+	require.Equal(topo.PayloadHash(), hashes[0])
+	_, txs, _, err = store.ReadReferencingTransactions(submit.AsVersioned().PayloadHash(), topo.TopologicalOrder+1, 100)
+	require.Nil(err)
+	require.Len(txs, 0)
+
+	_, err = store.RemoveGraphEntries(graphPrefixTimeTopology)
+	require.Nil(err)
+	_, err = store.RemoveGraphEntries(graphPrefixReference)
+	require.Nil(err)
+	count, err = store.RebuildTransactionIndex()
+	require.Nil(err)
+	require.Equal(len(snapshots)+3, count)
+	byTime, err = store.ReadSnapshotsByTime(0, ^uint64(0), 100)
+	require.Nil(err)
+	require.Len(byTime, len(snapshots)+3)
+	_, txs, _, err = store.ReadTransactionsByAsset(common.XINAssetId, 0, 100)
+	require.Nil(err)
+	require.Len(txs, len(transactions)+3)
+	_, txs, _, err = store.ReadReferencingTransactions(submit.AsVersioned().PayloadHash(), 0, 100)
+	require.Nil(err)
+	require.Len(txs, 1)
+
+	custom.Storage.TransactionIndex = false
+	_, err = store.RemoveGraphEntries(graphPrefixReference)
+	require.Nil(err)
+	count, err = store.RebuildTransactionIndex()
+	require.Nil(err)
+	require.Equal(len(snapshots)+3, count)
+	_, txs, _, err = store.ReadReferencingTransactions(submit.AsVersioned().PayloadHash(), 0, 100)
+	require.Nil(err)
+	require.Len(txs, 1)
+	custom.Storage.TransactionIndex = true
+	byTime, err = store.ReadSnapshotsByTime(0, ^uint64(0), 100)
+	require.Nil(err)
+	require.Len(byTime, len(snapshots)+3)
+
 	cs, err := store.ReadLastConsensusSnapshot()
 	require.Nil(err)
 	require.Equal(cs.PayloadHash(), snapshots[len(snapshots)-1].PayloadHash())
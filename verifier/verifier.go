@@ -0,0 +1,388 @@
+package verifier
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/config"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// Signer is a consensus node and the key of its CoSi signature.
+type Signer struct {
+	Id  crypto.Hash `json:"id"`
+	Key crypto.Key  `json:"key"`
+}
+
+// Finalization is a transaction, its finalized snapshot and the consensus
+// nodes claimed to sign the snapshot, in the order of the signature mask.
+type Finalization struct {
+	Transaction string    `json:"transaction"`
+	Snapshot    string    `json:"snapshot"`
+	Consensus   []*Signer `json:"consensus"`
+}
+
+// Proof is the finalization of a transaction, with the finalizations of all
+// node operations after the checkpoint and before the snapshot.
+type Proof struct {
+	Network crypto.Hash `json:"network"`
+	Finalization
+	History []*Finalization `json:"history"`
+}
+
+// Node is the state of a signer after its latest node operation.
+type Node struct {
+	Signer      crypto.Key  `json:"signer"`
+	Payee       crypto.Key  `json:"payee"`
+	Transaction crypto.Hash `json:"transaction"`
+	Timestamp   uint64      `json:"timestamp"`
+	State       string      `json:"state"`
+}
+
+// Checkpoint is the trusted node set to verify proofs from. Nodes are the
+// latest states of all signers at Timestamp, and Genesis the ids of the
+// genesis nodes, which are always ready for consensus.
+type Checkpoint struct {
+	Network   crypto.Hash   `json:"network"`
+	Epoch     uint64        `json:"epoch"`
+	Timestamp uint64        `json:"timestamp"`
+	Genesis   []crypto.Hash `json:"genesis"`
+	Nodes     []*Node       `json:"nodes"`
+}
+
+func GenesisCheckpoint(gns *common.Genesis) (*Checkpoint, error) {
+	_, _, transactions, err := gns.BuildSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{
+		Network:   gns.NetworkId(),
+		Epoch:     gns.EpochTimestamp(),
+		Timestamp: gns.EpochTimestamp(),
+	}
+	for _, tx := range transactions {
+		if len(tx.Outputs) != 1 || tx.Outputs[0].Type != common.OutputTypeNodeAccept {
+			continue
+		}
+		n := &Node{
+			Transaction: tx.PayloadHash(),
+			Timestamp:   cp.Timestamp,
+			State:       common.NodeStateAccepted,
+		}
+		copy(n.Signer[:], tx.Extra)
+		copy(n.Payee[:], tx.Extra[len(n.Signer):])
+		cp.Nodes = append(cp.Nodes, n)
+		cp.Genesis = append(cp.Genesis, cp.nodeId(n.Signer))
+	}
+	cp.sortNodes()
+	return cp, nil
+}
+
+// Verify checks the proof against the checkpoint, and returns the transaction
+// and its finalized snapshot.
+func (cp *Checkpoint) Verify(p *Proof) (*common.VersionedTransaction, *common.SnapshotWithTopologicalOrder, error) {
+	if p.Network != cp.Network {
+		return nil, nil, fmt.Errorf("proof network %s not match %s", p.Network, cp.Network)
+	}
+	next, err := cp.Advance(p.History)
+	if err != nil {
+		return nil, nil, err
+	}
+	return next.verify(&p.Finalization)
+}
+
+// Advance verifies the node operations in order and returns a new checkpoint
+// with them applied, the checkpoint itself is not changed.
+func (cp *Checkpoint) Advance(history []*Finalization) (*Checkpoint, error) {
+	next, _, err := cp.Follow(history)
+	return next, err
+}
+
+// Follow is Advance which also returns the applied node operations, to keep
+// them and rebuild the checkpoint at any later time with At.
+func (cp *Checkpoint) Follow(history []*Finalization) (*Checkpoint, []*Node, error) {
+	next := cp.copy()
+	operations := make([]*Node, 0, len(history))
+	for _, f := range history {
+		ver, s, err := next.verify(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		n, err := next.apply(ver, s.Timestamp)
+		if err != nil {
+			return nil, nil, err
+		}
+		next.Timestamp = s.Timestamp
+		c := *n
+		operations = append(operations, &c)
+	}
+	return next, operations, nil
+}
+
+// At returns a new checkpoint with the verified node operations before the
+// timestamp applied, operations must be in order and follow the checkpoint.
+func (cp *Checkpoint) At(operations []*Node, timestamp uint64) *Checkpoint {
+	next := cp.copy()
+	for _, op := range operations {
+		if op.Timestamp <= next.Timestamp || op.Timestamp >= timestamp {
+			continue
+		}
+		c := *op
+		next.Nodes = slices.DeleteFunc(next.Nodes, func(n *Node) bool { return n.Signer == c.Signer })
+		next.Nodes = append(next.Nodes, &c)
+		next.Timestamp = c.Timestamp
+	}
+	next.sortNodes()
+	return next
+}
+
+// VerifySnapshot checks a snapshot without the claimed consensus nodes, by
+// trying all the node sets allowed by the kernel rules at the snapshot. The
+// error is of the node set without any removal or pledging node.
+func (cp *Checkpoint) VerifySnapshot(s *common.Snapshot) error {
+	expected := cp.consensus(s.Timestamp)
+	candidates := [][]*Signer{expected}
+	if cp.inAcceptHour(s.Timestamp) {
+		for i := range expected {
+			candidates = append(candidates, slices.Delete(slices.Clone(expected), i, i+1))
+		}
+	}
+	if s.RoundNumber == 0 {
+		for _, n := range cp.Nodes {
+			if n.State == common.NodeStatePledging && cp.nodeId(n.Signer) == s.NodeId {
+				pledging := &Signer{Id: s.NodeId, Key: n.Signer}
+				candidates = append(candidates, append(slices.Clone(expected), pledging))
+			}
+		}
+	}
+	err := cp.verifySnapshot(s, expected)
+	for _, claimed := range candidates[1:] {
+		if err == nil {
+			break
+		}
+		if cp.verifySnapshot(s, claimed) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (cp *Checkpoint) verify(f *Finalization) (*common.VersionedTransaction, *common.SnapshotWithTopologicalOrder, error) {
+	raw, err := hex.DecodeString(f.Transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	ver, err := common.UnmarshalVersionedTransaction(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err = hex.DecodeString(f.Snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := common.UnmarshalVersionedSnapshot(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Hash = s.PayloadHash()
+	if !slices.Contains(s.Transactions, ver.PayloadHash()) {
+		return nil, nil, fmt.Errorf("transaction %s not in snapshot %s", ver.PayloadHash(), s.Hash)
+	}
+	err = cp.verifySnapshot(s.Snapshot, f.Consensus)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ver, s, nil
+}
+
+// verifySnapshot follows the kernel rules to build the consensus nodes of
+// the snapshot. The claimed nodes may leave out one node removed in the node
+// operation hours, or add the pledging node of the snapshot in its round 0.
+func (cp *Checkpoint) verifySnapshot(s *common.Snapshot, claimed []*Signer) error {
+	if s.Version != common.SnapshotVersionCommonEncoding {
+		return fmt.Errorf("invalid snapshot version %d", s.Version)
+	}
+	if s.Signature == nil || s.Signature.Mask == 0 {
+		return fmt.Errorf("snapshot %s not signed", s.Hash)
+	}
+	if s.Timestamp <= cp.Timestamp {
+		return fmt.Errorf("snapshot %s at %d before checkpoint %d", s.Hash, s.Timestamp, cp.Timestamp)
+	}
+
+	expected := cp.consensus(s.Timestamp)
+	var removing crypto.Hash
+	switch {
+	case len(claimed) == len(expected):
+		if !signersEqual(claimed, expected) {
+			return fmt.Errorf("snapshot %s consensus not match", s.Hash)
+		}
+	case len(claimed)+1 == len(expected) && cp.inAcceptHour(s.Timestamp):
+		i := 0
+		for i < len(claimed) && *claimed[i] == *expected[i] {
+			i++
+		}
+		removing = expected[i].Id
+		if !signersEqual(claimed, slices.Delete(slices.Clone(expected), i, i+1)) {
+			return fmt.Errorf("snapshot %s consensus not match", s.Hash)
+		}
+	case len(claimed) == len(expected)+1 && s.RoundNumber == 0:
+		last := claimed[len(claimed)-1]
+		if last.Id != s.NodeId || !signersEqual(claimed[:len(expected)], expected) {
+			return fmt.Errorf("snapshot %s consensus not match", s.Hash)
+		}
+		n := cp.node(last.Key)
+		if n == nil || n.State != common.NodeStatePledging {
+			return fmt.Errorf("snapshot %s consensus node %s not pledging", s.Hash, last.Id)
+		}
+	default:
+		return fmt.Errorf("snapshot %s consensus %d not match %d", s.Hash, len(claimed), len(expected))
+	}
+
+	threshold, err := cp.threshold(s.Timestamp, removing)
+	if err != nil {
+		return err
+	}
+	publics := make([]*crypto.Key, len(claimed))
+	for i, c := range claimed {
+		publics[i] = &c.Key
+	}
+	return s.Signature.FullVerify(publics, threshold, s.Hash)
+}
+
+func (cp *Checkpoint) consensus(timestamp uint64) []*Signer {
+	var signers []*Signer
+	for _, n := range cp.Nodes {
+		if n.State != common.NodeStateAccepted {
+			continue
+		}
+		id := cp.nodeId(n.Signer)
+		if slices.Contains(cp.Genesis, id) || n.Timestamp+uint64(config.KernelNodeAcceptPeriodMinimum) < timestamp {
+			signers = append(signers, &Signer{Id: id, Key: n.Signer})
+		}
+	}
+	return signers
+}
+
+func (cp *Checkpoint) threshold(timestamp uint64, removing crypto.Hash) (int, error) {
+	base := 0
+	for _, n := range cp.Nodes {
+		id := cp.nodeId(n.Signer)
+		if n.State != common.NodeStateAccepted || id == removing {
+			continue
+		}
+		threshold := config.SnapshotReferenceThreshold * config.SnapshotRoundGap
+		if slices.Contains(cp.Genesis, id) || n.Timestamp+threshold < timestamp {
+			base++
+		}
+	}
+	if base < config.KernelMinimumNodesCount {
+		return 0, fmt.Errorf("invalid consensus base %d at %d", base, timestamp)
+	}
+	return base*2/3 + 1, nil
+}
+
+func (cp *Checkpoint) apply(ver *common.VersionedTransaction, timestamp uint64) (*Node, error) {
+	var signer, payee crypto.Key
+	if len(ver.Extra) < len(signer)+len(payee) {
+		return nil, fmt.Errorf("invalid node operation %s extra %x", ver.PayloadHash(), ver.Extra)
+	}
+	copy(signer[:], ver.Extra)
+	copy(payee[:], ver.Extra[len(signer):])
+
+	old := cp.node(signer)
+	var last *Node
+	if len(cp.Nodes) > 0 {
+		last = cp.Nodes[len(cp.Nodes)-1]
+	}
+	var state string
+	switch ver.TransactionType() {
+	case common.TransactionTypeNodePledge:
+		state = common.NodeStatePledging
+		if old != nil || slices.ContainsFunc(cp.Nodes, func(n *Node) bool { return n.State == common.NodeStatePledging }) {
+			return nil, fmt.Errorf("invalid node pledge %s", ver.PayloadHash())
+		}
+	case common.TransactionTypeNodeCancel:
+		state = common.NodeStateCancelled
+		if last == nil || last.Signer != signer || last.State != common.NodeStatePledging {
+			return nil, fmt.Errorf("invalid node cancel %s", ver.PayloadHash())
+		}
+	case common.TransactionTypeNodeAccept:
+		state = common.NodeStateAccepted
+		if last == nil || last.Signer != signer || last.State != common.NodeStatePledging {
+			return nil, fmt.Errorf("invalid node accept %s", ver.PayloadHash())
+		}
+	case common.TransactionTypeNodeRemove:
+		state = common.NodeStateRemoved
+		if old == nil || old.State != common.NodeStateAccepted {
+			return nil, fmt.Errorf("invalid node remove %s", ver.PayloadHash())
+		}
+	default:
+		return nil, fmt.Errorf("transaction %s not a node operation", ver.PayloadHash())
+	}
+
+	cp.Nodes = slices.DeleteFunc(cp.Nodes, func(n *Node) bool { return n.Signer == signer })
+	n := &Node{
+		Signer:      signer,
+		Payee:       payee,
+		Transaction: ver.PayloadHash(),
+		Timestamp:   timestamp,
+		State:       state,
+	}
+	cp.Nodes = append(cp.Nodes, n)
+	cp.sortNodes()
+	return n, nil
+}
+
+func (cp *Checkpoint) node(signer crypto.Key) *Node {
+	for _, n := range cp.Nodes {
+		if n.Signer == signer {
+			return n
+		}
+	}
+	return nil
+}
+
+func (cp *Checkpoint) nodeId(signer crypto.Key) crypto.Hash {
+	addr := common.Address{PublicSpendKey: signer}
+	addr.PrivateViewKey = signer.DeterministicHashDerive()
+	addr.PublicViewKey = addr.PrivateViewKey.Public()
+	return addr.Hash().ForNetwork(cp.Network)
+}
+
+func (cp *Checkpoint) inAcceptHour(timestamp uint64) bool {
+	if timestamp < cp.Epoch {
+		return false
+	}
+	hour := (timestamp - cp.Epoch) / uint64(time.Hour) % 24
+	return hour >= config.KernelNodeAcceptTimeBegin && hour <= config.KernelNodeAcceptTimeEnd
+}
+
+// sortNodes keeps the kernel order of nodes, by timestamp and then id.
+func (cp *Checkpoint) sortNodes() {
+	sort.SliceStable(cp.Nodes, func(i, j int) bool {
+		a, b := cp.Nodes[i], cp.Nodes[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp < b.Timestamp
+		}
+		return cp.nodeId(a.Signer).String() < cp.nodeId(b.Signer).String()
+	})
+}
+
+func (cp *Checkpoint) copy() *Checkpoint {
+	c := *cp
+	c.Genesis = slices.Clone(cp.Genesis)
+	c.Nodes = make([]*Node, len(cp.Nodes))
+	for i, n := range cp.Nodes {
+		cn := *n
+		c.Nodes[i] = &cn
+	}
+	return &c
+}
+
+func signersEqual(a, b []*Signer) bool {
+	return slices.EqualFunc(a, b, func(x, y *Signer) bool { return *x == *y })
+}
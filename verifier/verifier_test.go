@@ -0,0 +1,246 @@
+package verifier
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyProof(t *testing.T) {
+	require := require.New(t)
+
+	signers := make([]common.Address, 8)
+	payees := make([]common.Address, 8)
+	for i := range signers {
+		signers[i] = testNodeAddress(fmt.Sprintf("signer%d", i))
+		payees[i] = testNodeAddress(fmt.Sprintf("payee%d", i))
+	}
+	gns := testGenesis(signers[:7], payees[:7])
+	cp, err := GenesisCheckpoint(gns)
+	require.Nil(err)
+	require.Equal(gns.NetworkId(), cp.Network)
+	require.Len(cp.Nodes, 7)
+	require.Len(cp.Genesis, 7)
+	epoch := cp.Epoch
+
+	keys := make(map[crypto.Hash]crypto.Key)
+	genesis := make([]*Signer, 7)
+	for i, s := range signers {
+		id := s.Hash().ForNetwork(cp.Network)
+		keys[id] = s.PrivateSpendKey
+		if i < 7 {
+			genesis[i] = &Signer{Id: id, Key: s.PublicSpendKey}
+		}
+	}
+	sort.Slice(genesis, func(i, j int) bool { return genesis[i].Id.String() < genesis[j].Id.String() })
+
+	tx := common.NewTransactionV5(common.XINAssetId)
+	tx.AddInput(crypto.Blake3Hash([]byte("input")), 0)
+	ver := tx.AsVersioned()
+	f := testFinalization(ver, genesis[0].Id, 1, epoch+uint64(time.Hour), genesis, keys, 5)
+	proof := &Proof{Network: cp.Network, Finalization: *f}
+	got, snap, err := cp.Verify(proof)
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), got.PayloadHash())
+	require.Equal(uint64(1), snap.RoundNumber)
+
+	weak := testFinalization(ver, genesis[0].Id, 1, epoch+uint64(time.Hour), genesis, keys, 4)
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: *weak})
+	require.ErrorContains(err, "cosi.FullVerify")
+	shuffled := *f
+	shuffled.Consensus = append([]*Signer{genesis[1], genesis[0]}, genesis[2:]...)
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: shuffled})
+	require.ErrorContains(err, "consensus not match")
+	shrunk := *f
+	shrunk.Consensus = genesis[1:]
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: shrunk})
+	require.ErrorContains(err, "consensus 6 not match 7")
+	_, _, err = cp.Verify(&Proof{Network: crypto.Blake3Hash([]byte("other")), Finalization: *f})
+	require.ErrorContains(err, "proof network")
+	other := *f
+	other.Transaction = hex.EncodeToString(common.NewTransactionV5(common.XINAssetId).AsVersioned().Marshal())
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: other})
+	require.ErrorContains(err, "not in snapshot")
+
+	newId := signers[7].Hash().ForNetwork(cp.Network)
+	pledge := common.NewTransactionV5(common.XINAssetId)
+	pledge.AddInput(crypto.Blake3Hash([]byte("pledge")), 0)
+	pledge.Outputs = []*common.Output{{Type: common.OutputTypeNodePledge, Amount: common.KernelNodePledgeAmount}}
+	pledge.Extra = append(signers[7].PublicSpendKey[:], payees[7].PublicSpendKey[:]...)
+	accept := common.NewTransactionV5(common.XINAssetId)
+	accept.AddInput(pledge.AsVersioned().PayloadHash(), 0)
+	accept.Outputs = []*common.Output{{Type: common.OutputTypeNodeAccept, Amount: common.KernelNodePledgeAmount}}
+	accept.Extra = pledge.Extra
+	pledging := append(append([]*Signer{}, genesis...), &Signer{Id: newId, Key: signers[7].PublicSpendKey})
+	history := []*Finalization{
+		testFinalization(pledge.AsVersioned(), genesis[1].Id, 5, epoch+uint64(2*time.Hour), genesis, keys, 5),
+		testFinalization(accept.AsVersioned(), newId, 0, epoch+uint64(3*time.Hour), pledging, keys, 5),
+	}
+
+	all := append(append([]*Signer{}, genesis...), &Signer{Id: newId, Key: signers[7].PublicSpendKey})
+	timestamp := epoch + uint64(16*time.Hour)
+	f = testFinalization(ver, genesis[2].Id, 9, timestamp, all, keys, 6)
+	proof = &Proof{Network: cp.Network, Finalization: *f, History: history}
+	_, snap, err = cp.Verify(proof)
+	require.Nil(err)
+	require.Equal(timestamp, snap.Timestamp)
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: *f})
+	require.ErrorContains(err, "consensus 8 not match 7")
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: *f, History: history[1:]})
+	require.ErrorContains(err, "not pledging")
+
+	removing := testFinalization(ver, genesis[2].Id, 9, timestamp, all[1:], keys, 5)
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: *removing, History: history})
+	require.Nil(err)
+	outside := testFinalization(ver, genesis[2].Id, 9, timestamp+uint64(4*time.Hour), all[1:], keys, 5)
+	_, _, err = cp.Verify(&Proof{Network: cp.Network, Finalization: *outside, History: history})
+	require.ErrorContains(err, "consensus 7 not match 8")
+
+	next, err := cp.Advance(history)
+	require.Nil(err)
+	require.Equal(epoch+uint64(3*time.Hour), next.Timestamp)
+	require.Len(next.Nodes, 8)
+	require.Equal(common.NodeStateAccepted, next.Nodes[7].State)
+	require.Equal(epoch, cp.Timestamp)
+	require.Len(cp.Nodes, 7)
+	_, _, err = next.Verify(&Proof{Network: cp.Network, Finalization: *f})
+	require.Nil(err)
+	_, _, err = next.Verify(proof)
+	require.ErrorContains(err, "before checkpoint")
+
+	next, operations, err := cp.Follow(history)
+	require.Nil(err)
+	require.Len(operations, 2)
+	require.Equal(common.NodeStatePledging, operations[0].State)
+	require.Equal(common.NodeStateAccepted, operations[1].State)
+	require.Equal(next.Nodes, cp.At(operations, timestamp).Nodes)
+	at := cp.At(operations, epoch+uint64(3*time.Hour))
+	require.Equal(epoch+uint64(2*time.Hour), at.Timestamp)
+	require.Equal(common.NodeStatePledging, at.Nodes[7].State)
+	require.Len(cp.At(operations, epoch+uint64(2*time.Hour)).Nodes, 7)
+
+	require.Nil(next.VerifySnapshot(testSnapshot(f)))
+	require.Nil(next.VerifySnapshot(testSnapshot(removing)))
+	require.Nil(at.VerifySnapshot(testSnapshot(history[1])))
+	require.Nil(cp.VerifySnapshot(testSnapshot(history[0])))
+	require.ErrorContains(next.VerifySnapshot(testSnapshot(outside)), "cosi.FullVerify")
+	require.ErrorContains(cp.VerifySnapshot(testSnapshot(weak)), "cosi.FullVerify")
+	require.ErrorContains(next.VerifySnapshot(testSnapshot(testFinalization(ver, genesis[2].Id, 9, timestamp+uint64(4*time.Hour), all, keys, 5))), "cosi.FullVerify")
+
+	data, err := json.Marshal(proof)
+	require.Nil(err)
+	var decoded Proof
+	err = json.Unmarshal(data, &decoded)
+	require.Nil(err)
+	_, _, err = cp.Verify(&decoded)
+	require.Nil(err)
+}
+
+func testFinalization(ver *common.VersionedTransaction, node crypto.Hash, round, timestamp uint64, consensus []*Signer, keys map[crypto.Hash]crypto.Key, count int) *Finalization {
+	s := &common.Snapshot{
+		Version:     common.SnapshotVersionCommonEncoding,
+		NodeId:      node,
+		RoundNumber: round,
+		Timestamp:   timestamp,
+	}
+	if round > 0 {
+		s.References = &common.RoundLink{Self: crypto.Blake3Hash([]byte("self")), External: crypto.Blake3Hash([]byte("external"))}
+	}
+	s.AddTransaction(ver.PayloadHash())
+	s.Hash = s.PayloadHash()
+
+	publics := make([]*crypto.Key, len(consensus))
+	for i, c := range consensus {
+		publics[i] = &c.Key
+	}
+	randoms := make(map[int]*crypto.Key)
+	commitments := make(map[int]*crypto.Key)
+	for i := range count {
+		seed := crypto.Blake3Hash(fmt.Appendf(nil, "%s%d", s.Hash, i))
+		r := crypto.NewKeyFromSeed(append(seed[:], seed[:]...))
+		R := r.Public()
+		randoms[i] = &r
+		commitments[i] = &R
+	}
+	cosi, err := crypto.CosiAggregateCommitment(commitments)
+	if err != nil {
+		panic(err)
+	}
+	responses := make(map[int]*[32]byte)
+	for i := range count {
+		priv := keys[consensus[i].Id]
+		res, err := cosi.Response(&priv, randoms[i], publics, s.Hash)
+		if err != nil {
+			panic(err)
+		}
+		responses[i] = res
+	}
+	err = cosi.AggregateResponse(publics, responses, s.Hash, true)
+	if err != nil {
+		panic(err)
+	}
+	s.Signature = cosi
+
+	topo := &common.SnapshotWithTopologicalOrder{Snapshot: s, TopologicalOrder: round}
+	return &Finalization{
+		Transaction: hex.EncodeToString(ver.Marshal()),
+		Snapshot:    hex.EncodeToString(topo.VersionedMarshal()),
+		Consensus:   consensus,
+	}
+}
+
+func testSnapshot(f *Finalization) *common.Snapshot {
+	raw, err := hex.DecodeString(f.Snapshot)
+	if err != nil {
+		panic(err)
+	}
+	s, err := common.UnmarshalVersionedSnapshot(raw)
+	if err != nil {
+		panic(err)
+	}
+	s.Hash = s.PayloadHash()
+	return s.Snapshot
+}
+
+func testGenesis(signers, payees []common.Address) *common.Genesis {
+	custodian := testNodeAddress("custodian")
+	nodes := make([]map[string]any, len(signers))
+	for i := range signers {
+		nodes[i] = map[string]any{
+			"signer":    signers[i],
+			"payee":     payees[i],
+			"custodian": custodian,
+			"balance":   common.KernelNodePledgeAmount,
+		}
+	}
+	data, err := json.Marshal(map[string]any{
+		"epoch":     1700006400,
+		"nodes":     nodes,
+		"custodian": custodian,
+	})
+	if err != nil {
+		panic(err)
+	}
+	var gns common.Genesis
+	err = json.Unmarshal(data, &gns)
+	if err != nil {
+		panic(err)
+	}
+	return &gns
+}
+
+func testNodeAddress(seed string) common.Address {
+	h := crypto.Blake3Hash([]byte(seed))
+	priv := crypto.NewKeyFromSeed(append(h[:], h[:]...))
+	addr := common.Address{PrivateSpendKey: priv, PublicSpendKey: priv.Public()}
+	addr.PrivateViewKey = addr.PublicSpendKey.DeterministicHashDerive()
+	addr.PublicViewKey = addr.PrivateViewKey.Public()
+	return addr
+}